@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements `smoke config <command>`. Returns the process exit
+// code.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "resolve" {
+		fmt.Fprintf(os.Stderr, "Usage: %s config resolve [-checks FILE] [-profile NAMES] [options]\n", os.Args[0])
+		return 2
+	}
+	return runConfigResolve(args[1:])
+}
+
+// runConfigResolve implements `smoke config resolve`, which prints the
+// effective config a run would use: includes and profiles merged, defaults
+// applied, CLI timeout and retry settings filled in, and checks selected, so
+// layered configs stay debuggable. The output is itself a valid checks file.
+func runConfigResolve(args []string) int {
+	fs := flag.NewFlagSet("config resolve", flag.ExitOnError)
+	checksFile := fs.String("checks", "", "Path to checks YAML file, or - for stdin (default: auto-discover)")
+	profile := fs.String("profile", "", "Apply these comma-separated profiles, in order")
+	strict := fs.Bool("strict", false, "Reject unknown fields in the checks file")
+	timeout := fs.Duration("timeout", 30*time.Second, "Default timeout for checks, as passed to a run")
+	maxRetries := fs.Int("retries", 3, "Maximum retries for failing checks, as passed to a run")
+	retryDelay := fs.Duration("retry-delay", 2*time.Second, "Delay between retries, as passed to a run")
+	only := fs.String("only", "", "Keep only checks whose name or ID matches one of these comma-separated globs")
+	skip := fs.String("skip", "", "Drop checks whose name or ID matches one of these comma-separated globs")
+	layers := fs.String("layers", "", "Keep only these layers: N, N-M, N-, or -M")
+	_ = fs.Parse(args)
+
+	checksPath := *checksFile
+	if checksPath == "" {
+		checksPath = findChecksFile()
+		if checksPath == "" {
+			fmt.Fprintf(os.Stderr, "Error: checks.yaml not found\n")
+			return 2
+		}
+	}
+
+	profiles := splitPatterns(*profile)
+	cfg, err := config.LoadConfigWith(checksPath, config.LoadOptions{Strict: *strict, Profiles: profiles})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 2
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return 2
+	}
+	if err := cfg.Select(splitPatterns(*only), splitPatterns(*skip)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *layers != "" {
+		lo, hi, err := config.ParseLayerRange(*layers)
+		if err == nil {
+			err = cfg.SelectLayers(lo, hi)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	// Fill in what the run would take from CLI flags
+	for i := range cfg.Checks {
+		check := &cfg.Checks[i]
+		check.Timeout = config.Duration{Duration: check.GetTimeout(*timeout)}
+		if check.IsRetry() {
+			retries := check.GetMaxRetries(*maxRetries)
+			check.Retry = &config.RetryConfig{
+				Max:     &retries,
+				Delay:   config.Duration{Duration: check.GetRetryDelay(*retryDelay)},
+				Backoff: check.GetRetryBackoff(),
+			}
+			check.RetryDelay, check.RetryBackoff = config.Duration{}, nil
+		}
+	}
+
+	applied := "none"
+	if len(profiles) > 0 {
+		applied = strings.Join(profiles, ", ")
+	}
+	fmt.Printf("# Effective config for %s (profiles: %s)\n", checksPath, applied)
+	fmt.Printf("# Precedence, lowest first: includes, file, profiles, defaults, CLI flags\n")
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if err := encoder.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
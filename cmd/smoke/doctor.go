@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// runDoctor implements `smoke doctor`, which verifies a run's
+// prerequisites without running any checks: the checks file lints clean
+// (scripts executable, templates render), every tool the enabled checks
+// need is on PATH, and the kube context exists. Returns the process exit
+// code: 0 when ready, 1 when not, and 2 if the checks file can't be read.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	checksFile := fs.String("checks", "", "Path to checks YAML file (default: auto-discover)")
+	cluster := fs.String("cluster", "home", "Cluster name for rendering templates")
+	namespace := fs.String("namespace", "", "Kubernetes namespace for rendering templates")
+	kubeContext := fs.String("context", "", "kubectl context to verify (default: the current context)")
+	_ = fs.Parse(args)
+
+	checksPath := *checksFile
+	if checksPath == "" {
+		checksPath = findChecksFile()
+		if checksPath == "" {
+			fmt.Fprintf(os.Stderr, "Error: checks.yaml not found\n")
+			return 2
+		}
+	}
+
+	vars := config.TemplateVars{Cluster: *cluster, Namespace: *namespace, Context: *kubeContext}
+	problems, err := config.Lint(checksPath, vars)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	cfg, err := config.LoadConfig(checksPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	fmt.Printf("Checking prerequisites for %s\n\n", checksPath)
+	ready, total := 0, 0
+	report := func(ok bool, name, detail string) {
+		total++
+		if ok {
+			ready++
+			fmt.Printf("ok   %s: %s\n", name, detail)
+		} else {
+			fmt.Printf("FAIL %s: %s\n", name, detail)
+		}
+	}
+
+	// Config: scripts, templates, and values
+	if len(problems) == 0 {
+		report(true, "config", fmt.Sprintf("%d checks, no problems", len(cfg.Checks)))
+	} else {
+		report(false, "config", fmt.Sprintf("%d problem(s), see `%s validate`", len(problems), os.Args[0]))
+	}
+
+	// Tools: implied by check kinds or declared with requires
+	neededBy := make(map[string][]string)
+	now := time.Now()
+	for i := range cfg.Checks {
+		check := &cfg.Checks[i]
+		if check.Disabled.IsActive(now) {
+			continue
+		}
+		for _, tool := range check.RequiredTools() {
+			neededBy[tool] = append(neededBy[tool], check.Name)
+		}
+	}
+	tools := make([]string, 0, len(neededBy))
+	for tool := range neededBy {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		if path, err := osexec.LookPath(tool); err == nil {
+			report(true, "tool "+tool, path)
+		} else {
+			report(false, "tool "+tool, "not found on PATH (needed by "+strings.Join(neededBy[tool], ", ")+")")
+		}
+	}
+
+	// Kube context: only when some check talks to the cluster
+	if _, ok := neededBy["kubectl"]; ok {
+		if _, err := osexec.LookPath("kubectl"); err == nil {
+			ok, detail := kubeContextExists(*kubeContext)
+			report(ok, "kube context", detail)
+		}
+	}
+
+	fmt.Printf("\n%d/%d prerequisites ready\n", ready, total)
+	if ready < total {
+		return 1
+	}
+	return 0
+}
+
+// kubeContextExists checks that the named kube context exists, or that a
+// current context is set if name is empty. Returns the result and a detail.
+func kubeContextExists(name string) (bool, string) {
+	ctx := context.Background()
+	if name == "" {
+		res := exec.RunCommand(ctx, "kubectl config current-context", 10*time.Second)
+		if res.Error != nil || res.ExitCode != 0 {
+			return false, "no current context set (pass -context)"
+		}
+		return true, strings.TrimSpace(res.Output) + " (current)"
+	}
+
+	res := exec.RunCommand(ctx, "kubectl config get-contexts -o name", 10*time.Second)
+	if res.Error != nil || res.ExitCode != 0 {
+		return false, fmt.Sprintf("kubectl config get-contexts failed: %s", strings.TrimSpace(res.Output))
+	}
+	for _, line := range strings.Split(res.Output, "\n") {
+		if strings.TrimSpace(line) == name {
+			return true, name
+		}
+	}
+	return false, fmt.Sprintf("context %s not found in kubeconfig", name)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// runFmt implements `smoke fmt [-w] [-l] [FILE]`, which rewrites a checks
+// file in canonical form. Without -w the result is printed to stdout; with
+// -l only the file name is printed, and the exit code is 1 if it needs
+// formatting. Returns the process exit code.
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("w", false, "Write the result back to the file instead of stdout")
+	list := fs.Bool("l", false, "List the file if it isn't formatted and exit 1")
+	_ = fs.Parse(args)
+	if fs.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s fmt [-w] [-l] [FILE]\n", os.Args[0])
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		path = findChecksFile()
+		if path == "" {
+			fmt.Fprintf(os.Stderr, "Error: checks.yaml not found\n")
+			return 2
+		}
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Path is user-provided config file
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	formatted, err := config.Format(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+		return 2
+	}
+
+	changed := !bytes.Equal(data, formatted)
+	switch {
+	case *list:
+		if changed {
+			fmt.Println(path)
+			return 1
+		}
+	case *write:
+		if changed {
+			if err := os.WriteFile(path, formatted, 0o644); err != nil { //nolint:gosec // Config files are meant to be readable
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 2
+			}
+		}
+	default:
+		_, _ = os.Stdout.Write(formatted)
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/generate"
+)
+
+// runGenerate implements `smoke generate checks --from-cluster`, which
+// writes a starter checks.yaml for the objects found in a cluster.
+// Returns the process exit code.
+func runGenerate(args []string) int {
+	if len(args) == 0 || args[0] != "checks" {
+		fmt.Fprintf(os.Stderr, "Usage: %s generate checks --from-cluster [-namespace NS] [-context CTX] [-o FILE]\n", os.Args[0])
+		return 2
+	}
+
+	fs := flag.NewFlagSet("generate checks", flag.ExitOnError)
+	fromCluster := fs.Bool("from-cluster", false, "Generate checks from the cluster's Deployments, Ingresses, PVCs, and CronJobs")
+	namespace := fs.String("namespace", "", "Namespace to inspect (default: all namespaces)")
+	kubeContext := fs.String("context", "", "kubectl context to inspect")
+	output := fs.String("o", "", "Write to this file instead of stdout (must not exist)")
+	_ = fs.Parse(args[1:])
+
+	if !*fromCluster {
+		fmt.Fprintf(os.Stderr, "Error: a source is required (--from-cluster)\n")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	inv, err := generate.LoadInventory(ctx, *kubeContext, *namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	checks := generate.Checks(inv)
+
+	scope := "all namespaces"
+	if *namespace != "" {
+		scope = "namespace " + *namespace
+	}
+	header := fmt.Sprintf("Generated by smoke generate checks --from-cluster (%s).\n"+
+		"Starter checks: review, prune, and adjust layers and gating before use.", scope)
+
+	return writeChecks(*output, header, checks)
+}
+
+// writeChecks writes generated checks to stdout, or to output if set
+// (refusing to overwrite an existing file). Returns the process exit code.
+func writeChecks(output, header string, checks []config.Check) int {
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	if err := generate.Write(w, header, checks); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %d checks to %s\n", len(checks), output)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/generate"
+)
+
+// importers maps each `smoke import` source to its translator.
+var importers = map[string]func([]byte) ([]config.Check, []string, error){
+	"gatus":       generate.ImportGatus,
+	"uptime-kuma": generate.ImportUptimeKuma,
+}
+
+// runImport implements `smoke import gatus|uptime-kuma FILE`, which
+// translates another monitor's config into a checks.yaml. Returns the
+// process exit code.
+func runImport(args []string) int {
+	usage := fmt.Sprintf("Usage: %s import gatus|uptime-kuma [-o FILE] CONFIG\n", os.Args[0])
+	if len(args) == 0 || importers[args[0]] == nil {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	kind := args[0]
+
+	fs := flag.NewFlagSet("import "+kind, flag.ExitOnError)
+	output := fs.String("o", "", "Write to this file instead of stdout (must not exist)")
+	_ = fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	source := fs.Arg(0)
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	checks, warnings, err := importers[kind](data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	header := fmt.Sprintf("Imported by smoke import %s from %s.", kind, source)
+	if len(warnings) > 0 {
+		header += "\n\nNot imported:\n  " + strings.Join(warnings, "\n  ")
+	}
+	return writeChecks(*output, header, checks)
+}
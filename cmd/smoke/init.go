@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erauner/homelab-smoke/pkg/generate"
+)
+
+// runInit implements `smoke init [DIR]`, which scaffolds a starter
+// checks.yaml and example script. Returns the process exit code.
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	_ = fs.Parse(args)
+	if fs.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s init [DIR]\n", os.Args[0])
+		return 2
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+
+	written, err := generate.Scaffold(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	for _, path := range written {
+		fmt.Printf("Created %s\n", path)
+	}
+	fmt.Printf("\nNext: edit the checks, then run `%s validate` and `%s -v`.\n", os.Args[0], os.Args[0])
+	return 0
+}
@@ -2,17 +2,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/erauner/homelab-go-utils/formatting"
 	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/report"
 	"github.com/erauner/homelab-smoke/pkg/runner"
 )
 
@@ -23,21 +31,92 @@ var (
 )
 
 func main() {
+	// Handle subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "selftest":
+			os.Exit(runSelftest(os.Args[2:]))
+		case "generate":
+			os.Exit(runGenerate(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "init":
+			os.Exit(runInit(os.Args[2:]))
+		case "fmt":
+			os.Exit(runFmt(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		}
+	}
+
 	// Define flags
-	checksFile := flag.String("checks", "", "Path to checks YAML file (default: checks.yaml in same dir as binary)")
+	checksFile := flag.String("checks", "", "Path to checks YAML file, or - for stdin (default: checks.yaml in same dir as binary)")
 	cluster := flag.String("cluster", "home", "Cluster name for template variables")
 	namespace := flag.String("namespace", "", "Kubernetes namespace for template variables")
 	kubeContext := flag.String("context", "", "kubectl context for template variables")
+	varFiles := fileListFlag{}
+	flag.Var(&varFiles, "var-file", "Load template variables from this YAML file, decrypting it if it is SOPS-encrypted (repeatable)")
 	timeout := flag.Duration("timeout", 30*time.Second, "Default timeout for checks")
 	maxRetries := flag.Int("retries", 3, "Maximum retries for failing checks")
 	retryDelay := flag.Duration("retry-delay", 2*time.Second, "Delay between retries")
-	verbose := flag.Bool("v", false, "Verbose output (show all check output)")
+	flakyWarn := flag.Bool("flaky-warn", false, "Report checks that pass only after retries as WARN instead of PASS")
+	parallel := flag.Int("parallel", 1, "Maximum checks to run concurrently within a layer")
+	repeat := flag.Int("repeat", 1, "Run the checks N times and report each check's pass rate, to confirm a check is deterministic")
+	soak := flag.Duration("soak", 0, "Run the checks every -interval for this long and report each check's availability and flake rate (e.g., 2h)")
+	interval := flag.Duration("interval", time.Minute, "Time between run starts in -soak mode")
+	jitter := flag.Float64("jitter", 0, "Shorten each -soak interval by a random fraction up to this (0-1), so soaking runners don't probe in lockstep")
+	retainOutput := config.ByteSize(64 * 1024)
+	flag.Var(&retainOutput, "retain-output", "Bytes of each check's output kept in memory after it is reported, e.g. 64KiB (0 = unlimited)")
+	logTimestamps := flag.Bool("log-timestamps", false, "Prefix output lines with RFC3339 timestamps and disable colors")
+	verbose := flag.Bool("v", false, "Verbose output (stream check output live)")
+	noColor := flag.Bool("no-color", false, "Disable ANSI colors (also set by NO_COLOR or when stdout isn't a terminal)")
+	forceColor := flag.Bool("force-color", false, "Use ANSI colors even when stdout isn't a terminal")
+	quiet := flag.Bool("quiet", false, "Print only failures, warnings, errors, and the summary")
+	tui := flag.Bool("tui", false, "Show a full-screen dashboard of the run: live check table, the selected check's output, and the summary")
+	groupBy := flag.String("group-by", "", "Group failures in the summary by check owner or team: owner or team")
+	profile := flag.String("profile", "", "Apply these comma-separated profiles from the checks file, in order")
+	strict := flag.Bool("strict", false, "Reject unknown fields in the checks file")
+	flag.BoolVar(strict, "strict-config", false, "Alias for -strict")
+	only := flag.String("only", "", "Run only checks whose name or ID matches one of these comma-separated globs")
+	skip := flag.String("skip", "", "Skip checks whose name or ID matches one of these comma-separated globs")
+	layers := flag.String("layers", "", "Run only these layers: N, N-M, N-, or -M")
+	maxLayer := flag.Int("max-layer", -1, "Run only layers up to N (default: all)")
+	sample := flag.String("sample", "", "Run all gating checks plus a random sample of the rest: a share like 20% or a count like 25")
+	sampleSeed := flag.Uint64("sample-seed", 0, "Seed for -sample, to repeat a run's selection (default: random)")
+	shuffle := flag.String("shuffle", "off", "Randomize check order within layers to find ordering dependencies: off, on, or a seed")
+	policyFile := flag.String("policy", "", "Enforce constraints from this policy file (max timeout, required tags, forbidden commands, owners)")
+	publishURL := flag.String("publish-url", "", "POST the JSON run report to this URL (e.g., an Argo Events webhook EventSource)")
+	summaryFile := flag.String("summary-file", "", "Write a compact JSON summary (outcome, counts, gating failures, exit code) to this path")
+	junitFile := flag.String("junit-file", "", "Write a JUnit XML test report to this path (e.g., for GitLab artifacts:reports:junit)")
+	artifactsDir := flag.String("artifacts-dir", "", "Write each check's full output to <dir>/<check-id>.log, with its diagnostics output and a <check-id>.json metadata file")
+	dotenvFile := flag.String("dotenv-file", "", "Write the run result as dotenv variables to this path (e.g., for GitLab artifacts:reports:dotenv)")
+	gha := flag.Bool("gha", false, "Emit GitHub Actions annotations and a job summary (default: on when GITHUB_ACTIONS=true)")
+	labels := labelFlag{}
+	flag.Var(labels, "label", "Attach a key=value label to the run's report and summary (repeatable)")
+	bench := flag.Int("bench", 0, "Benchmark the runner with N synthetic no-op checks and exit")
+	cpuProfile := flag.String("cpuprofile", "", "Write a CPU profile of the -bench run to this path")
+	memProfile := flag.String("memprofile", "", "Write a heap profile taken after the -bench run to this path")
 	listChecks := flag.Bool("list-checks", false, "List configured checks and exit")
 	showVersion := flag.Bool("version", false, "Print version information and exit")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Homelab Smoke Test Runner\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s selftest [-v] [-parallel N]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s generate checks --from-cluster [-namespace NS] [-context CTX] [-o FILE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s import gatus|uptime-kuma [-o FILE] CONFIG\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s init [DIR]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s fmt [-w] [-l] [FILE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s migrate [-w] [FILE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s validate [-checks FILE] [-cluster NAME] [-namespace NS] [-context CTX] [-var-file FILE] [-policy FILE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s doctor [-checks FILE] [-cluster NAME] [-namespace NS] [-context CTX]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s config resolve [-checks FILE] [-profile NAMES] [-only ...] [-skip ...] [-layers ...]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nTemplate Variables:\n")
@@ -51,7 +130,12 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s -cluster=home -context=home-admin\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -checks=custom-checks.yaml -v\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -label deploy=media-stack -publish-url=https://events.lab/smoke\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -list-checks\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -only='argocd-*' -v\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -layers=1-3\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s selftest\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s validate -checks=checks.yaml\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -62,6 +146,60 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Handle bench flag
+	if (*cpuProfile != "" || *memProfile != "") && *bench <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -cpuprofile and -memprofile require -bench\n")
+		os.Exit(2)
+	}
+	if *bench > 0 {
+		if err := runBenchmark(*bench, *parallel, *cpuProfile, *memProfile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		os.Exit(0)
+	}
+
+	if *groupBy != "" && *groupBy != "owner" && *groupBy != "team" {
+		fmt.Fprintf(os.Stderr, "Error: -group-by must be owner or team, got %q\n", *groupBy)
+		os.Exit(2)
+	}
+	if *quiet && *verbose {
+		fmt.Fprintf(os.Stderr, "Error: -quiet and -v cannot be used together\n")
+		os.Exit(2)
+	}
+	if *tui && (*quiet || *verbose || *logTimestamps || *repeat > 1 || *soak > 0) {
+		fmt.Fprintf(os.Stderr, "Error: -tui cannot be combined with -quiet, -v, -log-timestamps, -repeat, or -soak\n")
+		os.Exit(2)
+	}
+	if *tui && !runner.IsTerminal(os.Stdout) {
+		fmt.Fprintf(os.Stderr, "Error: -tui needs a terminal\n")
+		os.Exit(2)
+	}
+	if *noColor && *forceColor {
+		fmt.Fprintf(os.Stderr, "Error: -no-color and -force-color cannot be used together\n")
+		os.Exit(2)
+	}
+	if *repeat < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -repeat must be at least 1\n")
+		os.Exit(2)
+	}
+	if *soak < 0 || (*soak > 0 && *interval <= 0) {
+		fmt.Fprintf(os.Stderr, "Error: -soak and -interval must be positive\n")
+		os.Exit(2)
+	}
+	if *jitter < 0 || *jitter > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -jitter must be between 0 and 1\n")
+		os.Exit(2)
+	}
+	if *soak > 0 && *repeat > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -soak and -repeat cannot be used together\n")
+		os.Exit(2)
+	}
+	if (*repeat > 1 || *soak > 0) && (*publishURL != "" || *summaryFile != "" || *junitFile != "" || *artifactsDir != "" || *dotenvFile != "") {
+		fmt.Fprintf(os.Stderr, "Error: -repeat and -soak cannot be combined with report outputs (-publish-url, -summary-file, -junit-file, -artifacts-dir, -dotenv-file)\n")
+		os.Exit(2)
+	}
+
 	// Find checks file
 	checksPath := *checksFile
 	if checksPath == "" {
@@ -74,24 +212,84 @@ func main() {
 	}
 
 	// Load configuration
-	cfg, err := config.LoadConfig(checksPath)
+	cfg, err := config.LoadConfigWith(checksPath, config.LoadOptions{Strict: *strict, Profiles: splitPatterns(*profile)})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(2)
 	}
 
+	// Enforce policy as part of validation
+	if *policyFile != "" {
+		policy, err := config.LoadPolicy(*policyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+			os.Exit(2)
+		}
+		policy.DefaultTimeout = *timeout
+		cfg.Policy = policy
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
 		os.Exit(2)
 	}
 
+	// Select checks
+	if err := cfg.Select(splitPatterns(*only), splitPatterns(*skip)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Select layers
+	if *layers != "" || *maxLayer >= 0 {
+		lo, hi, err := config.ParseLayerRange(*layers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if *maxLayer >= 0 {
+			hi = min(hi, *maxLayer)
+		}
+		if err := cfg.SelectLayers(lo, hi); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	// Sample non-gating checks
+	sampled := ""
+	if *sample != "" {
+		seed := *sampleSeed
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		kept, pool, err := cfg.SelectSample(*sample, rand.New(rand.NewPCG(seed, seed)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		sampled = fmt.Sprintf("%d/%d non-gating checks (-sample-seed=%d)", kept, pool, seed)
+	}
+
 	// Handle list-checks flag
 	if *listChecks {
 		listConfiguredChecks(cfg)
 		os.Exit(0)
 	}
 
+	// Shuffle check order within layers
+	shuffled := ""
+	seed, ok, err := config.ParseShuffle(*shuffle)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if ok {
+		cfg.Shuffle(rand.New(rand.NewPCG(seed, seed)))
+		shuffled = fmt.Sprintf("-shuffle=%d", seed)
+	}
+
 	// Determine checks directory
 	checksDir := filepath.Dir(checksPath)
 
@@ -102,23 +300,72 @@ func main() {
 		Context:   *kubeContext,
 	}
 
-	// Print header
-	fmt.Printf("Homelab Smoke Tests\n")
-	fmt.Printf("  Cluster:   %s\n", vars.Cluster)
+	// Resolve secrets and variables before any command runs
+	varsCtx, varsCancel := context.WithTimeout(context.Background(), *timeout)
+	err = resolveVars(varsCtx, cfg, &vars, checksDir, varFiles)
+	varsCancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Set up output
+	var out io.Writer = os.Stdout
+	if *logTimestamps {
+		out = runner.NewTimestampWriter(os.Stdout)
+	}
+
+	// Print header (left out in quiet mode)
+	header := out
+	if *quiet {
+		header = io.Discard
+	}
+	fmt.Fprintf(header, "Homelab Smoke Tests\n")
+	fmt.Fprintf(header, "  Cluster:   %s\n", vars.Cluster)
 	if vars.Namespace != "" {
-		fmt.Printf("  Namespace: %s\n", vars.Namespace)
+		fmt.Fprintf(header, "  Namespace: %s\n", vars.Namespace)
 	}
 	if vars.Context != "" {
-		fmt.Printf("  Context:   %s\n", vars.Context)
+		fmt.Fprintf(header, "  Context:   %s\n", vars.Context)
+	}
+	fmt.Fprintf(header, "  Checks:    %d\n", len(cfg.Checks))
+	if sampled != "" {
+		fmt.Fprintf(header, "  Sampled:   %s\n", sampled)
+	}
+	if shuffled != "" {
+		fmt.Fprintf(header, "  Shuffled:  %s\n", shuffled)
+	}
+
+	// Capture baseline cluster state for the report
+	var snapshot *report.Snapshot
+	if cfg.Snapshot != nil {
+		snapshotCtx, snapshotCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		snapshot = report.TakeSnapshot(snapshotCtx, cfg.Snapshot, vars.Context, cfg.Environment.Environ(os.Environ()))
+		snapshotCancel()
+		fmt.Fprintf(header, "  Snapshot:  %s\n", snapshot.Describe())
 	}
-	fmt.Printf("  Checks:    %d\n\n", len(cfg.Checks))
+	fmt.Fprintln(header)
 
 	// Create runner
 	r := runner.NewRunner(cfg, checksDir, vars)
 	r.DefaultTimeout = *timeout
 	r.MaxRetries = *maxRetries
 	r.RetryDelay = *retryDelay
+	r.FlakyAsWarn = *flakyWarn
 	r.Verbose = *verbose
+	r.Quiet = *quiet
+	r.GroupBy = *groupBy
+	r.Parallel = *parallel
+	r.RetainOutputBytes = int(retainOutput)
+	r.Output = out
+	r.NoColor = *logTimestamps || !runner.UseColor(os.Stdout, *forceColor, *noColor)
+	if *artifactsDir != "" {
+		r.OnResult = func(res runner.CheckExecutionResult) {
+			if err := report.WriteArtifacts(*artifactsDir, res); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
 
 	// Set up context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -132,18 +379,275 @@ func main() {
 		cancel()
 	}()
 
+	// Repeat the run for stability testing or burn-in; reports and hooks
+	// are for single runs
+	if *repeat > 1 {
+		os.Exit(runRepeat(ctx, r, *repeat))
+	}
+	if *soak > 0 {
+		os.Exit(runSoak(ctx, r, *soak, *interval, *jitter))
+	}
+
 	// Run checks with timing
 	startTime := time.Now()
-	result := r.Run(ctx)
-	totalDuration := time.Since(startTime)
+	var result *runner.RunResult
+	var totalDuration time.Duration
+	if *tui {
+		result, totalDuration = runDashboard(ctx, cancel, r)
+	} else {
+		result = r.Run(ctx)
+		totalDuration = time.Since(startTime)
+	}
 
 	// Print summary with duration
 	r.PrintSummary(result, formatting.Duration(totalDuration))
 
+	// Build machine-readable report
+	rep := report.New(result, vars.Cluster, startTime, totalDuration)
+	if len(labels) > 0 {
+		rep.Labels = labels
+	}
+	rep.Snapshot = snapshot
+	if cfg.Summary != nil {
+		rep.ApplyStyle(cfg.Summary.JSON)
+	}
+
+	// Write summary file for CI gates
+	if *summaryFile != "" {
+		if err := report.WriteFile(*summaryFile, rep.Summary()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Write CI test report and result variables (e.g., GitLab artifacts)
+	if *junitFile != "" {
+		var buf bytes.Buffer
+		if err := report.WriteJUnit(&buf, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if err := os.WriteFile(*junitFile, buf.Bytes(), 0644); err != nil { //nolint:gosec // Report files are meant to be readable
+			fmt.Fprintf(os.Stderr, "Warning: failed to write %s: %v\n", *junitFile, err)
+		}
+	}
+	if *dotenvFile != "" {
+		if err := os.WriteFile(*dotenvFile, []byte(report.GitLabDotenv(rep)), 0644); err != nil { //nolint:gosec // Report files are meant to be readable
+			fmt.Fprintf(os.Stderr, "Warning: failed to write %s: %v\n", *dotenvFile, err)
+		}
+	}
+
+	// Annotate GitHub Actions runs
+	if *gha || os.Getenv("GITHUB_ACTIONS") == "true" {
+		if err := report.WriteGitHubAnnotations(os.Stdout, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+			if err := report.AppendFile(path, report.GitHubStepSummary(rep)); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+
+	// Trigger gating failure hook (e.g., automated rollback) on any
+	// blocking result, including ERROR
+	if hook := cfg.OnGatingFailure; hook != nil && len(result.GatingFailures()) > 0 {
+		runGatingFailureHook(r, hook, result, rep)
+	}
+
+	// Publish report to event endpoint
+	if *publishURL != "" {
+		publishCtx, publishCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := report.Publish(publishCtx, *publishURL, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		publishCancel()
+	}
+
 	// Exit with appropriate code
 	os.Exit(result.ExitCode())
 }
 
+// splitPatterns splits a comma-separated -only, -skip, or -profile value.
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// describeOwner returns a check's owner and team, e.g. "erin (platform)".
+func describeOwner(check *config.Check) string {
+	switch {
+	case check.Owner != "" && check.Team != "":
+		return fmt.Sprintf("%s (%s)", check.Owner, check.Team)
+	case check.Team != "":
+		return "team " + check.Team
+	}
+	return check.Owner
+}
+
+// labelFlag collects repeated -label key=value flags.
+type labelFlag map[string]string
+
+func (l labelFlag) String() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k+"="+l[k])
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func (l labelFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("label must be key=value, got %q", value)
+	}
+	l[strings.TrimSpace(key)] = val
+	return nil
+}
+
+// fileListFlag collects repeated file path flags.
+type fileListFlag []string
+
+func (f *fileListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// resolveVars adds the config's secrets, then its vars_from objects,
+// then the -var-file files to vars, later sources overriding earlier
+// ones. Values from Secrets and SOPS-encrypted files are secrets,
+// available as {{.Secret.<name>}} and masked in output; others are
+// available as {{.Custom.<name>}}.
+func resolveVars(ctx context.Context, cfg *config.Config, vars *config.TemplateVars, checksDir string, varFiles []string) error {
+	secrets, err := cfg.ResolveSecrets(ctx, checksDir)
+	if err != nil {
+		return err
+	}
+	addVars(&vars.Secret, secrets)
+
+	custom, secrets, err := cfg.ResolveVarsFrom(ctx, vars.Context, vars.Namespace)
+	if err != nil {
+		return err
+	}
+	addVars(&vars.Custom, custom)
+	addVars(&vars.Secret, secrets)
+
+	for _, path := range varFiles {
+		values, encrypted, err := config.LoadVarFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		if encrypted {
+			addVars(&vars.Secret, values)
+		} else {
+			addVars(&vars.Custom, values)
+		}
+	}
+	return nil
+}
+
+// addVars copies values into *target, creating it if needed.
+func addVars(target *map[string]string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	if *target == nil {
+		*target = make(map[string]string, len(values))
+	}
+	for name, value := range values {
+		(*target)[name] = value
+	}
+}
+
+// runGatingFailureHook runs the on_gating_failure command and webhook.
+// Hook failures are reported but never change the run's exit code.
+func runGatingFailureHook(r *runner.Runner, hook *config.HookConfig, result *runner.RunResult, rep *report.Report) {
+	ctx, cancel := context.WithTimeout(context.Background(), hook.GetTimeout(60*time.Second))
+	defer cancel()
+
+	if hook.Command != "" {
+		fmt.Fprintf(r.Output, "\nRunning on_gating_failure hook...\n")
+		hookResult := r.RunHookCommand(ctx, hook, result)
+		if hookResult.Output != "" {
+			fmt.Fprint(r.Output, hookResult.Output)
+		}
+		if hookResult.Error != nil {
+			fmt.Fprintf(os.Stderr, "Warning: on_gating_failure command: %v\n", hookResult.Error)
+		} else if hookResult.ExitCode != 0 {
+			fmt.Fprintf(os.Stderr, "Warning: on_gating_failure command exited with code %d\n", hookResult.ExitCode)
+		}
+	}
+
+	if hook.Webhook != "" {
+		if err := report.PublishHook(ctx, hook, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: on_gating_failure webhook: %v\n", err)
+		}
+	}
+}
+
+// runBenchmark measures runner overhead with n synthetic no-op checks
+// spread across layers, using the given parallelism. A non-empty
+// cpuProfile or memProfile path gets a pprof CPU profile of the run or a
+// heap profile taken after it.
+func runBenchmark(n, parallel int, cpuProfile, memProfile string) error {
+	cfg := &config.Config{Checks: make([]config.Check, n)}
+	for i := range cfg.Checks {
+		cfg.Checks[i] = config.Check{
+			Name:    fmt.Sprintf("noop-%d", i+1),
+			Command: "true",
+			Layer:   i%5 + 1,
+		}
+	}
+
+	r := runner.NewRunner(cfg, ".", config.TemplateVars{})
+	r.Parallel = parallel
+	r.Output = io.Discard
+
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+	}
+
+	startTime := time.Now()
+	result := r.Run(context.Background())
+	totalDuration := time.Since(startTime)
+
+	if cpuProfile != "" {
+		pprof.StopCPUProfile()
+	}
+
+	fmt.Printf("Benchmark: %d checks (parallel=%d)\n", n, parallel)
+	fmt.Printf("  Total:     %s\n", formatting.Duration(totalDuration))
+	fmt.Printf("  Per check: %s\n", formatting.Duration(totalDuration/time.Duration(n)))
+	fmt.Printf("  Passed:    %d/%d\n", result.PassCount, result.TotalCount)
+
+	if memProfile != "" {
+		f, err := os.Create(memProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create memory profile: %w", err)
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return fmt.Errorf("failed to write memory profile: %w", err)
+		}
+	}
+	return nil
+}
+
 // findChecksFile looks for checks.yaml in common locations.
 // Priority order:
 //  1. ./checks.yaml (for development in homelab-smoke repo)
@@ -180,10 +684,20 @@ func listConfiguredChecks(cfg *config.Config) {
 			layerStr = fmt.Sprintf("[Layer %d] ", check.Layer)
 		}
 
-		fmt.Printf("%2d. %s%s (%s)\n", i+1, layerStr, check.Name, gating)
+		if check.Disabled.IsActive(time.Now()) {
+			gating += ", disabled"
+		}
+		if check.Quarantine.IsActive(time.Now()) {
+			gating += ", quarantined"
+		}
+
+		fmt.Printf("%2d. %s%s (%s) [%s]\n", i+1, layerStr, check.Name, gating, check.GetID())
 
 		if check.Description != "" {
 			fmt.Printf("    %s\n", check.Description)
 		}
+		if owner := describeOwner(&check); owner != "" {
+			fmt.Printf("    Owner: %s\n", owner)
+		}
 	}
 }
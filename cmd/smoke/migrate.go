@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// runMigrate implements `smoke migrate [-w] [FILE]`, which upgrades a
+// checks file to the current apiVersion, keeping comments. Each change is
+// noted on stderr. Without -w the result is printed to stdout. Included
+// files are migrated separately. Returns the process exit code.
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	write := fs.Bool("w", false, "Write the result back to the file instead of stdout")
+	_ = fs.Parse(args)
+	if fs.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s migrate [-w] [FILE]\n", os.Args[0])
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		path = findChecksFile()
+		if path == "" {
+			fmt.Fprintf(os.Stderr, "Error: checks.yaml not found\n")
+			return 2
+		}
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Path is user-provided config file
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	migrated, notes, err := config.Migrate(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+		return 2
+	}
+
+	if len(notes) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: already at %s\n", path, config.APIVersion)
+	}
+	for _, note := range notes {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, note)
+	}
+
+	if !*write {
+		_, _ = os.Stdout.Write(migrated)
+		return 0
+	}
+	if len(notes) > 0 {
+		if err := os.WriteFile(path, migrated, 0o644); err != nil { //nolint:gosec // Config files are meant to be readable
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/erauner/homelab-go-utils/formatting"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+)
+
+// runRepeat implements -repeat: it runs the selected checks n times,
+// printing each run's summary, then each check's pass rate, so a new
+// check can be shown to be deterministic before it is made gating.
+// Returns the highest exit code of the runs.
+func runRepeat(ctx context.Context, r *runner.Runner, n int) int {
+	var runs []*runner.RunResult
+	exitCode := 0
+	for i := 1; i <= n && ctx.Err() == nil; i++ {
+		fmt.Fprintf(r.Output, "%s=== Run %d/%d ===\n", runSeparator(i), i, n)
+		result := runOnce(ctx, r)
+		runs = append(runs, result)
+		exitCode = max(exitCode, result.ExitCode())
+	}
+
+	r.PrintPassRates(fmt.Sprintf("Pass rates over %d runs", len(runs)), runner.PassRates(runs))
+	return exitCode
+}
+
+// runSoak implements -soak: it runs the selected checks every interval
+// (start to start, or back to back when a run overruns it) until the soak
+// duration is up, then prints each check's availability and flake rate,
+// for burn-in after cluster upgrades. Jitter shortens each interval by a
+// random fraction up to it, so several soaking runners drift apart.
+// Returns the highest exit code of the runs.
+func runSoak(ctx context.Context, r *runner.Runner, soak, interval time.Duration, jitter float64) int {
+	startTime := time.Now()
+	deadline := startTime.Add(soak)
+
+	var runs []*runner.RunResult
+	exitCode := 0
+	for i := 1; ctx.Err() == nil; i++ {
+		runStart := time.Now()
+		fmt.Fprintf(r.Output, "%s=== Run %d (%s left) ===\n", runSeparator(i), i, formatting.Duration(time.Until(deadline).Round(time.Second)))
+		result := runOnce(ctx, r)
+		runs = append(runs, result)
+		exitCode = max(exitCode, result.ExitCode())
+
+		wait := interval
+		if jitter > 0 {
+			wait -= time.Duration(rand.Float64() * jitter * float64(interval))
+		}
+		next := runStart.Add(wait)
+		if !next.Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Until(next)):
+		}
+	}
+
+	title := fmt.Sprintf("Availability over %d runs in %s", len(runs), formatting.Duration(time.Since(startTime).Round(time.Second)))
+	r.PrintPassRates(title, runner.PassRates(runs))
+	return exitCode
+}
+
+// runOnce runs the checks and prints the run's summary.
+func runOnce(ctx context.Context, r *runner.Runner) *runner.RunResult {
+	startTime := time.Now()
+	result := r.Run(ctx)
+	r.PrintSummary(result, formatting.Duration(time.Since(startTime)))
+	return result
+}
+
+// runSeparator returns the blank line printed before each run but the
+// first.
+func runSeparator(i int) string {
+	if i > 1 {
+		return "\n"
+	}
+	return ""
+}
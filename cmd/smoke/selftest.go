@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-go-utils/formatting"
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/report"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+	"github.com/erauner/homelab-smoke/pkg/validate"
+)
+
+// selftestCase is a synthetic check and the outcome it must produce.
+type selftestCase struct {
+	check       config.Check
+	want        engine.Outcome
+	wantRetries int
+}
+
+// selftestCases returns the built-in suite. workDir holds scratch files
+// (e.g., the retry marker).
+func selftestCases(workDir string) []selftestCase {
+	marker := filepath.Join(workDir, "retry-marker")
+	return []selftestCase{
+		{check: config.Check{Name: "outcome pass", Command: "true"}, want: engine.OutcomePass},
+		{check: config.Check{Name: "outcome fail", Command: "exit 1"}, want: engine.OutcomeFail},
+		{check: config.Check{Name: "outcome error", Command: "exit 2"}, want: engine.OutcomeError},
+		{check: config.Check{Name: "outcome skip", Command: "exit 3"}, want: engine.OutcomeSkip},
+		{check: config.Check{Name: "outcome warn", Command: "exit 4"}, want: engine.OutcomeWarn},
+		{check: config.Check{Name: "unknown exit code is error", Command: "exit 9"}, want: engine.OutcomeError},
+		{
+			check: config.Check{Name: "validate contains", Command: "echo ready", Validate: &validate.Validation{Contains: "ready"}},
+			want:  engine.OutcomePass,
+		},
+		{
+			check: config.Check{Name: "validate contains missing", Command: "echo starting", Validate: &validate.Validation{Contains: "ready"}},
+			want:  engine.OutcomeFail,
+		},
+		{
+			check: config.Check{Name: "validate not_contains", Command: "echo panic", Validate: &validate.Validation{NotContains: "panic"}},
+			want:  engine.OutcomeFail,
+		},
+		{
+			check: config.Check{Name: "validate regex", Command: "echo v1.2.3", Validate: &validate.Validation{Regex: `^v\d+\.\d+\.\d+`}},
+			want:  engine.OutcomePass,
+		},
+		{
+			check: config.Check{
+				Name:     "validate script",
+				Command:  `echo '{"ready": 3}'`,
+				Validate: &validate.Validation{Script: "if json.decode(output)[\"ready\"] < 3:\n    fail(\"not ready\")"},
+			},
+			want: engine.OutcomePass,
+		},
+		{
+			check: config.Check{Name: "validate script fails", Command: "echo ok", Validate: &validate.Validation{Script: `fail("selftest")`}},
+			want:  engine.OutcomeFail,
+		},
+		{
+			check: config.Check{
+				Name:    "retry recovers",
+				Command: fmt.Sprintf("test -f %s || { touch %s; exit 1; }", marker, marker),
+				Retry:   &config.RetryConfig{},
+			},
+			want:        engine.OutcomePass,
+			wantRetries: 1,
+		},
+		{
+			check: config.Check{Name: "timeout", Command: "sleep 5", Timeout: config.Duration{Duration: 200 * time.Millisecond}},
+			want:  engine.OutcomeError,
+		},
+		{
+			check: config.Check{Name: "negative test", Command: "exit 1", Expect: &config.ExpectConfig{Outcome: config.ExpectOutcomeFail}},
+			want:  engine.OutcomePass,
+		},
+		{
+			check: config.Check{Name: "custom exit codes", Command: "exit 1", Expect: &config.ExpectConfig{ExitCodes: []int{0, 1}}},
+			want:  engine.OutcomePass,
+		},
+		{
+			check: config.Check{Name: "expected failure", Command: "exit 1", ExpectedFailure: &config.ExpectedFailure{Reason: "selftest"}},
+			want:  engine.OutcomeXFail,
+		},
+		{
+			check: config.Check{Name: "unexpected pass", Command: "true", ExpectedFailure: &config.ExpectedFailure{Reason: "selftest"}},
+			want:  engine.OutcomeXPass,
+		},
+		{check: config.Check{Name: "when false", When: "false", Command: "exit 1"}, want: engine.OutcomeSkip},
+		{check: config.Check{Name: "skip_if command", SkipIf: "true", Command: "exit 1"}, want: engine.OutcomeSkip},
+		{
+			check: config.Check{Name: "fallback command", Command: "exit 2", FallbackCommand: "true"},
+			want:  engine.OutcomePass,
+		},
+		{
+			check: config.Check{Name: "capture", Command: "echo build=42", Capture: map[string]string{"build": `build=(\d+)`}},
+			want:  engine.OutcomePass,
+		},
+		{
+			check: config.Check{Name: "capture used", Layer: 2, Command: `test "{{.Custom.build}}" = 42`},
+			want:  engine.OutcomePass,
+		},
+		{
+			check: config.Check{Name: "template funcs", Layer: 2, Command: `test {{quote (upper "ok")}} = OK`},
+			want:  engine.OutcomePass,
+		},
+	}
+}
+
+// runSelftest runs the built-in suite and verifies outcomes, retries, and
+// every reporter (console, artifacts, summary file, report file, JUnit,
+// dotenv, GitHub annotations and job summary, publish). Returns the
+// process exit code: 0 if the runtime behaves, 1 otherwise.
+func runSelftest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	verbose := fs.Bool("v", false, "Print the console report of the synthetic run")
+	parallel := fs.Int("parallel", 1, "Maximum checks to run concurrently within a layer")
+	_ = fs.Parse(args)
+
+	workDir, err := os.MkdirTemp("", "smoke-selftest-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	// Checks run non-gating so deliberate FAILs don't stop the run. ERROR
+	// always blocks, so each ERROR case runs alone; the rest share one run
+	// (captures flow between its layers).
+	cases := selftestCases(workDir)
+	var shared []config.Check
+	var isolated [][]config.Check
+	for _, c := range cases {
+		if c.want.IsBlocking(false) {
+			isolated = append(isolated, []config.Check{c.check})
+		} else {
+			shared = append(shared, c.check)
+		}
+	}
+
+	out := io.Discard
+	if *verbose {
+		out = os.Stdout
+	}
+	artifactsDir := filepath.Join(workDir, "artifacts")
+	var artifactErr error
+	runSuite := func(checks []config.Check) (*runner.RunResult, *runner.Runner, error) {
+		no := false
+		cfg := &config.Config{Defaults: &config.Defaults{Gating: &no}, Checks: checks}
+		cfg.ApplyDefaults()
+		if err := cfg.Validate(); err != nil {
+			return nil, nil, err
+		}
+		r := runner.NewRunner(cfg, workDir, config.TemplateVars{Cluster: "selftest"})
+		r.DefaultTimeout = 5 * time.Second
+		r.MaxRetries = 2
+		r.RetryDelay = 10 * time.Millisecond
+		r.Parallel = *parallel
+		r.Output = out
+		r.NoColor = !runner.UseColor(os.Stdout, false, false)
+		r.RetainOutputBytes = 4
+		r.OnResult = func(res runner.CheckExecutionResult) {
+			if err := report.WriteArtifacts(artifactsDir, res); err != nil && artifactErr == nil {
+				artifactErr = err
+			}
+		}
+		return r.Run(context.Background()), r, nil
+	}
+
+	startTime := time.Now()
+	result, r, err := runSuite(shared)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "selftest suite invalid: %v\n", err)
+		return 1
+	}
+	r.PrintSummary(result, formatting.Duration(time.Since(startTime)))
+
+	results := result.Results
+	for _, checks := range isolated {
+		res, _, err := runSuite(checks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "selftest suite invalid: %v\n", err)
+			return 1
+		}
+		results = append(results, res.Results...)
+	}
+
+	failures := 0
+	fail := func(format string, args ...any) {
+		failures++
+		fmt.Printf("  FAIL  "+format+"\n", args...)
+	}
+
+	// Outcomes and retries
+	byName := make(map[string]*engine.CheckResult, len(results))
+	for _, res := range results {
+		byName[res.Check.Name] = res.Result
+	}
+	for _, c := range cases {
+		got, ok := byName[c.check.Name]
+		switch {
+		case !ok:
+			fail("%s: did not run", c.check.Name)
+		case got.Outcome != c.want:
+			fail("%s: outcome %s, want %s (%s)", c.check.Name, got.Outcome, c.want, got.OutcomeReason)
+		case got.RetryCount != c.wantRetries:
+			fail("%s: %d retries, want %d", c.check.Name, got.RetryCount, c.wantRetries)
+		default:
+			fmt.Printf("  ok    %s\n", c.check.Name)
+		}
+	}
+
+	// Artifacts keep the full output even though retained output is cut
+	if artifactErr == nil {
+		artifactErr = selftestArtifacts(results, artifactsDir)
+	}
+	if artifactErr != nil {
+		fail("artifacts: %v", artifactErr)
+	} else {
+		fmt.Printf("  ok    artifacts\n")
+	}
+
+	// Reporters
+	rep := report.New(result, "selftest", startTime, time.Since(startTime))
+	if err := selftestReporters(rep, workDir); err != nil {
+		fail("reporters: %v", err)
+	} else {
+		fmt.Printf("  ok    reporters\n")
+	}
+
+	total := len(cases) + 2
+	if failures > 0 {
+		fmt.Printf("\nselftest: %d of %d checks failed\n", failures, total)
+		return 1
+	}
+	fmt.Printf("\nselftest: all %d checks passed\n", total)
+	return 0
+}
+
+// selftestArtifacts checks that every result has an untruncated log and
+// metadata in the artifacts dir.
+func selftestArtifacts(results []runner.CheckExecutionResult, dir string) error {
+	for _, res := range results {
+		id := res.Check.GetID()
+		var meta report.ArtifactMetadata
+		if err := readJSON(filepath.Join(dir, id+".json"), &meta); err != nil {
+			return err
+		}
+		if meta.Outcome != string(res.Result.Outcome) {
+			return fmt.Errorf("%s.json outcome %s, want %s", id, meta.Outcome, res.Result.Outcome)
+		}
+		log, err := os.ReadFile(filepath.Join(dir, meta.Log))
+		if err != nil {
+			return err
+		}
+		if len(log) != meta.OutputBytes {
+			return fmt.Errorf("%s has %d bytes, want %d", meta.Log, len(log), meta.OutputBytes)
+		}
+	}
+	return nil
+}
+
+// selftestReporters round-trips the report through the summary file,
+// report file, CI, and publish paths.
+func selftestReporters(rep *report.Report, workDir string) error {
+	var summary report.Summary
+	path := filepath.Join(workDir, "summary.json")
+	if err := report.WriteFile(path, rep.Summary()); err != nil {
+		return err
+	}
+	if err := readJSON(path, &summary); err != nil {
+		return err
+	}
+	if summary.Counts != rep.Counts {
+		return fmt.Errorf("summary file counts %+v, want %+v", summary.Counts, rep.Counts)
+	}
+
+	var written report.Report
+	path = filepath.Join(workDir, "report.json")
+	if err := report.WriteFile(path, rep); err != nil {
+		return err
+	}
+	if err := readJSON(path, &written); err != nil {
+		return err
+	}
+	if len(written.Checks) != len(rep.Checks) {
+		return fmt.Errorf("report file has %d checks, want %d", len(written.Checks), len(rep.Checks))
+	}
+
+	// CI reports: JUnit, dotenv, GitHub annotations and job summary
+	var junit bytes.Buffer
+	if err := report.WriteJUnit(&junit, rep); err != nil {
+		return err
+	}
+	var suites struct {
+		Tests int `xml:"tests,attr"`
+	}
+	if err := xml.Unmarshal(junit.Bytes(), &suites); err != nil {
+		return fmt.Errorf("failed to decode JUnit report: %w", err)
+	}
+	if suites.Tests != len(rep.Checks) {
+		return fmt.Errorf("JUnit report has %d tests, want %d", suites.Tests, len(rep.Checks))
+	}
+	if want := fmt.Sprintf("SMOKE_TOTAL=%d\n", rep.Counts.Total); !strings.Contains(report.GitLabDotenv(rep), want) {
+		return fmt.Errorf("dotenv is missing %q", strings.TrimSpace(want))
+	}
+	var annotations bytes.Buffer
+	if err := report.WriteGitHubAnnotations(&annotations, rep); err != nil {
+		return err
+	}
+	if want := "::warning title=smoke FAIL%3A outcome fail::"; !strings.Contains(annotations.String(), want) {
+		return fmt.Errorf("GitHub annotations are missing %q", want)
+	}
+	if want := "## Smoke tests"; !strings.HasPrefix(report.GitHubStepSummary(rep), want) {
+		return fmt.Errorf("GitHub job summary doesn't start with %q", want)
+	}
+
+	// Publish to a local listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	received := make(chan report.Report, 1)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var got report.Report
+			_ = json.NewDecoder(req.Body).Decode(&got)
+			received <- got
+		}),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() { _ = server.Serve(ln) }()
+	defer func() { _ = server.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := report.Publish(ctx, "http://"+ln.Addr().String(), rep); err != nil {
+		return err
+	}
+	if got := <-received; got.Counts != rep.Counts {
+		return fmt.Errorf("published counts %+v, want %+v", got.Counts, rep.Counts)
+	}
+	return nil
+}
+
+// readJSON decodes a JSON file into v.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+func TestSelftest(t *testing.T) {
+	for _, args := range [][]string{nil, {"-parallel", "4"}} {
+		if code := runSelftest(args); code != 0 {
+			t.Errorf("selftest %v exited %d, want 0", args, code)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/erauner/homelab-go-utils/formatting"
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+)
+
+// dashboardMaxLines caps the output lines kept per check in the
+// dashboard.
+const dashboardMaxLines = 1000
+
+// spinnerFrames animate running checks in the dashboard.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// runDashboard implements -tui: it runs the checks under a full-screen
+// dashboard with a live table of checks, the streaming output of the
+// selected check, and the run's summary once it ends. The runner's own
+// output is discarded while the dashboard is up. Returns the run's result
+// and how long the run (not the dashboard) took.
+func runDashboard(ctx context.Context, cancel context.CancelFunc, r *runner.Runner) (*runner.RunResult, time.Duration) {
+	out := r.Output
+	r.Output = io.Discard
+	defer func() { r.Output = out }()
+
+	var style *config.SummaryStyle
+	if r.Config.Summary != nil {
+		style = r.Config.Summary.Console
+	}
+	p := tea.NewProgram(newDashboard(r.Config.Checks, style, cancel), tea.WithAltScreen())
+
+	onResult := r.OnResult
+	r.OnStart = func(check *config.Check) {
+		p.Send(checkStartedMsg{check: check, at: time.Now()})
+	}
+	r.OnOutput = func(check *config.Check, line string) {
+		p.Send(checkOutputMsg{id: check.GetID(), line: line})
+	}
+	r.OnResult = func(res runner.CheckExecutionResult) {
+		if onResult != nil {
+			onResult(res)
+		}
+		// Copy what the dashboard shows; the runner trims the result's
+		// output once this returns
+		p.Send(checkDoneMsg{
+			id:       res.Check.GetID(),
+			outcome:  res.Result.Outcome,
+			reason:   res.Result.OutcomeReason,
+			duration: res.Result.Duration,
+			output:   res.Result.Output,
+		})
+	}
+
+	var result *runner.RunResult
+	var duration time.Duration
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		start := time.Now()
+		result = r.Run(ctx)
+		duration = time.Since(start)
+
+		var summary bytes.Buffer
+		r.Output = &summary
+		r.PrintSummary(result, formatting.Duration(duration))
+		r.Output = io.Discard
+		p.Send(runDoneMsg{summary: summary.String()})
+	}()
+
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: dashboard: %v\n", err)
+	}
+	<-done
+	return result, duration
+}
+
+// Dashboard messages, sent from the runner's hooks.
+type (
+	checkStartedMsg struct {
+		check *config.Check
+		at    time.Time
+	}
+	checkOutputMsg struct {
+		id, line string
+	}
+	checkDoneMsg struct {
+		id       string
+		outcome  engine.Outcome
+		reason   string
+		duration time.Duration
+		output   string
+	}
+	runDoneMsg struct {
+		summary string
+	}
+	tickMsg struct{}
+)
+
+// dashboardRow is a check's live state in the dashboard.
+type dashboardRow struct {
+	name     string
+	layer    int
+	started  time.Time
+	finished bool
+	outcome  engine.Outcome
+	reason   string
+	duration time.Duration
+	output   []string
+}
+
+// dashboard is the -tui bubbletea model.
+type dashboard struct {
+	rows  []*dashboardRow
+	byID  map[string]int
+	style *config.SummaryStyle
+
+	// selected is the row whose output is shown; while follow is set it
+	// moves to each check as it starts.
+	selected int
+	follow   bool
+
+	width, height int
+	frame         int
+	start, end    time.Time
+
+	cancel   context.CancelFunc
+	stopping bool
+
+	done        bool
+	summary     string
+	showSummary bool
+}
+
+// newDashboard returns a dashboard listing checks in the order they run.
+func newDashboard(checks []config.Check, style *config.SummaryStyle, cancel context.CancelFunc) *dashboard {
+	sorted := make([]config.Check, len(checks))
+	copy(sorted, checks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Layer < sorted[j].Layer })
+
+	d := &dashboard{byID: make(map[string]int), style: style, follow: true, start: time.Now(), cancel: cancel}
+	for i := range sorted {
+		d.addRow(&sorted[i])
+	}
+	return d
+}
+
+// addRow appends a row for check and returns its index.
+func (d *dashboard) addRow(check *config.Check) int {
+	d.byID[check.GetID()] = len(d.rows)
+	d.rows = append(d.rows, &dashboardRow{name: check.Name, layer: check.Layer})
+	return len(d.rows) - 1
+}
+
+// Init starts the spinner.
+func (d *dashboard) Init() tea.Cmd {
+	return tick()
+}
+
+// tick schedules the next spinner frame and elapsed time refresh.
+func tick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+// Update applies runner events and key presses.
+func (d *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.width, d.height = msg.Width, msg.Height
+
+	case tickMsg:
+		if d.done {
+			return d, nil
+		}
+		d.frame++
+		return d, tick()
+
+	case checkStartedMsg:
+		i, ok := d.byID[msg.check.GetID()]
+		if !ok {
+			i = d.addRow(msg.check)
+		}
+		d.rows[i].started = msg.at
+		if d.follow {
+			d.selected = i
+		}
+
+	case checkOutputMsg:
+		if i, ok := d.byID[msg.id]; ok {
+			d.rows[i].appendOutput(msg.line)
+		}
+
+	case checkDoneMsg:
+		if i, ok := d.byID[msg.id]; ok {
+			row := d.rows[i]
+			row.finished = true
+			row.outcome, row.reason, row.duration = msg.outcome, msg.reason, msg.duration
+			// Kinds that don't stream show their output once done
+			if len(row.output) == 0 && msg.output != "" {
+				for _, line := range strings.Split(strings.TrimRight(msg.output, "\n"), "\n") {
+					row.appendOutput(line)
+				}
+			}
+		}
+
+	case runDoneMsg:
+		d.done = true
+		d.end = time.Now()
+		d.summary = msg.summary
+		d.showSummary = true
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			if d.done {
+				return d, tea.Quit
+			}
+			if !d.stopping {
+				d.stopping = true
+				d.cancel()
+			}
+		case "up", "k":
+			d.follow = false
+			d.selected = max(d.selected-1, 0)
+		case "down", "j":
+			d.follow = false
+			if d.selected < len(d.rows)-1 {
+				d.selected++
+			}
+		case "f":
+			d.follow = true
+		case "tab":
+			if d.done {
+				d.showSummary = !d.showSummary
+			}
+		}
+	}
+	return d, nil
+}
+
+// appendOutput adds a line to the row's output, keeping the last
+// dashboardMaxLines.
+func (row *dashboardRow) appendOutput(line string) {
+	row.output = append(row.output, line)
+	if len(row.output) > dashboardMaxLines {
+		row.output = row.output[len(row.output)-dashboardMaxLines:]
+	}
+}
+
+// Dashboard styles; outcome colors match the console report's.
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	faintStyle    = lipgloss.NewStyle().Faint(true)
+	paneStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	outcomeColors = map[engine.Outcome]lipgloss.Color{
+		engine.OutcomePass:  "2",
+		engine.OutcomeFail:  "1",
+		engine.OutcomeError: "1",
+		engine.OutcomeSkip:  "8",
+		engine.OutcomeWarn:  "3",
+		engine.OutcomeXFail: "8",
+		engine.OutcomeXPass: "3",
+	}
+)
+
+// View renders the check table and the selected check's output, or the
+// summary once the run has ended.
+func (d *dashboard) View() string {
+	width, height := d.width, d.height
+	if width == 0 || height == 0 {
+		width, height = 80, 24
+	}
+
+	var b strings.Builder
+	b.WriteString(d.header() + "\n\n")
+
+	if d.showSummary {
+		b.WriteString(strings.TrimLeft(d.summary, "\n"))
+		b.WriteString("\n" + faintStyle.Render("tab checks · q quit"))
+		return b.String()
+	}
+
+	// Split the screen between the table and the output pane
+	available := max(height-5, 4)
+	tableHeight := min(len(d.rows), max(available/2, 2))
+	paneHeight := max(available-tableHeight-1, 1)
+
+	top := 0
+	if d.selected >= tableHeight {
+		top = d.selected - tableHeight + 1
+	}
+	nameWidth := 10
+	for _, row := range d.rows {
+		nameWidth = max(nameWidth, min(lipgloss.Width(row.name), 40))
+	}
+	for i := top; i < top+tableHeight && i < len(d.rows); i++ {
+		b.WriteString(d.renderRow(i, nameWidth, width) + "\n")
+	}
+
+	var lines []string
+	if d.selected < len(d.rows) {
+		selected := d.rows[d.selected]
+		b.WriteString(paneStyle.Render(truncate("── "+selected.name+" ", width)) + "\n")
+		lines = selected.output
+		if len(lines) > paneHeight {
+			lines = lines[len(lines)-paneHeight:]
+		}
+	}
+	for _, line := range lines {
+		b.WriteString(truncate(line, width) + "\n")
+	}
+	for i := len(lines); i < paneHeight; i++ {
+		b.WriteString("\n")
+	}
+
+	keys := "↑/↓ select · f follow · q stop"
+	if d.done {
+		keys = "↑/↓ select · tab summary · q quit"
+	}
+	b.WriteString(faintStyle.Render(keys))
+	return b.String()
+}
+
+// header returns the run's progress line.
+func (d *dashboard) header() string {
+	finished, running := 0, 0
+	for _, row := range d.rows {
+		switch {
+		case row.finished:
+			finished++
+		case !row.started.IsZero():
+			running++
+		}
+	}
+
+	state := fmt.Sprintf("%d running", running)
+	switch {
+	case d.done:
+		state = "done"
+	case d.stopping:
+		state = "stopping"
+	}
+	end := d.end
+	if !d.done {
+		end = time.Now()
+	}
+	elapsed := formatting.Duration(end.Sub(d.start).Round(time.Second))
+	return titleStyle.Render("Homelab Smoke Tests") + faintStyle.Render(fmt.Sprintf("  %d/%d checks · %s · %s", finished, len(d.rows), state, elapsed))
+}
+
+// renderRow renders a table row: marker, status, name, layer, and time.
+func (d *dashboard) renderRow(i, nameWidth, width int) string {
+	row := d.rows[i]
+
+	marker := "  "
+	if i == d.selected {
+		marker = "> "
+	}
+
+	var status, elapsed string
+	switch {
+	case row.finished:
+		label := d.style.Label(string(row.outcome))
+		status = lipgloss.NewStyle().Foreground(outcomeColors[row.outcome]).Render(fmt.Sprintf("  %-7s", label))
+		elapsed = formatting.Duration(row.duration)
+	case !row.started.IsZero():
+		status = fmt.Sprintf("%s %-7s", spinnerFrames[d.frame%len(spinnerFrames)], "RUN")
+		elapsed = formatting.Duration(time.Since(row.started).Round(100 * time.Millisecond))
+	case d.done:
+		status = faintStyle.Render(fmt.Sprintf("  %-7s", "-"))
+		elapsed = "not run"
+	default:
+		status = faintStyle.Render(fmt.Sprintf("  %-7s", "queued"))
+	}
+
+	name := truncate(row.name, nameWidth)
+	line := fmt.Sprintf("%s%s %-*s  L%-2d %s", marker, status, nameWidth, name, row.layer, elapsed)
+	if row.finished && row.reason != "" && row.outcome != engine.OutcomePass {
+		line += faintStyle.Render("  " + row.reason)
+	}
+	return truncate(line, width)
+}
+
+// truncate cuts s to width terminal cells, keeping ANSI styling intact.
+func truncate(s string, width int) string {
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
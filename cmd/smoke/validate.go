@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// runValidate implements `smoke validate`, which lints the checks file and
+// reports every problem with its line, including -policy violations.
+// Templates are rendered with the variables a run would have: the flags,
+// the config's secrets and vars_from, and -var-file files. Returns the
+// process exit code.
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	checksFile := fs.String("checks", "", "Path to checks YAML file (default: auto-discover)")
+	cluster := fs.String("cluster", "home", "Cluster name for rendering templates")
+	namespace := fs.String("namespace", "", "Kubernetes namespace for rendering templates")
+	kubeContext := fs.String("context", "", "kubectl context for rendering templates")
+	varFiles := fileListFlag{}
+	fs.Var(&varFiles, "var-file", "Load template variables from this YAML file, decrypting it if it is SOPS-encrypted (repeatable)")
+	policyFile := fs.String("policy", "", "Also report violations of this policy file")
+	timeout := fs.Duration("timeout", 30*time.Second, "Timeout of checks without their own, held to the policy's max_timeout")
+	_ = fs.Parse(args)
+
+	checksPath := *checksFile
+	if checksPath == "" {
+		checksPath = findChecksFile()
+		if checksPath == "" {
+			fmt.Fprintf(os.Stderr, "Error: checks.yaml not found\n")
+			return 2
+		}
+	}
+
+	// A config that doesn't load is linted without its secrets and
+	// vars_from; Lint reports why it doesn't load
+	vars := config.TemplateVars{Cluster: *cluster, Namespace: *namespace, Context: *kubeContext}
+	cfg, err := config.LoadConfig(checksPath)
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err = resolveVars(ctx, cfg, &vars, filepath.Dir(checksPath), varFiles)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	var opts config.LintOptions
+	if *policyFile != "" {
+		policy, err := config.LoadPolicy(*policyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+			return 2
+		}
+		policy.DefaultTimeout = *timeout
+		opts.Policy = policy
+	}
+
+	problems, err := config.LintWith(checksPath, vars, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	for _, p := range problems {
+		if p.Line > 0 {
+			fmt.Printf("%s:%d: %s\n", checksPath, p.Line, p.Message)
+		} else {
+			fmt.Printf("%s: %s\n", checksPath, p.Message)
+		}
+	}
+	if len(problems) > 0 {
+		fmt.Printf("\n%d problem(s) found\n", len(problems))
+		return 2
+	}
+	fmt.Printf("%s: no problems found\n", checksPath)
+	return 0
+}
@@ -4,166 +4,2261 @@ package config
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"math"
+	"net"
+	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/transform"
 	"github.com/erauner/homelab-smoke/pkg/validate"
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds the complete smoke test configuration.
 type Config struct {
+	// APIVersion is the schema version the file is written for (see
+	// APIVersion); older versions are migrated when loaded.
+	APIVersion string `yaml:"apiVersion,omitempty"`
+
+	// Include lists config files merged before this one (paths relative
+	// to this file; globs allowed). See LoadConfigWith for precedence.
+	Include []string `yaml:"include,omitempty"`
+
+	// Profiles are named partial configs merged over the file when
+	// selected (e.g., with -profile=ci).
+	Profiles map[string]Config `yaml:"profiles,omitempty"`
+
+	// Defaults are applied to every check that doesn't set the field itself.
+	Defaults *Defaults `yaml:"defaults,omitempty"`
+
+	// Layers configures per-layer behavior, keyed by layer number.
+	Layers map[int]LayerConfig `yaml:"layers,omitempty"`
+
+	// Summary customizes outcome labels and summary buckets per reporter.
+	Summary *SummaryConfig `yaml:"summary,omitempty"`
+
+	// Setup lists commands run in order before the first check (e.g.,
+	// creating a scratch namespace). A failing command stops the run
+	// before any check runs.
+	Setup []string `yaml:"setup,omitempty"`
+
+	// Teardown lists commands run in order after the last check. They
+	// always run, even after a failed setup, a fail-fast stop, or an
+	// interrupt.
+	Teardown []string `yaml:"teardown,omitempty"`
+
+	// Fixtures are named setup/teardown pairs that checks share with
+	// uses. Each is set up before its first consumer runs and torn down
+	// after its last.
+	Fixtures map[string]FixtureConfig `yaml:"fixtures,omitempty"`
+
+	// Secrets are values read at load time from the environment, a file,
+	// or a command, available to templates as {{.Secret.name}} and
+	// redacted from output.
+	Secrets map[string]SecretSource `yaml:"secrets,omitempty"`
+
+	// Vault says how to reach HashiCorp Vault for secrets stored there.
+	Vault *VaultConfig `yaml:"vault,omitempty"`
+
+	// VarsFrom populates template variables from Secrets and ConfigMaps in
+	// the target cluster at run start.
+	VarsFrom []VarSource `yaml:"vars_from,omitempty"`
+
+	// Maintenance mutes failures of matching checks during planned
+	// maintenance windows.
+	Maintenance []MaintenanceWindow `yaml:"maintenance,omitempty"`
+
+	// OnGatingFailure runs after a run with gating failures (e.g., rollback).
+	OnGatingFailure *HookConfig `yaml:"on_gating_failure,omitempty"`
+
+	// Environment controls the environment checks and hooks run with.
+	Environment *EnvironmentConfig `yaml:"environment,omitempty"`
+
+	// Snapshot captures cluster state before the run for the report.
+	Snapshot *SnapshotConfig `yaml:"snapshot,omitempty"`
+
+	// Diagnostics maps a tag to commands run when any check with that tag
+	// fails, in addition to the check's own diagnostics.
+	Diagnostics map[string][]string `yaml:"diagnostics,omitempty"`
+
+	// Overrides change classified outcomes for matching checks (e.g., a
+	// known benign error downgraded to WARN). The first match applies.
+	Overrides []OverrideRule `yaml:"overrides,omitempty"`
+
+	// Targets names groups of hosts that ssh checks can fan out to with
+	// group (e.g., "storage": [nas1.lan, nas2.lan]).
+	Targets map[string][]string `yaml:"targets,omitempty"`
+
 	Checks []Check `yaml:"checks"`
+
+	// Policy, if set, is enforced by Validate (e.g., from -policy). It is
+	// never read from the checks file, which it constrains.
+	Policy *Policy `yaml:"-"`
+}
+
+// OverrideRule replaces the outcome of checks that match all of its set
+// matchers, after classification (including captures) and before
+// expected_failure is applied.
+type OverrideRule struct {
+	// Name matches the check name or ID as a glob (e.g., "backup-*").
+	Name string `yaml:"name,omitempty"`
+
+	// Tag matches checks carrying this tag.
+	Tag string `yaml:"tag,omitempty"`
+
+	// Output matches the check's output as a regex.
+	Output string `yaml:"output,omitempty"`
+
+	// From limits the rule to these original outcomes (default: any).
+	From []string `yaml:"from,omitempty"`
+
+	// Outcome is the replacement outcome (PASS, FAIL, WARN, SKIP, ERROR).
+	Outcome string `yaml:"outcome"`
+
+	// Reason explains the override in the check's reason.
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// Matches returns whether the rule applies to a check with the given
+// classified outcome and output.
+func (o *OverrideRule) Matches(check *Check, outcome, output string) bool {
+	if o.Name != "" && !check.matchesAny([]string{o.Name}) {
+		return false
+	}
+	if o.Tag != "" && !slices.Contains(check.Tags, o.Tag) {
+		return false
+	}
+	if len(o.From) > 0 && !slices.Contains(o.From, outcome) {
+		return false
+	}
+	if o.Output != "" {
+		if ok, err := regexp.MatchString(o.Output, output); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks the rule's matchers and outcomes.
+func (o *OverrideRule) validate(i int) error {
+	if o.Name == "" && o.Tag == "" && o.Output == "" {
+		return fmt.Errorf("overrides[%d]: must match on name, tag, or output", i)
+	}
+	if _, err := path.Match(o.Name, ""); err != nil {
+		return fmt.Errorf("overrides[%d]: invalid name pattern %q: %w", i, o.Name, err)
+	}
+	if _, err := regexp.Compile(o.Output); err != nil {
+		return fmt.Errorf("overrides[%d]: invalid output regex %q: %w", i, o.Output, err)
+	}
+	for _, from := range o.From {
+		if !isKnownOutcome(from) {
+			return fmt.Errorf("overrides[%d]: unknown outcome %q in from", i, from)
+		}
+	}
+	switch engine.Outcome(o.Outcome) {
+	case engine.OutcomePass, engine.OutcomeFail, engine.OutcomeWarn, engine.OutcomeSkip, engine.OutcomeError:
+	default:
+		return fmt.Errorf("overrides[%d]: outcome must be PASS, FAIL, WARN, SKIP, or ERROR, got %q", i, o.Outcome)
+	}
+	return nil
+}
+
+// OverrideFor returns the first override rule matching a check's result,
+// or nil.
+func (c *Config) OverrideFor(check *Check, outcome, output string) *OverrideRule {
+	for i := range c.Overrides {
+		if c.Overrides[i].Matches(check, outcome, output) {
+			return &c.Overrides[i]
+		}
+	}
+	return nil
+}
+
+// SnapshotConfig enables a pre-run cluster snapshot (node readiness,
+// failing pods, recent warning events) attached to the run report.
+type SnapshotConfig struct {
+	// Namespace limits pods and events to one namespace (default: all).
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Events is how many recent warning events to keep (default: 20).
+	Events int `yaml:"events,omitempty"`
+}
+
+// GetEvents returns the warning event limit, or the default of 20.
+func (s *SnapshotConfig) GetEvents() int {
+	if s.Events > 0 {
+		return s.Events
+	}
+	return 20
+}
+
+// SummaryConfig holds per-reporter presentation settings.
+type SummaryConfig struct {
+	// Console styles the human-readable console output.
+	Console *SummaryStyle `yaml:"console,omitempty"`
+
+	// JSON styles the machine-readable JSON report.
+	JSON *SummaryStyle `yaml:"json,omitempty"`
+}
+
+// SummaryStyle maps outcomes to display labels and summary buckets.
+type SummaryStyle struct {
+	// Labels maps outcome names (e.g., "WARN") to display labels.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Buckets group outcomes into named summary totals.
+	Buckets []SummaryBucket `yaml:"buckets,omitempty"`
+}
+
+// SummaryBucket is a named group of outcomes counted together.
+type SummaryBucket struct {
+	// Name is the bucket's display name (e.g., "attention").
+	Name string `yaml:"name"`
+
+	// Outcomes are the outcome names counted in this bucket.
+	Outcomes []string `yaml:"outcomes"`
+}
+
+// Label returns the display label for an outcome, or the outcome itself.
+func (s *SummaryStyle) Label(outcome string) string {
+	if s != nil {
+		if label, ok := s.Labels[outcome]; ok && label != "" {
+			return label
+		}
+	}
+	return outcome
+}
+
+// BucketTotal sums the counts of a bucket's outcomes.
+func (b *SummaryBucket) BucketTotal(counts map[string]int) int {
+	total := 0
+	for _, outcome := range b.Outcomes {
+		total += counts[outcome]
+	}
+	return total
+}
+
+// validate checks that all referenced outcomes are known.
+func (s *SummaryStyle) validate(reporter string) error {
+	if s == nil {
+		return nil
+	}
+	for outcome := range s.Labels {
+		if !isKnownOutcome(outcome) {
+			return fmt.Errorf("summary.%s: unknown outcome %q in labels", reporter, outcome)
+		}
+	}
+	for i, b := range s.Buckets {
+		if b.Name == "" {
+			return fmt.Errorf("summary.%s: bucket %d missing name", reporter, i)
+		}
+		for _, outcome := range b.Outcomes {
+			if !isKnownOutcome(outcome) {
+				return fmt.Errorf("summary.%s: unknown outcome %q in bucket %s", reporter, outcome, b.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// isKnownOutcome reports whether name is a valid outcome name.
+func isKnownOutcome(name string) bool {
+	for _, o := range engine.AllOutcomes() {
+		if string(o) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HookConfig defines a command and/or webhook triggered by a run event.
+type HookConfig struct {
+	// Command is a shell command to run (templated like check commands).
+	Command string `yaml:"command,omitempty"`
+
+	// Webhook is a URL that receives the JSON run report via POST.
+	Webhook string `yaml:"webhook,omitempty"`
+
+	// Payload, if set, is a template over the run report rendered as the
+	// webhook body instead of the report itself (e.g., a Slack message).
+	Payload string `yaml:"payload,omitempty"`
+
+	// ContentType is the webhook body's content type (default:
+	// application/json).
+	ContentType string `yaml:"content_type,omitempty"`
+
+	// Timeout bounds the hook command and webhook call (default: 60s).
+	Timeout Duration `yaml:"timeout,omitempty"`
+}
+
+// GetContentType returns the webhook content type, or application/json.
+func (h *HookConfig) GetContentType() string {
+	if h.ContentType != "" {
+		return h.ContentType
+	}
+	return "application/json"
+}
+
+// GetTimeout returns the hook timeout, or the default if not set.
+func (h *HookConfig) GetTimeout(defaultTimeout time.Duration) time.Duration {
+	if h.Timeout.Duration > 0 {
+		return h.Timeout.Duration
+	}
+	return defaultTimeout
+}
+
+// EnvironmentConfig makes check results independent of the shell or user
+// that launched the runner.
+type EnvironmentConfig struct {
+	// Scrub starts checks from an empty environment instead of the
+	// runner's; only PATH and the Pass variables are kept.
+	Scrub bool `yaml:"scrub,omitempty"`
+
+	// Pass lists variables passed through from the runner when scrubbing.
+	Pass []string `yaml:"pass,omitempty"`
+
+	// Set forces variables (e.g., LANG, TZ, KUBECONFIG), overriding both
+	// the runner's environment and Pass.
+	Set map[string]string `yaml:"set,omitempty"`
+}
+
+// Environ builds the environment for check commands from the runner's
+// environment (as from os.Environ). Returns nil, meaning inherit as-is,
+// when nothing is configured.
+func (e *EnvironmentConfig) Environ(host []string) []string {
+	if e == nil || (!e.Scrub && len(e.Set) == 0) {
+		return nil
+	}
+
+	keep := func(key string) bool {
+		if !e.Scrub {
+			return true
+		}
+		return key == "PATH" || containsString(e.Pass, key)
+	}
+
+	env := []string{}
+	for _, kv := range host {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, forced := e.Set[key]; forced || !keep(key) {
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	keys := make([]string, 0, len(e.Set))
+	for key := range e.Set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+e.Set[key])
+	}
+	return env
+}
+
+// FixtureConfig is a shared resource for checks, such as a scratch
+// namespace or a port-forward.
+type FixtureConfig struct {
+	// Setup lists commands that create the fixture, run in order.
+	Setup []string `yaml:"setup,omitempty"`
+
+	// Teardown lists commands that remove the fixture, run in order. They
+	// run even if setup failed.
+	Teardown []string `yaml:"teardown,omitempty"`
+}
+
+// LayerConfig configures how a layer's checks are run.
+type LayerConfig struct {
+	// ContinueOnFailure keeps running after a gating failure in this layer
+	// (e.g., independent app checks), instead of stopping the run. The
+	// failure still fails the run.
+	ContinueOnFailure bool `yaml:"continue_on_failure,omitempty"`
+
+	// Timeout is the layer's total time budget (0 = none). Once it runs
+	// out, checks that haven't started are skipped; running checks finish
+	// within their own timeouts.
+	Timeout Duration `yaml:"timeout,omitempty"`
+}
+
+// ContinuesOnFailure returns whether a gating failure in the layer leaves
+// the run going.
+func (c *Config) ContinuesOnFailure(layer int) bool {
+	return c.Layers[layer].ContinueOnFailure
+}
+
+// LayerTimeout returns the layer's time budget, or 0 if it has none.
+func (c *Config) LayerTimeout(layer int) time.Duration {
+	return c.Layers[layer].Timeout.Duration
+}
+
+// Defaults holds suite-level settings inherited by all checks.
+type Defaults struct {
+	// Timeout is the default per-check timeout.
+	Timeout Duration `yaml:"timeout,omitempty"`
+
+	// Retry enables retry on failure for checks that don't set retry, and
+	// fills in the max, delay, and backoff of those that do.
+	Retry *RetryConfig `yaml:"retry,omitempty"`
+
+	// RetryDelay is the default delay between retries.
+	RetryDelay Duration `yaml:"retry_delay,omitempty"`
+
+	// RetryBackoff is the default backoff for checks that don't set one.
+	RetryBackoff *RetryBackoff `yaml:"retry_backoff,omitempty"`
+
+	// Gating is the default for expect.gating.
+	Gating *bool `yaml:"gating,omitempty"`
+
+	// Tags are prepended to every check's tags.
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Owner is the default owner, e.g. for a file of one person's checks.
+	Owner string `yaml:"owner,omitempty"`
+
+	// Team is the default team.
+	Team string `yaml:"team,omitempty"`
 }
 
 // Check defines a single smoke test check.
 type Check struct {
+	// ID is a stable identifier for the check (defaults to a slug of Name).
+	ID string `yaml:"id,omitempty"`
+
 	// Name is the display name for the check.
 	Name string `yaml:"name"`
 
 	// Description provides additional context about the check.
 	Description string `yaml:"description,omitempty"`
 
-	// Layer determines execution order (lower layers run first, fail fast).
-	Layer int `yaml:"layer,omitempty"`
+	// Owner is the person or team responsible for the check.
+	Owner string `yaml:"owner,omitempty"`
+
+	// Team is the team the check belongs to, for grouping when owners
+	// are individuals.
+	Team string `yaml:"team,omitempty"`
+
+	// Layer determines execution order (lower layers run first, fail fast).
+	Layer int `yaml:"layer,omitempty"`
+
+	// When is a condition that must be true for the check to run. It is
+	// rendered as a template; "true"/"false" are used directly, anything
+	// else runs as a shell command where exit 0 means true.
+	When string `yaml:"when,omitempty"`
+
+	// SkipIf is a condition (same forms as When) that skips the check when true.
+	SkipIf string `yaml:"skip_if,omitempty"`
+
+	// Command is the shell command to run (alternative to Script).
+	Command string `yaml:"command,omitempty"`
+
+	// Script defines an external script to run (alternative to Command).
+	Script *ScriptConfig `yaml:"script,omitempty"`
+
+	// ExecInPod runs the command inside a pod (alternative to Command/Script).
+	ExecInPod *ExecInPodConfig `yaml:"exec_in_pod,omitempty"`
+
+	// SSH runs the command on a remote host (alternative to Command/Script).
+	SSH *SSHConfig `yaml:"ssh,omitempty"`
+
+	// GRPC probes a gRPC health endpoint (alternative to Command/Script).
+	GRPC *GRPCConfig `yaml:"grpc,omitempty"`
+
+	// SMTP verifies a mail relay without sending mail (alternative to Command/Script).
+	SMTP *SMTPConfig `yaml:"smtp,omitempty"`
+
+	// NTP checks local clock drift against an NTP server (alternative to Command/Script).
+	NTP *NTPConfig `yaml:"ntp,omitempty"`
+
+	// Certificate checks cert-manager Certificates are Ready and not expiring
+	// (alternative to Command/Script).
+	Certificate *CertificateConfig `yaml:"certificate,omitempty"`
+
+	// Ingress discovers Ingress/HTTPRoute hosts and probes each over HTTPS
+	// (alternative to Command/Script).
+	Ingress *IngressConfig `yaml:"ingress,omitempty"`
+
+	// External checks an externally published hostname (e.g., behind a
+	// Cloudflare Tunnel) resolves publicly as expected and serves the same
+	// content as the internal path (alternative to Command/Script).
+	External *ExternalConfig `yaml:"external,omitempty"`
+
+	// File asserts existence, age, size, checksum, mount, or free space of
+	// a path, locally or over SSH (alternative to Command/Script).
+	File *FileConfig `yaml:"file,omitempty"`
+
+	// Systemd asserts systemd units are active (and optionally enabled),
+	// locally or over SSH (alternative to Command/Script).
+	Systemd *SystemdConfig `yaml:"systemd,omitempty"`
+
+	// GPU checks nodes advertise an extended resource such as
+	// nvidia.com/gpu, optionally running a trivial Job that requests it
+	// (alternative to Command/Script).
+	GPU *GPUConfig `yaml:"gpu,omitempty"`
+
+	// Sandbox confines a command/script check's filesystem and network
+	// access, for untrusted scripts (Linux only). It applies to every
+	// command the check runs, including conditions, before/after, and
+	// diagnostics.
+	Sandbox *SandboxConfig `yaml:"sandbox,omitempty"`
+
+	// FallbackCommand runs if the primary command/script results in ERROR
+	// (e.g., a kubectl plugin missing on this runner).
+	FallbackCommand string `yaml:"fallback_command,omitempty"`
+
+	// Uses names the fixtures the check needs; they are set up before it
+	// runs. If one fails to set up, the check is ERROR.
+	Uses []string `yaml:"uses,omitempty"`
+
+	// Before lists commands run in order before the check (e.g., seeding
+	// test data). A failing command makes the check ERROR without running
+	// it.
+	Before []string `yaml:"before,omitempty"`
+
+	// After lists commands run in order after the check, whatever its
+	// outcome (e.g., cleaning up seeded data).
+	After []string `yaml:"after,omitempty"`
+
+	// Capture maps variable names to regexes; on PASS, the first submatch
+	// (or whole match) becomes {{.Custom.<name>}} for later checks.
+	Capture map[string]string `yaml:"capture,omitempty"`
+
+	// Diagnostics are commands run only when the check fails (FAIL or
+	// ERROR), e.g., kubectl describe; their output is attached to the result.
+	Diagnostics []string `yaml:"diagnostics,omitempty"`
+
+	// Transform post-processes the output, in order, before captures,
+	// validation, and reporting (e.g., strip_ansi, head: 20, jq: .status).
+	Transform []transform.Step `yaml:"transform,omitempty"`
+
+	// Validate defines output validation postconditions.
+	Validate *validate.Validation `yaml:"validate,omitempty"`
+
+	// Expect defines expectations for the check result.
+	Expect *ExpectConfig `yaml:"expect,omitempty"`
+
+	// ExpectedFailure marks a known-broken check: FAIL reports as XFAIL and
+	// an unexpected PASS reports as XPASS.
+	ExpectedFailure *ExpectedFailure `yaml:"expected_failure,omitempty"`
+
+	// Disabled temporarily turns the check off; it reports as SKIP with
+	// the reason until the optional expiry date.
+	Disabled *Disabled `yaml:"disabled,omitempty"`
+
+	// Quarantine keeps a flaky check running but non-gating: failures
+	// report as WARN until the optional expiry date.
+	Quarantine *Quarantine `yaml:"quarantine,omitempty"`
+
+	// Maintenance lists planned maintenance windows during which the
+	// check's failures are reported as WARN or SKIP.
+	Maintenance []MaintenanceWindow `yaml:"maintenance,omitempty"`
+
+	// Tags are free-form labels used for grouping and selection.
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Requires lists binaries the check needs on PATH (e.g., jq, curl),
+	// beyond those its kind implies. `smoke doctor` verifies them.
+	Requires []string `yaml:"requires,omitempty"`
+
+	// Retry enables retry on failure: true, or an object that also sets
+	// the retry count, delay, and backoff.
+	Retry *RetryConfig `yaml:"retry,omitempty"`
+
+	// RetryDelay is the per-check delay between retries (overrides default).
+	RetryDelay Duration `yaml:"retry_delay,omitempty"`
+
+	// RetryBackoff grows the delay after each retry instead of keeping it
+	// fixed at retry_delay.
+	RetryBackoff *RetryBackoff `yaml:"retry_backoff,omitempty"`
+
+	// WaitFor re-runs the check until it passes or a deadline passes,
+	// for conditions that take a while to converge (e.g., a rollout
+	// becoming Ready). It takes the place of retry.
+	WaitFor *WaitFor `yaml:"wait_for,omitempty"`
+
+	// Timeout is the per-check timeout (overrides default).
+	Timeout Duration `yaml:"timeout,omitempty"`
+}
+
+// DefaultWaitInterval is the time between wait_for polls when no interval
+// is set.
+const DefaultWaitInterval = 10 * time.Second
+
+// WaitFor polls a check until it passes. Unlike retry, every outcome
+// short of PASS is polled again, including failed output validation, and
+// the number of attempts is bounded by time rather than a count. PASS is
+// judged after expect.exit_codes and expect.outcome apply, so a negative
+// check polls until its command fails.
+type WaitFor struct {
+	// Timeout is how long to keep polling; an attempt still running at
+	// the deadline is cut short.
+	Timeout Duration `yaml:"timeout"`
+
+	// Interval is the time between attempts (default: 10s).
+	Interval Duration `yaml:"interval,omitempty"`
+}
+
+// GetInterval returns the time between polls.
+func (w *WaitFor) GetInterval() time.Duration {
+	if w.Interval.Duration > 0 {
+		return w.Interval.Duration
+	}
+	return DefaultWaitInterval
+}
+
+// RetryConfig enables retrying a failing check. It is written as a bool
+// (`retry: true`) or as an object, which enables retries and tunes them
+// (`retry: {max: 5, delay: 10s, backoff: exponential}`).
+type RetryConfig struct {
+	// Max is the maximum number of retries (default: -retries).
+	Max *int `yaml:"max,omitempty"`
+
+	// Delay is the delay before the first retry, taking precedence over
+	// retry_delay.
+	Delay Duration `yaml:"delay,omitempty"`
+
+	// Backoff grows the delay after each retry, taking precedence over
+	// retry_backoff.
+	Backoff *RetryBackoff `yaml:"backoff,omitempty"`
+
+	// disabled is set by an explicit `retry: false`.
+	disabled bool
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for RetryConfig.
+func (r *RetryConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var enabled bool
+		if err := value.Decode(&enabled); err != nil {
+			return err
+		}
+		r.disabled = !enabled
+		return nil
+	}
+
+	type plain RetryConfig
+	return value.Decode((*plain)(r))
+}
+
+// MarshalYAML implements yaml.Marshaler for RetryConfig, writing the bool
+// form when no settings are given.
+func (r RetryConfig) MarshalYAML() (any, error) {
+	if r.disabled || (r.Max == nil && r.Delay.Duration == 0 && r.Backoff == nil) {
+		return !r.disabled, nil
+	}
+	type plain RetryConfig
+	return plain(r), nil
+}
+
+// inherit fills settings r doesn't set from defaults.
+func (r *RetryConfig) inherit(defaults *RetryConfig) {
+	if r.Max == nil && defaults.Max != nil {
+		max := *defaults.Max
+		r.Max = &max
+	}
+	if r.Delay.Duration == 0 {
+		r.Delay = defaults.Delay
+	}
+	if r.Backoff == nil && defaults.Backoff != nil {
+		backoff := *defaults.Backoff
+		r.Backoff = &backoff
+	}
+}
+
+// validate checks the retry settings, naming field in errors.
+func (r *RetryConfig) validate(field string) error {
+	if r.Max != nil && *r.Max < 0 {
+		return fmt.Errorf("%s.max must not be negative, got %d", field, *r.Max)
+	}
+	if r.Delay.Duration < 0 {
+		return fmt.Errorf("%s.delay must not be negative", field)
+	}
+	if r.Backoff != nil {
+		return r.Backoff.validate(field + ".backoff")
+	}
+	return nil
+}
+
+// RetryBackoff grows the delay between retries, starting from
+// retry_delay, so a recovering service isn't hammered. Besides the object
+// form it can be written as "fixed" (the default) or "exponential" (the
+// delay doubles after each retry).
+type RetryBackoff struct {
+	// Multiplier scales the delay after each retry (e.g., 2 doubles it).
+	Multiplier float64 `yaml:"multiplier,omitempty"`
+
+	// MaxDelay caps the delay (0 = no cap).
+	MaxDelay Duration `yaml:"max_delay,omitempty"`
+
+	// Jitter shortens each delay by a random fraction up to this (0-1).
+	Jitter float64 `yaml:"jitter,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for RetryBackoff.
+func (b *RetryBackoff) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		switch value.Value {
+		case "fixed":
+			*b = RetryBackoff{}
+		case "exponential":
+			*b = RetryBackoff{Multiplier: 2}
+		default:
+			return fmt.Errorf("line %d: backoff must be fixed, exponential, or an object, got %q", value.Line, value.Value)
+		}
+		return nil
+	}
+
+	type plain RetryBackoff
+	return value.Decode((*plain)(b))
+}
+
+// validate checks the backoff's values, naming field in errors.
+func (b *RetryBackoff) validate(field string) error {
+	if b.Multiplier != 0 && b.Multiplier < 1 {
+		return fmt.Errorf("%s.multiplier must be at least 1, got %g", field, b.Multiplier)
+	}
+	if b.MaxDelay.Duration < 0 {
+		return fmt.Errorf("%s.max_delay must not be negative", field)
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		return fmt.Errorf("%s.jitter must be between 0 and 1, got %g", field, b.Jitter)
+	}
+	return nil
+}
+
+// ScriptConfig defines an external script to run.
+type ScriptConfig struct {
+	// Path is the path to the script file (relative to checks dir or absolute).
+	Path string `yaml:"path"`
+
+	// Args are the arguments to pass to the script.
+	Args []string `yaml:"args,omitempty"`
+}
+
+// SandboxConfig isolates a check command in its own user, mount, and
+// network namespaces.
+type SandboxConfig struct {
+	// Network keeps host network access (default: no network).
+	Network bool `yaml:"network,omitempty"`
+
+	// Root chroots the commands into this absolute directory (required);
+	// the command, script path, and tools all resolve inside it.
+	Root string `yaml:"root,omitempty"`
+}
+
+// ExecInPodConfig defines a command run inside a pod via kubectl exec,
+// for checks from the workload's point of view.
+type ExecInPodConfig struct {
+	// Namespace is the pod's namespace (defaults to the kubectl default).
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Pod is the target (a pod name or kubectl target like "deploy/web").
+	Pod string `yaml:"pod,omitempty"`
+
+	// Selector picks the first pod matching a label selector (alternative to Pod).
+	Selector string `yaml:"selector,omitempty"`
+
+	// Container is the container to exec into (optional).
+	Container string `yaml:"container,omitempty"`
+
+	// Command is the shell command run inside the container via sh -c.
+	Command string `yaml:"command"`
+}
+
+// SSHConfig defines a command run on a remote host over SSH, using the
+// system ssh client (so ~/.ssh/config and the SSH agent apply).
+type SSHConfig struct {
+	// Host is the remote hostname or address.
+	Host string `yaml:"host"`
+
+	// User is the remote user (defaults to ssh's own default).
+	User string `yaml:"user,omitempty"`
+
+	// Port is the remote SSH port (defaults to 22).
+	Port int `yaml:"port,omitempty"`
+
+	// Key is a private key file; if empty the SSH agent/default keys are used.
+	Key string `yaml:"key,omitempty"`
+
+	// Jump connects through one or more bastion hosts (ssh -J), e.g.
+	// "admin@gateway.lan" or "gw1,gw2:2222".
+	Jump string `yaml:"jump,omitempty"`
+
+	// HostKey pins the host's public key (e.g., "ssh-ed25519 AAAA..."):
+	// only this key is accepted, regardless of known_hosts.
+	HostKey string `yaml:"host_key,omitempty"`
+
+	// Command is the command run on the remote host.
+	Command string `yaml:"command"`
+
+	// Group runs the command on every host of this targets group
+	// concurrently instead of on Host (ssh checks only).
+	Group string `yaml:"group,omitempty"`
+
+	// MinPassRatio is the fraction of the group's hosts that must pass for
+	// the check to pass (default: 1, all of them).
+	MinPassRatio float64 `yaml:"min_pass_ratio,omitempty"`
+}
+
+// GetMinPassRatio returns the fraction of hosts that must pass (default 1).
+func (s *SSHConfig) GetMinPassRatio() float64 {
+	if s.MinPassRatio == 0 {
+		return 1
+	}
+	return s.MinPassRatio
+}
+
+// hostKeyPattern matches a public key as in known_hosts: type and base64.
+var hostKeyPattern = regexp.MustCompile(`^[a-z0-9@.-]+ [A-Za-z0-9+/]+={0,3}$`)
+
+// validate checks the connection settings shared by every remote target.
+func (s *SSHConfig) validate(field string) error {
+	if strings.ContainsAny(s.Jump, " \t\n") {
+		return fmt.Errorf("%s jump must not contain whitespace: %q", field, s.Jump)
+	}
+	if s.HostKey != "" && !hostKeyPattern.MatchString(s.HostKey) {
+		return fmt.Errorf("%s host_key must be \"TYPE BASE64\" (e.g., ssh-ed25519 AAAA...), got %q", field, s.HostKey)
+	}
+	if s.Group != "" && field != "ssh" {
+		return fmt.Errorf("%s does not support group", field)
+	}
+	if s.Group == "" && s.MinPassRatio != 0 {
+		return fmt.Errorf("%s min_pass_ratio requires group", field)
+	}
+	return nil
+}
+
+// GRPCConfig defines a native grpc.health.v1 health check, so no grpcurl
+// is needed on the runner.
+type GRPCConfig struct {
+	// Address is the server's host:port.
+	Address string `yaml:"address"`
+
+	// Service is the health service name to query (empty = whole server).
+	Service string `yaml:"service,omitempty"`
+
+	// TLS connects over TLS instead of plaintext HTTP/2.
+	TLS bool `yaml:"tls,omitempty"`
+
+	// InsecureSkipVerify disables certificate verification (TLS only).
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+
+	// ServerName overrides the TLS server name used for verification.
+	ServerName string `yaml:"server_name,omitempty"`
+}
+
+// SMTPConfig defines a native mail relay check: connect, EHLO, optional
+// STARTTLS and AUTH, and optional sender/recipient acceptance. No message
+// is sent.
+type SMTPConfig struct {
+	// Address is the relay's host:port.
+	Address string `yaml:"address"`
+
+	// Hello is the EHLO hostname (defaults to "localhost").
+	Hello string `yaml:"hello,omitempty"`
+
+	// StartTLS requires the relay to offer and complete STARTTLS.
+	StartTLS bool `yaml:"starttls,omitempty"`
+
+	// TLS connects with implicit TLS (e.g., port 465) instead of plaintext.
+	TLS bool `yaml:"tls,omitempty"`
+
+	// InsecureSkipVerify disables certificate verification.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+
+	// ServerName overrides the TLS server name used for verification.
+	ServerName string `yaml:"server_name,omitempty"`
+
+	// Username enables PLAIN authentication (requires TLS or STARTTLS).
+	Username string `yaml:"username,omitempty"`
+
+	// Password is the AUTH password; use {{env "VAR"}} to keep it out of the file.
+	Password string `yaml:"password,omitempty"`
+
+	// MailFrom, if set, checks that MAIL FROM is accepted.
+	MailFrom string `yaml:"mail_from,omitempty"`
+
+	// RcptTo, if set with MailFrom, checks that RCPT TO is accepted.
+	RcptTo string `yaml:"rcpt_to,omitempty"`
+}
+
+// NTPConfig defines a native clock drift check against an NTP server.
+type NTPConfig struct {
+	// Server is the NTP server host, with optional :port (default 123).
+	Server string `yaml:"server"`
+
+	// MaxOffset is the largest tolerated offset before FAIL (default: 1s).
+	MaxOffset Duration `yaml:"max_offset,omitempty"`
+
+	// WarnOffset, if set, is the offset beyond which the check WARNs.
+	WarnOffset Duration `yaml:"warn_offset,omitempty"`
+}
+
+// GetMaxOffset returns the FAIL threshold, or the 1s default if not set.
+func (n *NTPConfig) GetMaxOffset() time.Duration {
+	if n.MaxOffset.Duration > 0 {
+		return n.MaxOffset.Duration
+	}
+	return time.Second
+}
+
+// CertificateConfig selects cert-manager Certificates that must be Ready
+// and valid for a minimum number of days.
+type CertificateConfig struct {
+	// Namespace limits the check to one namespace (default: all namespaces).
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Selector filters certificates by label (e.g., "tier=edge").
+	Selector string `yaml:"selector,omitempty"`
+
+	// MinValidDays fails certificates expiring sooner than this (default: 14).
+	MinValidDays int `yaml:"min_valid_days,omitempty"`
+}
+
+// GetMinValidDays returns the expiry threshold, or the 14-day default.
+func (c *CertificateConfig) GetMinValidDays() int {
+	if c.MinValidDays > 0 {
+		return c.MinValidDays
+	}
+	return 14
+}
+
+// IngressConfig selects Ingresses (and optionally HTTPRoutes) whose hosts
+// are probed over HTTPS, so new apps get coverage without new checks.
+type IngressConfig struct {
+	// Namespace limits discovery to one namespace (default: all namespaces).
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Selector filters routes by label (e.g., "smoke=true").
+	Selector string `yaml:"selector,omitempty"`
+
+	// HTTPRoutes also discovers Gateway API HTTPRoute hostnames.
+	HTTPRoutes bool `yaml:"httproutes,omitempty"`
+
+	// Path is the request path probed on each host (default: "/").
+	Path string `yaml:"path,omitempty"`
+
+	// InsecureSkipVerify disables certificate verification.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// GetPath returns the probe path, or "/" if not set.
+func (i *IngressConfig) GetPath() string {
+	if i.Path != "" {
+		return i.Path
+	}
+	return "/"
+}
+
+// ExternalConfig validates a hostname published outside the homelab, as
+// the internet sees it: public DNS is queried directly so split-horizon
+// records can't mask a broken public record, and the external URL is
+// fetched from the publicly resolved address.
+type ExternalConfig struct {
+	// Host is the externally published hostname (e.g., "app.example.com").
+	Host string `yaml:"host"`
+
+	// Resolver is the public DNS server queried, host[:port]
+	// (default: 1.1.1.1:53).
+	Resolver string `yaml:"resolver,omitempty"`
+
+	// ExpectCNAME requires the host's canonical name to end with this
+	// suffix (e.g., "cfargotunnel.com" or a tunnel's "<id>.cfargotunnel.com").
+	ExpectCNAME string `yaml:"expect_cname,omitempty"`
+
+	// ExpectAddresses requires every resolved address to be one of these
+	// IPs or within one of these CIDRs (e.g., Cloudflare's ranges).
+	ExpectAddresses []string `yaml:"expect_addresses,omitempty"`
+
+	// Internal is the internal base URL (e.g., "http://web.media.svc:8080");
+	// if set, the external and internal responses for Path must have the
+	// same content hash.
+	Internal string `yaml:"internal,omitempty"`
+
+	// Path is the request path compared (default: "/").
+	Path string `yaml:"path,omitempty"`
+
+	// InsecureSkipVerify disables certificate verification for both URLs.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// GetResolver returns the DNS server address, defaulting to 1.1.1.1:53.
+func (e *ExternalConfig) GetResolver() string {
+	if e.Resolver == "" {
+		return "1.1.1.1:53"
+	}
+	if _, _, err := net.SplitHostPort(e.Resolver); err != nil {
+		return net.JoinHostPort(e.Resolver, "53")
+	}
+	return e.Resolver
+}
+
+// GetPath returns the compared path, or "/" if not set.
+func (e *ExternalConfig) GetPath() string {
+	if e.Path != "" {
+		return e.Path
+	}
+	return "/"
+}
+
+// FileConfig defines assertions on a file, directory, or mount, e.g. "is
+// the NFS share mounted and its heartbeat file fresh". The path must exist;
+// every other assertion is optional.
+type FileConfig struct {
+	// Path is the absolute path to check.
+	Path string `yaml:"path"`
+
+	// SSH, if set, checks the path on a remote host (its command is unused).
+	SSH *SSHConfig `yaml:"ssh,omitempty"`
+
+	// Mounted requires the path to be a mount point.
+	Mounted bool `yaml:"mounted,omitempty"`
+
+	// MaxAge fails if the path was last modified longer ago than this.
+	MaxAge Duration `yaml:"max_age,omitempty"`
+
+	// MinSize and MaxSize bound the file size (e.g., "1KiB", "2GB").
+	MinSize ByteSize `yaml:"min_size,omitempty"`
+	MaxSize ByteSize `yaml:"max_size,omitempty"`
+
+	// SHA256 is the expected hex checksum of the file's contents.
+	SHA256 string `yaml:"sha256,omitempty"`
+
+	// MinFree is the minimum free space on the path's filesystem.
+	MinFree ByteSize `yaml:"min_free,omitempty"`
+
+	// MinFreePercent is the minimum free space as a percentage (1-100).
+	MinFreePercent int `yaml:"min_free_percent,omitempty"`
+}
+
+// SystemdConfig defines systemd units that must be running on a host, so
+// node-level services (smbd, tailscaled, nut) join the cluster suite.
+type SystemdConfig struct {
+	// Units are the unit names (e.g., "smbd", "nut-server.service").
+	Units []string `yaml:"units"`
+
+	// Enabled also requires each unit to be enabled at boot.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// SSH, if set, checks units on a remote host (its command is unused).
+	SSH *SSHConfig `yaml:"ssh,omitempty"`
+}
+
+// resourceNamePattern matches Kubernetes extended resource names.
+var resourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9.-]+/[A-Za-z0-9._-]+$`)
+
+// imagePattern matches container image references.
+var imagePattern = regexp.MustCompile(`^[A-Za-z0-9./_:@-]+$`)
+
+// unitNamePattern matches valid systemd unit names.
+var unitNamePattern = regexp.MustCompile(`^[A-Za-z0-9:_.@-]+$`)
+
+// GPUConfig selects nodes that must advertise a device plugin's extended
+// resource, catching driver/toolkit breakage after node upgrades.
+type GPUConfig struct {
+	// Resource is the extended resource name (default: nvidia.com/gpu).
+	Resource string `yaml:"resource,omitempty"`
+
+	// Selector picks the nodes expected to have the resource (default: all).
+	Selector string `yaml:"selector,omitempty"`
+
+	// MinPerNode is the minimum allocatable count per node (default: 1).
+	MinPerNode int `yaml:"min_per_node,omitempty"`
+
+	// Job, if set, also runs a one-off Job requesting one unit of the
+	// resource and requires it to succeed. This creates (and deletes) a
+	// Job, so the check is not read-only.
+	Job *GPUJobConfig `yaml:"job,omitempty"`
+}
+
+// GPUJobConfig defines the Job run by a gpu check.
+type GPUJobConfig struct {
+	// Image is the container image (e.g., "nvidia/cuda:12.4.1-base-ubuntu22.04").
+	Image string `yaml:"image"`
+
+	// Namespace is where the Job runs (default: "default").
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Command is the container command (default: ["nvidia-smi"]).
+	Command []string `yaml:"command,omitempty"`
+}
+
+// GetResource returns the extended resource, or nvidia.com/gpu.
+func (g *GPUConfig) GetResource() string {
+	if g.Resource != "" {
+		return g.Resource
+	}
+	return "nvidia.com/gpu"
+}
+
+// GetMinPerNode returns the per-node minimum, or 1.
+func (g *GPUConfig) GetMinPerNode() int {
+	if g.MinPerNode > 0 {
+		return g.MinPerNode
+	}
+	return 1
+}
+
+// GetNamespace returns the Job namespace, or "default".
+func (j *GPUJobConfig) GetNamespace() string {
+	if j.Namespace != "" {
+		return j.Namespace
+	}
+	return "default"
+}
+
+// GetCommand returns the Job command, or nvidia-smi.
+func (j *GPUJobConfig) GetCommand() []string {
+	if len(j.Command) > 0 {
+		return j.Command
+	}
+	return []string{"nvidia-smi"}
+}
+
+// ExpectConfig defines expectations for check results.
+type ExpectConfig struct {
+	// Gating indicates whether FAIL blocks rollouts (default: true).
+	Gating *bool `yaml:"gating,omitempty"`
+
+	// ExitCodes lists exit codes that count as PASS, for tools with
+	// nonstandard conventions (e.g., grep, diff). An unlisted 0 is FAIL.
+	ExitCodes []int `yaml:"exit_codes,omitempty"`
+
+	// Outcome is the expected outcome: "pass" (default) or "fail" for
+	// negative tests, which PASS when the command fails.
+	Outcome string `yaml:"outcome,omitempty"`
+
+	// MaxDuration reports a PASS that took longer than this, retries
+	// included, as WARN (0 = no limit).
+	MaxDuration Duration `yaml:"max_duration,omitempty"`
+}
+
+// Expected outcomes for expect.outcome.
+const (
+	ExpectOutcomePass = "pass"
+	ExpectOutcomeFail = "fail"
+)
+
+// ExpectedFailure marks a check as known-broken. It can be written as
+// `expected_failure: true` or as an object with a reason and expiry date.
+type ExpectedFailure struct {
+	// Reason explains why the check is expected to fail.
+	Reason string `yaml:"reason,omitempty"`
+
+	// Until is an optional expiry date (YYYY-MM-DD); after it the check
+	// reports normally again.
+	Until string `yaml:"until,omitempty"`
+
+	// disabled is set by an explicit `expected_failure: false`.
+	disabled bool
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for ExpectedFailure.
+func (e *ExpectedFailure) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var enabled bool
+		if err := value.Decode(&enabled); err != nil {
+			return err
+		}
+		e.disabled = !enabled
+		return nil
+	}
+
+	type plain ExpectedFailure
+	return value.Decode((*plain)(e))
+}
+
+// MarshalYAML implements yaml.Marshaler for ExpectedFailure, keeping an
+// explicit `expected_failure: false`.
+func (e ExpectedFailure) MarshalYAML() (any, error) {
+	if e.disabled {
+		return false, nil
+	}
+	type plain ExpectedFailure
+	return plain(e), nil
+}
+
+// dateLayout is the layout for date-only config fields.
+const dateLayout = "2006-01-02"
+
+// IsActive returns whether the expected failure applies at the given time.
+// Expiry dates are inclusive.
+func (e *ExpectedFailure) IsActive(now time.Time) bool {
+	if e == nil || e.disabled {
+		return false
+	}
+	if e.Until == "" {
+		return true
+	}
+	until, err := time.Parse(dateLayout, e.Until)
+	if err != nil {
+		return true
+	}
+	return now.Before(until.AddDate(0, 0, 1))
+}
+
+// Disabled records why and since when a check is turned off, so temporary
+// disables stay visible and expire instead of becoming permanent.
+type Disabled struct {
+	// Reason explains why the check is disabled (required).
+	Reason string `yaml:"reason"`
+
+	// Since is the date (YYYY-MM-DD) the check was disabled, for the record.
+	Since string `yaml:"since,omitempty"`
+
+	// Until is an optional expiry date (YYYY-MM-DD); after it the check
+	// runs again.
+	Until string `yaml:"until,omitempty"`
+}
+
+// IsActive returns whether the disable applies at the given time.
+// Expiry dates are inclusive.
+func (d *Disabled) IsActive(now time.Time) bool {
+	return d != nil && activeUntil(d.Until, now)
+}
+
+// Describe returns the SKIP reason for a disabled check, including how
+// long the disable has left (or that it never expires).
+func (d *Disabled) Describe(now time.Time) string {
+	msg := "disabled: " + d.Reason
+	if d.Since != "" {
+		msg += fmt.Sprintf(" (since %s)", d.Since)
+	}
+	return msg + describeUntil(d.Until, now)
+}
+
+// Quarantine keeps a known-flaky check running without letting it block:
+// its failures report as non-gating WARN until the optional expiry date,
+// and the summary lists it so it isn't forgotten.
+type Quarantine struct {
+	// Reason explains why the check is quarantined (required).
+	Reason string `yaml:"reason"`
+
+	// Since is the date (YYYY-MM-DD) the check was quarantined, for the
+	// record.
+	Since string `yaml:"since,omitempty"`
+
+	// Until is an optional expiry date (YYYY-MM-DD); after it the check
+	// gates again.
+	Until string `yaml:"until,omitempty"`
+}
+
+// IsActive returns whether the quarantine applies at the given time.
+// Expiry dates are inclusive.
+func (q *Quarantine) IsActive(now time.Time) bool {
+	return q != nil && activeUntil(q.Until, now)
+}
+
+// Describe returns the quarantine's reason, including how long it has
+// left (or that it never expires).
+func (q *Quarantine) Describe(now time.Time) string {
+	msg := q.Reason
+	if q.Since != "" {
+		msg += fmt.Sprintf(" (since %s)", q.Since)
+	}
+	return msg + describeUntil(q.Until, now)
+}
+
+// activeUntil returns whether an optional, inclusive YYYY-MM-DD expiry
+// date is still ahead. Unparseable dates never expire.
+func activeUntil(until string, now time.Time) bool {
+	if until == "" {
+		return true
+	}
+	date, err := time.Parse(dateLayout, until)
+	if err != nil {
+		return true
+	}
+	return now.Before(date.AddDate(0, 0, 1))
+}
+
+// describeUntil formats how long is left until an optional YYYY-MM-DD
+// expiry date, as a suffix for a reason.
+func describeUntil(until string, now time.Time) string {
+	if until == "" {
+		return " - no expiry set"
+	}
+	date, err := time.Parse(dateLayout, until)
+	if err != nil {
+		return ""
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch days := int(date.Sub(today).Hours() / 24); days {
+	case 0:
+		return fmt.Sprintf(" - expires today (%s)", until)
+	case 1:
+		return fmt.Sprintf(" - expires tomorrow (%s)", until)
+	default:
+		return fmt.Sprintf(" - expires in %d days (%s)", days, until)
+	}
+}
+
+// ExtractCapture applies a capture regex to output and returns the first
+// submatch, or the whole match if the regex has no groups.
+func ExtractCapture(pattern, output string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex %q: %w", pattern, err)
+	}
+	m := re.FindStringSubmatch(output)
+	if m == nil {
+		return "", fmt.Errorf("no match for %q", pattern)
+	}
+	if len(m) > 1 {
+		return m[1], nil
+	}
+	return m[0], nil
+}
+
+// IsGating returns whether this check is gating (blocks on failure).
+// Defaults to true if not explicitly set.
+func (c *Check) IsGating() bool {
+	if c.Expect == nil || c.Expect.Gating == nil {
+		return true // Default: gating
+	}
+	return *c.Expect.Gating
+}
+
+// GetID returns the check's explicit ID, or a slug derived from its name.
+func (c *Check) GetID() string {
+	if c.ID != "" {
+		return c.ID
+	}
+	return Slugify(c.Name)
+}
+
+// Slugify converts a display name into a lowercase, dash-separated ID.
+// "Gateway Has IP" becomes "gateway-has-ip".
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// IsRetry returns whether retry on failure is enabled for this check.
+// Defaults to false if not explicitly set.
+func (c *Check) IsRetry() bool {
+	return c.Retry != nil && !c.Retry.disabled
+}
+
+// GetMaxRetries returns the check's maximum number of retries, or the
+// default if not set.
+func (c *Check) GetMaxRetries(defaultMax int) int {
+	if c.Retry != nil && c.Retry.Max != nil {
+		return *c.Retry.Max
+	}
+	return defaultMax
+}
+
+// GetRetryBackoff returns the check's retry backoff (retry.backoff, then
+// retry_backoff), or nil for a fixed delay.
+func (c *Check) GetRetryBackoff() *RetryBackoff {
+	if c.Retry != nil && c.Retry.Backoff != nil {
+		return c.Retry.Backoff
+	}
+	return c.RetryBackoff
+}
+
+// IsNegative returns whether the check is expected to fail.
+func (c *Check) IsNegative() bool {
+	return c.Expect != nil && c.Expect.Outcome == ExpectOutcomeFail
+}
+
+// PassExitCodes returns the exit codes declared as PASS, if any.
+func (c *Check) PassExitCodes() []int {
+	if c.Expect == nil {
+		return nil
+	}
+	return c.Expect.ExitCodes
+}
+
+// MaxDuration returns the slow-check threshold, or 0 if none is set.
+func (c *Check) MaxDuration() time.Duration {
+	if c.Expect == nil {
+		return 0
+	}
+	return c.Expect.MaxDuration.Duration
+}
+
+// GetTimeout returns the check timeout, or the default if not set.
+func (c *Check) GetTimeout(defaultTimeout time.Duration) time.Duration {
+	if c.Timeout.Duration > 0 {
+		return c.Timeout.Duration
+	}
+	return defaultTimeout
+}
+
+// GetRetryDelay returns the check retry delay (retry.delay, then
+// retry_delay), or the default if not set.
+func (c *Check) GetRetryDelay(defaultDelay time.Duration) time.Duration {
+	if c.Retry != nil && c.Retry.Delay.Duration > 0 {
+		return c.Retry.Delay.Duration
+	}
+	if c.RetryDelay.Duration > 0 {
+		return c.RetryDelay.Duration
+	}
+	return defaultDelay
+}
+
+// DiagnosticsFor returns the diagnostics commands for a check: its own,
+// then those of each of its tags, without duplicates.
+func (c *Config) DiagnosticsFor(check *Check) []string {
+	commands := append([]string(nil), check.Diagnostics...)
+	for _, tag := range check.Tags {
+		for _, cmd := range c.Diagnostics[tag] {
+			if !slices.Contains(commands, cmd) {
+				commands = append(commands, cmd)
+			}
+		}
+	}
+	return commands
+}
+
+// RequiredTools returns the local binaries the check needs: those its kind
+// runs (e.g., kubectl for exec_in_pod, ssh for remote hosts) plus any it
+// declares in requires. Tools used only on a remote host are not included.
+func (c *Check) RequiredTools() []string {
+	tools := append([]string(nil), c.Requires...)
+	if transform.NeedsJQ(c.Transform) {
+		tools = append(tools, "jq")
+	}
+	if c.ExecInPod != nil || c.Certificate != nil || c.Ingress != nil || c.GPU != nil {
+		tools = append(tools, "kubectl")
+	}
+	if c.SSH != nil || (c.File != nil && c.File.SSH != nil) || (c.Systemd != nil && c.Systemd.SSH != nil) {
+		tools = append(tools, "ssh")
+	}
+	if c.File != nil && c.File.SSH == nil && c.File.SHA256 != "" {
+		tools = append(tools, "sha256sum")
+	}
+	if c.Systemd != nil && c.Systemd.SSH == nil {
+		tools = append(tools, "systemctl")
+	}
+	sort.Strings(tools)
+	return slices.Compact(tools)
+}
+
+// Duration is a wrapper for time.Duration that supports YAML unmarshaling.
+// Values are Go durations ("30s", "1m30s") or bare seconds (30, 1.5).
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for Duration.
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return valueError(value, "%v", err)
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// ParseDuration parses a Go duration, or a bare non-negative number as
+// seconds.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(secs, 0) && !math.IsNaN(secs) {
+		switch {
+		case secs < 0:
+			return 0, fmt.Errorf("invalid duration %q: must not be negative", s)
+		case secs*float64(time.Second) >= math.MaxInt64:
+			return 0, fmt.Errorf("invalid duration %q: out of range", s)
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (e.g., 30s, 1m30s, or 30 for seconds)", s)
+	}
+	return parsed, nil
+}
+
+// valueError reports an invalid value as a *yaml.TypeError with its line,
+// so decoding continues and collects every problem instead of stopping.
+func valueError(value *yaml.Node, format string, args ...any) error {
+	msg := fmt.Sprintf("line %d: %s", value.Line, fmt.Sprintf(format, args...))
+	return &yaml.TypeError{Errors: []string{msg}}
+}
+
+// MarshalYAML implements yaml.Marshaler for Duration.
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+// ByteSize is a size in bytes that unmarshals from YAML as a plain number
+// or with a unit suffix: "512", "10K"/"10KB" (1000s), "10Ki"/"10KiB" (1024s),
+// through T/TB/Ti/TiB. Numbers with a unit may be fractional ("1.5GiB").
+// It also implements flag.Value.
+type ByteSize int64
+
+// byteSizePattern splits an upper-cased size into number and unit.
+var byteSizePattern = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*([KMGT]I?B?|B)?\s*$`)
+
+// UnmarshalYAML implements yaml.Unmarshaler for ByteSize.
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return valueError(value, "%v", err)
+	}
+	*b = size
+	return nil
+}
+
+// String implements flag.Value for ByteSize.
+func (b *ByteSize) String() string {
+	if b == nil {
+		return "0"
+	}
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+// Set implements flag.Value for ByteSize.
+func (b *ByteSize) Set(s string) error {
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// byteSizeExponents maps unit prefixes to powers of the base.
+var byteSizeExponents = map[string]int{"K": 1, "M": 2, "G": 3, "T": 4}
+
+// ParseByteSize parses a size like "10GiB" or "500M" into bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	m := byteSizePattern.FindStringSubmatch(strings.ToUpper(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q (e.g., 512, 10MB, 2GiB)", s)
+	}
+	unit := strings.TrimSuffix(m[2], "B")
+	base := int64(1000)
+	if strings.HasSuffix(unit, "I") {
+		base = 1024
+		unit = strings.TrimSuffix(unit, "I")
+	}
+	multiplier := int64(1)
+	for i := 0; i < byteSizeExponents[unit]; i++ {
+		multiplier *= base
+	}
+
+	// Fractions need a unit; whole numbers stay exact
+	if strings.Contains(m[1], ".") {
+		if multiplier == 1 {
+			return 0, fmt.Errorf("invalid size %q: fractional bytes", s)
+		}
+		f, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q: %w", s, err)
+		}
+		size := math.Round(f * float64(multiplier))
+		if size >= math.MaxInt64 {
+			return 0, fmt.Errorf("invalid size %q: out of range", s)
+		}
+		return ByteSize(size), nil
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	if n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid size %q: out of range", s)
+	}
+	return ByteSize(n * multiplier), nil
+}
+
+// sha256Pattern matches a hex-encoded SHA-256 digest.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// TemplateVars holds template variables for command substitution.
+type TemplateVars struct {
+	// Cluster is the target cluster name (e.g., "home").
+	Cluster string
+
+	// Namespace is the target namespace.
+	Namespace string
+
+	// Context is the kubectl context.
+	Context string
+
+	// Custom allows for additional custom variables.
+	Custom map[string]string
+
+	// Secret holds resolved secret values by name.
+	Secret map[string]string
+}
+
+// LoadConfig loads a smoke test configuration from a YAML file.
+// Unknown fields are ignored.
+func LoadConfig(path string) (*Config, error) {
+	return loadConfig(path, false)
+}
+
+// LoadConfigStrict loads a smoke test configuration from a YAML file,
+// rejecting unknown fields so typos like "commnad:" fail loudly.
+func LoadConfigStrict(path string) (*Config, error) {
+	return loadConfig(path, true)
+}
+
+func loadConfig(path string, strict bool) (*Config, error) {
+	return LoadConfigWith(path, LoadOptions{Strict: strict})
+}
+
+// ApplyDefaults copies suite-level defaults onto every check that doesn't
+// set the corresponding field. Tags are merged, with defaults first.
+func (c *Config) ApplyDefaults() {
+	d := c.Defaults
+	if d == nil {
+		return
+	}
+
+	for i := range c.Checks {
+		check := &c.Checks[i]
+
+		if check.Timeout.Duration == 0 {
+			check.Timeout = d.Timeout
+		}
+		if d.Retry != nil {
+			if check.Retry == nil {
+				check.Retry = &RetryConfig{disabled: d.Retry.disabled}
+			}
+			check.Retry.inherit(d.Retry)
+		}
+		if check.RetryDelay.Duration == 0 {
+			check.RetryDelay = d.RetryDelay
+		}
+		if check.RetryBackoff == nil && d.RetryBackoff != nil {
+			backoff := *d.RetryBackoff
+			check.RetryBackoff = &backoff
+		}
+		if d.Gating != nil {
+			if check.Expect == nil {
+				check.Expect = &ExpectConfig{}
+			}
+			if check.Expect.Gating == nil {
+				gating := *d.Gating
+				check.Expect.Gating = &gating
+			}
+		}
+		if len(d.Tags) > 0 {
+			check.Tags = mergeTags(d.Tags, check.Tags)
+		}
+		if check.Owner == "" {
+			check.Owner = d.Owner
+		}
+		if check.Team == "" {
+			check.Team = d.Team
+		}
+	}
+}
+
+// mergeTags returns base followed by extra, without duplicates.
+func mergeTags(base, extra []string) []string {
+	seen := make(map[string]bool, len(base)+len(extra))
+	merged := make([]string, 0, len(base)+len(extra))
+	for _, tag := range append(append([]string{}, base...), extra...) {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		merged = append(merged, tag)
+	}
+	return merged
+}
+
+// checkKindFields lists the fields that set a check's kind, in the order
+// kinds reports them.
+var checkKindFields = []string{
+	"command", "script", "exec_in_pod", "ssh", "grpc", "smtp", "ntp",
+	"certificate", "ingress", "external", "file", "systemd", "gpu",
+}
+
+// kinds returns the YAML keys of the check kinds set on a check. Command
+// and script count as one kind, as script takes precedence over command.
+func (c *Check) kinds() []string {
+	var kinds []string
+	if c.Command != "" || c.Script != nil {
+		kinds = append(kinds, "command/script")
+	}
+	if c.ExecInPod != nil {
+		kinds = append(kinds, "exec_in_pod")
+	}
+	if c.SSH != nil {
+		kinds = append(kinds, "ssh")
+	}
+	if c.GRPC != nil {
+		kinds = append(kinds, "grpc")
+	}
+	if c.SMTP != nil {
+		kinds = append(kinds, "smtp")
+	}
+	if c.NTP != nil {
+		kinds = append(kinds, "ntp")
+	}
+	if c.Certificate != nil {
+		kinds = append(kinds, "certificate")
+	}
+	if c.Ingress != nil {
+		kinds = append(kinds, "ingress")
+	}
+	if c.External != nil {
+		kinds = append(kinds, "external")
+	}
+	if c.File != nil {
+		kinds = append(kinds, "file")
+	}
+	if c.Systemd != nil {
+		kinds = append(kinds, "systemd")
+	}
+	if c.GPU != nil {
+		kinds = append(kinds, "gpu")
+	}
+	return kinds
+}
+
+// Validate checks the configuration for errors.
+// Returns an error if any check is invalid, or every policy violation if
+// the config has a policy.
+func (c *Config) Validate() error {
+	if len(c.Checks) == 0 {
+		return fmt.Errorf("no checks defined")
+	}
+
+	if err := c.validateSettings(); err != nil {
+		return err
+	}
+
+	index := newCheckIndex(len(c.Checks))
+	for i := range c.Checks {
+		check := &c.Checks[i]
+		if err := validateCheck(i, check); err != nil {
+			return err
+		}
+		if err := c.validateGroup(i, check); err != nil {
+			return err
+		}
+		if err := c.validateUses(i, check); err != nil {
+			return err
+		}
+		if err := index.add(i, check); err != nil {
+			return err
+		}
+	}
+
+	if c.Policy != nil {
+		return c.Policy.Validate(c)
+	}
+	return nil
+}
+
+// validateSettings checks the suite-level settings (everything but checks).
+func (c *Config) validateSettings() error {
+	if d := c.Defaults; d != nil && d.Retry != nil {
+		if err := d.Retry.validate("defaults.retry"); err != nil {
+			return err
+		}
+	}
+	if d := c.Defaults; d != nil && d.RetryBackoff != nil {
+		if err := d.RetryBackoff.validate("defaults.retry_backoff"); err != nil {
+			return err
+		}
+	}
+
+	for layer, l := range c.Layers {
+		if l.Timeout.Duration < 0 {
+			return fmt.Errorf("layers.%d: timeout must not be negative", layer)
+		}
+	}
+
+	for group, hosts := range c.Targets {
+		for _, host := range hosts {
+			if host == "" || strings.ContainsAny(host, " \t\n") {
+				return fmt.Errorf("targets.%s: invalid host %q", group, host)
+			}
+		}
+	}
+
+	for phase, commands := range map[string][]string{"setup": c.Setup, "teardown": c.Teardown} {
+		for _, cmd := range commands {
+			if strings.TrimSpace(cmd) == "" {
+				return fmt.Errorf("%s: empty command", phase)
+			}
+		}
+	}
+
+	if err := c.Vault.validate(); err != nil {
+		return err
+	}
+	for i, source := range c.VarsFrom {
+		if err := source.validate(i); err != nil {
+			return err
+		}
+	}
+	for name, secret := range c.Secrets {
+		if err := validateSecret(name, secret); err != nil {
+			return err
+		}
+	}
+
+	for name, f := range c.Fixtures {
+		if len(f.Setup) == 0 && len(f.Teardown) == 0 {
+			return fmt.Errorf("fixtures.%s: must have setup or teardown", name)
+		}
+		for _, commands := range [][]string{f.Setup, f.Teardown} {
+			for _, cmd := range commands {
+				if strings.TrimSpace(cmd) == "" {
+					return fmt.Errorf("fixtures.%s: empty command", name)
+				}
+			}
+		}
+	}
 
-	// Command is the shell command to run (alternative to Script).
-	Command string `yaml:"command,omitempty"`
+	for tag, commands := range c.Diagnostics {
+		for _, cmd := range commands {
+			if strings.TrimSpace(cmd) == "" {
+				return fmt.Errorf("diagnostics.%s: empty command", tag)
+			}
+		}
+	}
 
-	// Script defines an external script to run (alternative to Command).
-	Script *ScriptConfig `yaml:"script,omitempty"`
+	for i := range c.Overrides {
+		if err := c.Overrides[i].validate(i); err != nil {
+			return err
+		}
+	}
+	for i := range c.Maintenance {
+		if err := c.Maintenance[i].validate(fmt.Sprintf("maintenance[%d]", i), true); err != nil {
+			return err
+		}
+	}
 
-	// Validate defines output validation postconditions.
-	Validate *validate.Validation `yaml:"validate,omitempty"`
+	if s := c.Snapshot; s != nil && s.Events < 0 {
+		return fmt.Errorf("snapshot: events must be positive, got %d", s.Events)
+	}
 
-	// Expect defines expectations for the check result.
-	Expect *ExpectConfig `yaml:"expect,omitempty"`
+	if h := c.OnGatingFailure; h != nil {
+		if h.Command == "" && h.Webhook == "" {
+			return fmt.Errorf("on_gating_failure: must have command or webhook")
+		}
+		if h.Payload != "" {
+			if h.Webhook == "" {
+				return fmt.Errorf("on_gating_failure: payload requires webhook")
+			}
+			if _, err := template.New("payload").Funcs(TemplateFuncs()).Parse(h.Payload); err != nil {
+				return fmt.Errorf("on_gating_failure: invalid payload template: %w", err)
+			}
+		}
+	}
 
-	// Retry enables retry on failure.
-	Retry bool `yaml:"retry,omitempty"`
+	if c.Summary != nil {
+		if err := c.Summary.Console.validate("console"); err != nil {
+			return err
+		}
+		if err := c.Summary.JSON.validate("json"); err != nil {
+			return err
+		}
+	}
 
-	// Timeout is the per-check timeout (overrides default).
-	Timeout Duration `yaml:"timeout,omitempty"`
+	return nil
 }
 
-// ScriptConfig defines an external script to run.
-type ScriptConfig struct {
-	// Path is the path to the script file (relative to checks dir or absolute).
-	Path string `yaml:"path"`
-
-	// Args are the arguments to pass to the script.
-	Args []string `yaml:"args,omitempty"`
+// validateGroup checks that an ssh check's group is a non-empty targets
+// group.
+func (c *Config) validateGroup(i int, check *Check) error {
+	if check.SSH == nil || check.SSH.Group == "" {
+		return nil
+	}
+	if len(c.Targets[check.SSH.Group]) == 0 {
+		return fmt.Errorf("check %d (%s): ssh group %q is not a non-empty targets group", i, check.Name, check.SSH.Group)
+	}
+	return nil
 }
 
-// ExpectConfig defines expectations for check results.
-type ExpectConfig struct {
-	// Gating indicates whether FAIL blocks rollouts (default: true).
-	Gating *bool `yaml:"gating,omitempty"`
+// validateUses checks that the fixtures a check uses are defined.
+func (c *Config) validateUses(i int, check *Check) error {
+	for _, name := range check.Uses {
+		if _, ok := c.Fixtures[name]; !ok {
+			return fmt.Errorf("check %d (%s): uses unknown fixture %q", i, check.Name, name)
+		}
+	}
+	return nil
 }
 
-// IsGating returns whether this check is gating (blocks on failure).
-// Defaults to true if not explicitly set.
-func (c *Check) IsGating() bool {
-	if c.Expect == nil || c.Expect.Gating == nil {
-		return true // Default: gating
+// HostsFor returns the hosts an ssh check runs on: its group's hosts, or
+// its single host.
+func (c *Config) HostsFor(spec *SSHConfig) []string {
+	if spec.Group == "" {
+		return []string{spec.Host}
 	}
-	return *c.Expect.Gating
+	return c.Targets[spec.Group]
 }
 
-// GetTimeout returns the check timeout, or the default if not set.
-func (c *Check) GetTimeout(defaultTimeout time.Duration) time.Duration {
-	if c.Timeout.Duration > 0 {
-		return c.Timeout.Duration
-	}
-	return defaultTimeout
+// checkIndex detects duplicate check names and IDs.
+type checkIndex struct {
+	names map[string]int
+	ids   map[string]int
 }
 
-// Duration is a wrapper for time.Duration that supports YAML unmarshaling.
-type Duration struct {
-	time.Duration
+// newCheckIndex returns an empty index sized for n checks.
+func newCheckIndex(n int) *checkIndex {
+	return &checkIndex{names: make(map[string]int, n), ids: make(map[string]int, n)}
 }
 
-// UnmarshalYAML implements yaml.Unmarshaler for Duration.
-func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
-	var s string
-	if err := value.Decode(&s); err != nil {
-		return err
+// add records check i, returning an error if its name or ID is taken.
+func (x *checkIndex) add(i int, check *Check) error {
+	// Check must have a unique name
+	if prev, ok := x.names[check.Name]; ok {
+		return fmt.Errorf("check %d (%s): duplicate name (also used by check %d)", i, check.Name, prev)
 	}
-	if s == "" {
-		d.Duration = 0
-		return nil
+	x.names[check.Name] = i
+
+	// Check IDs must be unique
+	id := check.GetID()
+	if prev, ok := x.ids[id]; ok {
+		return fmt.Errorf("check %d (%s): duplicate id %q (also used by check %d)", i, check.Name, id, prev)
 	}
-	parsed, err := time.ParseDuration(s)
-	if err != nil {
-		return fmt.Errorf("invalid duration %q: %w", s, err)
+	x.ids[id] = i
+	return nil
+}
+
+// validateWindow checks the reason and since/until dates of a disable or
+// quarantine, naming field in errors.
+func validateWindow(field, reason, since, until string) error {
+	if reason == "" {
+		return fmt.Errorf("%s missing reason", field)
+	}
+	for _, f := range []struct{ name, value string }{{"since", since}, {"until", until}} {
+		if f.value == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, f.value); err != nil {
+			return fmt.Errorf("invalid %s.%s %q (want YYYY-MM-DD)", field, f.name, f.value)
+		}
+	}
+	if since != "" && until != "" && until < since {
+		return fmt.Errorf("%s.until %s is before %s.since %s", field, until, field, since)
 	}
-	d.Duration = parsed
 	return nil
 }
 
-// TemplateVars holds template variables for command substitution.
-type TemplateVars struct {
-	// Cluster is the target cluster name (e.g., "home").
-	Cluster string
+// validateCheck checks the i-th check for errors, apart from name and ID
+// uniqueness.
+func validateCheck(i int, check *Check) error {
+	// Check must have a name and an ID
+	if check.Name == "" {
+		return fmt.Errorf("check %d: missing name", i)
+	}
+	if check.GetID() == "" {
+		return fmt.Errorf("check %d (%s): cannot derive id from name, set id explicitly", i, check.Name)
+	}
 
-	// Namespace is the target namespace.
-	Namespace string
+	// Check must have exactly one kind
+	switch kinds := check.kinds(); len(kinds) {
+	case 0:
+		return fmt.Errorf("check %d (%s): must have one of %s", i, check.Name, strings.Join(checkKindFields, ", "))
+	case 1:
+	default:
+		return fmt.Errorf("check %d (%s): %s cannot be combined", i, check.Name, strings.Join(kinds, ", "))
+	}
 
-	// Context is the kubectl context.
-	Context string
+	// sandbox only confines local commands, and needs an absolute root so
+	// the host filesystem stays out of reach
+	if s := check.Sandbox; s != nil {
+		if check.Command == "" && check.Script == nil {
+			return fmt.Errorf("check %d (%s): sandbox requires command or script", i, check.Name)
+		}
+		if s.Root == "" {
+			return fmt.Errorf("check %d (%s): sandbox requires root", i, check.Name)
+		}
+		if !filepath.IsAbs(s.Root) {
+			return fmt.Errorf("check %d (%s): sandbox root %q must be an absolute path", i, check.Name, s.Root)
+		}
+	}
 
-	// Custom allows for additional custom variables.
-	Custom map[string]string
-}
+	// exec_in_pod needs a command and exactly one target
+	if p := check.ExecInPod; p != nil {
+		if p.Command == "" {
+			return fmt.Errorf("check %d (%s): exec_in_pod missing command", i, check.Name)
+		}
+		if (p.Pod == "") == (p.Selector == "") {
+			return fmt.Errorf("check %d (%s): exec_in_pod must set exactly one of pod or selector", i, check.Name)
+		}
+	}
 
-// LoadConfig loads a smoke test configuration from a YAML file.
-func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path) //nolint:gosec // Path is user-provided config file
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	// ssh needs a host (or a group of them) and a command
+	if h := check.SSH; h != nil {
+		if h.Host == "" && h.Group == "" {
+			return fmt.Errorf("check %d (%s): ssh missing host", i, check.Name)
+		}
+		if h.Host != "" && h.Group != "" {
+			return fmt.Errorf("check %d (%s): ssh must set only one of host or group", i, check.Name)
+		}
+		if h.Group != "" && h.HostKey != "" {
+			return fmt.Errorf("check %d (%s): ssh host_key cannot be pinned for a group", i, check.Name)
+		}
+		if h.MinPassRatio < 0 || h.MinPassRatio > 1 {
+			return fmt.Errorf("check %d (%s): ssh min_pass_ratio must be between 0 and 1, got %g", i, check.Name, h.MinPassRatio)
+		}
+		if h.Command == "" {
+			return fmt.Errorf("check %d (%s): ssh missing command", i, check.Name)
+		}
+		if err := h.validate("ssh"); err != nil {
+			return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+		}
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	// grpc needs an address
+	if g := check.GRPC; g != nil {
+		if g.Address == "" {
+			return fmt.Errorf("check %d (%s): grpc missing address", i, check.Name)
+		}
+		if !g.TLS && (g.InsecureSkipVerify || g.ServerName != "") {
+			return fmt.Errorf("check %d (%s): grpc insecure_skip_verify and server_name require tls", i, check.Name)
+		}
 	}
 
-	return &config, nil
-}
+	// smtp needs an address; credentials need encryption
+	if m := check.SMTP; m != nil {
+		if m.Address == "" {
+			return fmt.Errorf("check %d (%s): smtp missing address", i, check.Name)
+		}
+		if m.TLS && m.StartTLS {
+			return fmt.Errorf("check %d (%s): smtp tls and starttls are mutually exclusive", i, check.Name)
+		}
+		if m.Username != "" && !m.TLS && !m.StartTLS {
+			return fmt.Errorf("check %d (%s): smtp username requires tls or starttls", i, check.Name)
+		}
+		if m.RcptTo != "" && m.MailFrom == "" {
+			return fmt.Errorf("check %d (%s): smtp rcpt_to requires mail_from", i, check.Name)
+		}
+	}
 
-// Validate checks the configuration for errors.
-// Returns an error if any check is invalid.
-func (c *Config) Validate() error {
-	if len(c.Checks) == 0 {
-		return fmt.Errorf("no checks defined")
+	// ntp needs a server; warn_offset must be below max_offset
+	if n := check.NTP; n != nil {
+		if n.Server == "" {
+			return fmt.Errorf("check %d (%s): ntp missing server", i, check.Name)
+		}
+		if n.WarnOffset.Duration >= n.GetMaxOffset() {
+			return fmt.Errorf("check %d (%s): ntp warn_offset %v must be less than max_offset %v", i, check.Name, n.WarnOffset.Duration, n.GetMaxOffset())
+		}
+	}
+
+	// certificate thresholds can't be negative
+	if c := check.Certificate; c != nil && c.MinValidDays < 0 {
+		return fmt.Errorf("check %d (%s): certificate min_valid_days must not be negative", i, check.Name)
 	}
 
-	for i, check := range c.Checks {
-		// Check must have a name
-		if check.Name == "" {
-			return fmt.Errorf("check %d: missing name", i)
+	// file needs an absolute path and sane thresholds
+	if f := check.File; f != nil {
+		if !strings.HasPrefix(f.Path, "/") {
+			return fmt.Errorf("check %d (%s): file path %q must be absolute", i, check.Name, f.Path)
+		}
+		if f.SSH != nil && f.SSH.Host == "" {
+			return fmt.Errorf("check %d (%s): file ssh missing host", i, check.Name)
+		}
+		if f.SSH != nil {
+			if err := f.SSH.validate("file ssh"); err != nil {
+				return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+			}
+		}
+		if f.MaxSize > 0 && f.MinSize > f.MaxSize {
+			return fmt.Errorf("check %d (%s): file min_size exceeds max_size", i, check.Name)
 		}
+		if f.SHA256 != "" && !sha256Pattern.MatchString(f.SHA256) {
+			return fmt.Errorf("check %d (%s): file sha256 must be 64 hex characters", i, check.Name)
+		}
+		if f.MinFreePercent < 0 || f.MinFreePercent > 100 {
+			return fmt.Errorf("check %d (%s): file min_free_percent must be between 0 and 100", i, check.Name)
+		}
+	}
 
-		// Check must have either command or script
-		if check.Command == "" && check.Script == nil {
-			return fmt.Errorf("check %d (%s): must have command or script", i, check.Name)
+	// systemd needs valid unit names
+	if sd := check.Systemd; sd != nil {
+		if len(sd.Units) == 0 {
+			return fmt.Errorf("check %d (%s): systemd missing units", i, check.Name)
+		}
+		for _, unit := range sd.Units {
+			if !unitNamePattern.MatchString(unit) {
+				return fmt.Errorf("check %d (%s): invalid systemd unit name %q", i, check.Name, unit)
+			}
+		}
+		if sd.SSH != nil && sd.SSH.Host == "" {
+			return fmt.Errorf("check %d (%s): systemd ssh missing host", i, check.Name)
+		}
+		if sd.SSH != nil {
+			if err := sd.SSH.validate("systemd ssh"); err != nil {
+				return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+			}
+		}
+	}
+
+	// gpu names must be safe to embed in the Job manifest
+	if g := check.GPU; g != nil {
+		if !resourceNamePattern.MatchString(g.GetResource()) {
+			return fmt.Errorf("check %d (%s): invalid gpu resource %q", i, check.Name, g.Resource)
+		}
+		if j := g.Job; j != nil && !imagePattern.MatchString(j.Image) {
+			return fmt.Errorf("check %d (%s): invalid gpu job image %q", i, check.Name, j.Image)
+		}
+	}
+
+	// external needs a host and something to verify
+	if e := check.External; e != nil {
+		if e.Host == "" {
+			return fmt.Errorf("check %d (%s): external missing host", i, check.Name)
+		}
+		if e.ExpectCNAME == "" && len(e.ExpectAddresses) == 0 && e.Internal == "" {
+			return fmt.Errorf("check %d (%s): external must set expect_cname, expect_addresses, or internal", i, check.Name)
+		}
+		for _, addr := range e.ExpectAddresses {
+			if net.ParseIP(addr) == nil {
+				if _, _, err := net.ParseCIDR(addr); err != nil {
+					return fmt.Errorf("check %d (%s): external expect_addresses: %q is not an IP or CIDR", i, check.Name, addr)
+				}
+			}
+		}
+		if e.Path != "" && !strings.HasPrefix(e.Path, "/") {
+			return fmt.Errorf("check %d (%s): external path %q must start with /", i, check.Name, e.Path)
+		}
+	}
+
+	// ingress probe paths are absolute
+	if in := check.Ingress; in != nil && in.Path != "" && !strings.HasPrefix(in.Path, "/") {
+		return fmt.Errorf("check %d (%s): ingress path %q must start with /", i, check.Name, in.Path)
+	}
+
+	// Script must have a path
+	if check.Script != nil && check.Script.Path == "" {
+		return fmt.Errorf("check %d (%s): script missing path", i, check.Name)
+	}
+
+	// Expected outcome must be known
+	if check.Expect != nil {
+		switch check.Expect.Outcome {
+		case "", ExpectOutcomePass, ExpectOutcomeFail:
+		default:
+			return fmt.Errorf("check %d (%s): invalid expect.outcome %q (want pass or fail)", i, check.Name, check.Expect.Outcome)
+		}
+		if check.Expect.MaxDuration.Duration < 0 {
+			return fmt.Errorf("check %d (%s): expect.max_duration must not be negative", i, check.Name)
+		}
+	}
+
+	// Expiry dates must parse
+	if xf := check.ExpectedFailure; xf != nil && xf.Until != "" {
+		if _, err := time.Parse(dateLayout, xf.Until); err != nil {
+			return fmt.Errorf("check %d (%s): invalid expected_failure.until %q (want YYYY-MM-DD)", i, check.Name, xf.Until)
+		}
+	}
+
+	// Disables and quarantines need a reason and valid dates
+	if d := check.Disabled; d != nil {
+		if err := validateWindow("disabled", d.Reason, d.Since, d.Until); err != nil {
+			return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+		}
+	}
+	if q := check.Quarantine; q != nil {
+		if err := validateWindow("quarantine", q.Reason, q.Since, q.Until); err != nil {
+			return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+		}
+	}
+	for j := range check.Maintenance {
+		if err := check.Maintenance[j].validate(fmt.Sprintf("maintenance[%d]", j), false); err != nil {
+			return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+		}
+	}
+
+	// Retry and backoff values must be in range
+	if r := check.Retry; r != nil {
+		if err := r.validate("retry"); err != nil {
+			return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+		}
+	}
+	if b := check.RetryBackoff; b != nil {
+		if err := b.validate("retry_backoff"); err != nil {
+			return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+		}
+	}
+
+	// wait_for needs a deadline
+	if w := check.WaitFor; w != nil {
+		if w.Timeout.Duration <= 0 {
+			return fmt.Errorf("check %d (%s): wait_for.timeout must be positive", i, check.Name)
+		}
+		if w.Interval.Duration < 0 {
+			return fmt.Errorf("check %d (%s): wait_for.interval must not be negative", i, check.Name)
 		}
+	}
 
-		// Script must have a path
-		if check.Script != nil && check.Script.Path == "" {
-			return fmt.Errorf("check %d (%s): script missing path", i, check.Name)
+	// Capture regexes must compile
+	for name, pattern := range check.Capture {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("check %d (%s): invalid capture %s regex %q: %w", i, check.Name, name, pattern, err)
 		}
+	}
 
-		// Validate regex syntax at load time
-		if check.Validate != nil && check.Validate.Regex != "" {
-			if _, err := regexp.Compile(check.Validate.Regex); err != nil {
-				return fmt.Errorf("check %d (%s): invalid regex %q: %w", i, check.Name, check.Validate.Regex, err)
+	// before and after commands must not be empty
+	for phase, commands := range map[string][]string{"before": check.Before, "after": check.After} {
+		for _, cmd := range commands {
+			if strings.TrimSpace(cmd) == "" {
+				return fmt.Errorf("check %d (%s): empty %s command", i, check.Name, phase)
 			}
 		}
 	}
 
+	// Validate regex syntax at load time
+	for _, cmd := range check.Diagnostics {
+		if strings.TrimSpace(cmd) == "" {
+			return fmt.Errorf("check %d (%s): empty diagnostics command", i, check.Name)
+		}
+	}
+	for j := range check.Transform {
+		if err := check.Transform[j].Validate(); err != nil {
+			return fmt.Errorf("check %d (%s): %w", i, check.Name, err)
+		}
+	}
+	if check.Validate != nil && check.Validate.Regex != "" {
+		if _, err := regexp.Compile(check.Validate.Regex); err != nil {
+			return fmt.Errorf("check %d (%s): invalid regex %q: %w", i, check.Name, check.Validate.Regex, err)
+		}
+	}
+	if check.Validate != nil && check.Validate.Script != "" {
+		if err := validate.CompileScript(check.Validate.Script); err != nil {
+			return fmt.Errorf("check %d (%s): invalid validate.script: %w", i, check.Name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -173,7 +2268,7 @@ func ApplyTemplate(input string, vars TemplateVars) (string, error) {
 		return "", nil
 	}
 
-	tmpl, err := template.New("command").Parse(input)
+	tmpl, err := template.New("command").Funcs(TemplateFuncs()).Parse(input)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -200,6 +2295,177 @@ func ApplyTemplateToCheck(check *Check, vars TemplateVars) (*Check, error) {
 		result.Command = cmd
 	}
 
+	// Apply template to conditions
+	for _, field := range []*string{&result.When, &result.SkipIf} {
+		if *field == "" {
+			continue
+		}
+		rendered, err := ApplyTemplate(*field, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply template to condition: %w", err)
+		}
+		*field = strings.TrimSpace(rendered)
+	}
+
+	// Apply template to fallback command
+	if result.FallbackCommand != "" {
+		cmd, err := ApplyTemplate(result.FallbackCommand, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply template to fallback_command: %w", err)
+		}
+		result.FallbackCommand = cmd
+	}
+
+	// Apply template to exec_in_pod fields
+	if result.ExecInPod != nil {
+		podCopy := *result.ExecInPod
+		for _, field := range []*string{&podCopy.Namespace, &podCopy.Pod, &podCopy.Selector, &podCopy.Container, &podCopy.Command} {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to exec_in_pod: %w", err)
+			}
+			*field = rendered
+		}
+		result.ExecInPod = &podCopy
+	}
+
+	// Apply template to ssh fields
+	if result.SSH != nil {
+		sshCopy := *result.SSH
+		for _, field := range []*string{&sshCopy.Host, &sshCopy.User, &sshCopy.Key, &sshCopy.Jump, &sshCopy.Command} {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to ssh: %w", err)
+			}
+			*field = rendered
+		}
+		result.SSH = &sshCopy
+	}
+
+	// Apply template to grpc fields
+	if result.GRPC != nil {
+		grpcCopy := *result.GRPC
+		for _, field := range []*string{&grpcCopy.Address, &grpcCopy.Service, &grpcCopy.ServerName} {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to grpc: %w", err)
+			}
+			*field = rendered
+		}
+		result.GRPC = &grpcCopy
+	}
+
+	// Apply template to smtp fields
+	if result.SMTP != nil {
+		smtpCopy := *result.SMTP
+		for _, field := range []*string{&smtpCopy.Address, &smtpCopy.Hello, &smtpCopy.ServerName, &smtpCopy.Username, &smtpCopy.Password, &smtpCopy.MailFrom, &smtpCopy.RcptTo} {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to smtp: %w", err)
+			}
+			*field = rendered
+		}
+		result.SMTP = &smtpCopy
+	}
+
+	// Apply template to certificate filters
+	if result.Certificate != nil {
+		certCopy := *result.Certificate
+		for _, field := range []*string{&certCopy.Namespace, &certCopy.Selector} {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to certificate: %w", err)
+			}
+			*field = rendered
+		}
+		result.Certificate = &certCopy
+	}
+
+	// Apply template to file path and remote host
+	if result.File != nil {
+		fileCopy := *result.File
+		path, err := ApplyTemplate(fileCopy.Path, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply template to file: %w", err)
+		}
+		fileCopy.Path = path
+		if fileCopy.SSH != nil {
+			remote, err := applyTemplateToSSHTarget(fileCopy.SSH, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to file ssh: %w", err)
+			}
+			fileCopy.SSH = remote
+		}
+		result.File = &fileCopy
+	}
+
+	// Apply template to gpu selector and job
+	if result.GPU != nil {
+		gpuCopy := *result.GPU
+		fields := []*string{&gpuCopy.Selector}
+		if gpuCopy.Job != nil {
+			jobCopy := *gpuCopy.Job
+			gpuCopy.Job = &jobCopy
+			fields = append(fields, &jobCopy.Image, &jobCopy.Namespace)
+		}
+		for _, field := range fields {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to gpu: %w", err)
+			}
+			*field = rendered
+		}
+		result.GPU = &gpuCopy
+	}
+
+	// Apply template to systemd remote host
+	if result.Systemd != nil && result.Systemd.SSH != nil {
+		systemdCopy := *result.Systemd
+		remote, err := applyTemplateToSSHTarget(systemdCopy.SSH, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply template to systemd ssh: %w", err)
+		}
+		systemdCopy.SSH = remote
+		result.Systemd = &systemdCopy
+	}
+
+	// Apply template to ingress filters
+	if result.Ingress != nil {
+		ingressCopy := *result.Ingress
+		for _, field := range []*string{&ingressCopy.Namespace, &ingressCopy.Selector, &ingressCopy.Path} {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to ingress: %w", err)
+			}
+			*field = rendered
+		}
+		result.Ingress = &ingressCopy
+	}
+
+	// Apply template to external host and URLs
+	if result.External != nil {
+		externalCopy := *result.External
+		for _, field := range []*string{&externalCopy.Host, &externalCopy.ExpectCNAME, &externalCopy.Internal, &externalCopy.Path} {
+			rendered, err := ApplyTemplate(*field, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply template to external: %w", err)
+			}
+			*field = rendered
+		}
+		result.External = &externalCopy
+	}
+
+	// Apply template to ntp server
+	if result.NTP != nil {
+		ntpCopy := *result.NTP
+		server, err := ApplyTemplate(ntpCopy.Server, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply template to ntp: %w", err)
+		}
+		ntpCopy.Server = server
+		result.NTP = &ntpCopy
+	}
+
 	// Apply template to script args
 	if result.Script != nil {
 		scriptCopy := *result.Script
@@ -219,3 +2485,17 @@ func ApplyTemplateToCheck(check *Check, vars TemplateVars) (*Check, error) {
 
 	return &result, nil
 }
+
+// applyTemplateToSSHTarget renders the connection fields of an SSH target
+// used by another check kind.
+func applyTemplateToSSHTarget(target *SSHConfig, vars TemplateVars) (*SSHConfig, error) {
+	sshCopy := *target
+	for _, field := range []*string{&sshCopy.Host, &sshCopy.User, &sshCopy.Key, &sshCopy.Jump} {
+		rendered, err := ApplyTemplate(*field, vars)
+		if err != nil {
+			return nil, err
+		}
+		*field = rendered
+	}
+	return &sshCopy, nil
+}
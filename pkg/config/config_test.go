@@ -3,9 +3,12 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/erauner/homelab-smoke/pkg/validate"
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -66,6 +69,7 @@ func TestLoadConfigInvalidYAML(t *testing.T) {
 }
 
 func TestConfigValidate(t *testing.T) {
+	negative := -1
 	tests := []struct {
 		name    string
 		config  Config
@@ -92,7 +96,7 @@ func TestConfigValidate(t *testing.T) {
 				{Name: "Test"},
 			}},
 			wantErr: true,
-			errMsg:  "must have command or script",
+			errMsg:  "must have one of command, script, exec_in_pod, ssh, grpc, smtp, ntp, certificate, ingress, external, file, systemd, gpu",
 		},
 		{
 			name: "script missing path",
@@ -102,18 +106,539 @@ func TestConfigValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "script missing path",
 		},
+		{
+			name: "retry backoff multiplier below 1",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", RetryBackoff: &RetryBackoff{Multiplier: 0.5}},
+			}},
+			wantErr: true,
+			errMsg:  "retry_backoff.multiplier must be at least 1",
+		},
+		{
+			name: "negative retry max",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Retry: &RetryConfig{Max: &negative}},
+			}},
+			wantErr: true,
+			errMsg:  "retry.max must not be negative",
+		},
+		{
+			name: "retry backoff jitter out of range",
+			config: Config{
+				Defaults: &Defaults{RetryBackoff: &RetryBackoff{Jitter: 1.5}},
+				Checks:   []Check{{Name: "Test", Command: "true"}},
+			},
+			wantErr: true,
+			errMsg:  "defaults.retry_backoff.jitter must be between 0 and 1",
+		},
+		{
+			name: "negative layer timeout",
+			config: Config{
+				Layers: map[int]LayerConfig{2: {Timeout: Duration{Duration: -time.Second}}},
+				Checks: []Check{{Name: "Test", Command: "true"}},
+			},
+			wantErr: true,
+			errMsg:  "layers.2: timeout must not be negative",
+		},
+		{
+			name: "negative max_duration",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Expect: &ExpectConfig{MaxDuration: Duration{Duration: -time.Second}}},
+			}},
+			wantErr: true,
+			errMsg:  "expect.max_duration must not be negative",
+		},
+		{
+			name: "wait_for without timeout",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", WaitFor: &WaitFor{Interval: Duration{Duration: time.Second}}},
+			}},
+			wantErr: true,
+			errMsg:  "wait_for.timeout must be positive",
+		},
+		{
+			name: "valid retry backoff",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", RetryBackoff: &RetryBackoff{Multiplier: 2, MaxDelay: Duration{Duration: time.Minute}, Jitter: 0.2}},
+			}},
+		},
+		{
+			name: "sandbox on native probe",
+			config: Config{Checks: []Check{
+				{Name: "Test", NTP: &NTPConfig{Server: "ntp.lab"}, Sandbox: &SandboxConfig{}},
+			}},
+			wantErr: true,
+			errMsg:  "sandbox requires command or script",
+		},
+		{
+			name: "file relative path",
+			config: Config{Checks: []Check{
+				{Name: "Test", File: &FileConfig{Path: "data/heartbeat"}},
+			}},
+			wantErr: true,
+			errMsg:  "must be absolute",
+		},
+		{
+			name: "file bad sha256",
+			config: Config{Checks: []Check{
+				{Name: "Test", File: &FileConfig{Path: "/data", SHA256: "abc"}},
+			}},
+			wantErr: true,
+			errMsg:  "sha256 must be 64 hex characters",
+		},
+		{
+			name: "systemd invalid unit",
+			config: Config{Checks: []Check{
+				{Name: "Test", Systemd: &SystemdConfig{Units: []string{"smbd; reboot"}}},
+			}},
+			wantErr: true,
+			errMsg:  "invalid systemd unit name",
+		},
+		{
+			name: "gpu job missing image",
+			config: Config{Checks: []Check{
+				{Name: "Test", GPU: &GPUConfig{Job: &GPUJobConfig{}}},
+			}},
+			wantErr: true,
+			errMsg:  "invalid gpu job image",
+		},
+		{
+			name: "ingress relative path",
+			config: Config{Checks: []Check{
+				{Name: "Test", Ingress: &IngressConfig{Path: "healthz"}},
+			}},
+			wantErr: true,
+			errMsg:  "ingress path \"healthz\" must start with /",
+		},
+		{
+			name: "sandbox without root",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Sandbox: &SandboxConfig{Network: true}},
+			}},
+			wantErr: true,
+			errMsg:  "sandbox requires root",
+		},
+		{
+			name: "sandbox relative root",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Sandbox: &SandboxConfig{Root: "jail"}},
+			}},
+			wantErr: true,
+			errMsg:  "must be an absolute path",
+		},
 		{
 			name: "invalid regex",
 			config: Config{Checks: []Check{
 				{
-					Name:    "Test",
-					Command: "echo hello",
+					Name:     "Test",
+					Command:  "echo hello",
 					Validate: &validate.Validation{Regex: "[invalid"},
 				},
 			}},
 			wantErr: true,
 			errMsg:  "invalid regex",
 		},
+		{
+			name: "duplicate derived id",
+			config: Config{Checks: []Check{
+				{Name: "Gateway Has IP", Command: "echo hello"},
+				{Name: "gateway has ip", Command: "echo hello"},
+			}},
+			wantErr: true,
+			errMsg:  "duplicate id",
+		},
+		{
+			name: "duplicate explicit id",
+			config: Config{Checks: []Check{
+				{ID: "gw", Name: "One", Command: "echo hello"},
+				{ID: "gw", Name: "Two", Command: "echo hello"},
+			}},
+			wantErr: true,
+			errMsg:  "duplicate id",
+		},
+		{
+			name: "duplicate name",
+			config: Config{Checks: []Check{
+				{Name: "Gateway", Command: "echo hello"},
+				{ID: "gateway-2", Name: "Gateway", Command: "echo hello"},
+			}},
+			wantErr: true,
+			errMsg:  "duplicate name",
+		},
+		{
+			name: "explicit id collides with derived id",
+			config: Config{Checks: []Check{
+				{ID: "gateway", Name: "Gateway Has Address", Command: "echo hello"},
+				{Name: "Gateway", Command: "echo hello"},
+			}},
+			wantErr: true,
+			errMsg:  "duplicate id",
+		},
+		{
+			name: "invalid expected_failure date",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "exit 1", ExpectedFailure: &ExpectedFailure{Until: "next week"}},
+			}},
+			wantErr: true,
+			errMsg:  "invalid expected_failure.until",
+		},
+		{
+			name: "invalid expect.outcome",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "exit 1", Expect: &ExpectConfig{Outcome: "error"}},
+			}},
+			wantErr: true,
+			errMsg:  "invalid expect.outcome",
+		},
+		{
+			name: "exec_in_pod without target",
+			config: Config{Checks: []Check{
+				{Name: "Test", ExecInPod: &ExecInPodConfig{Command: "true"}},
+			}},
+			wantErr: true,
+			errMsg:  "exactly one of pod or selector",
+		},
+		{
+			name: "exec_in_pod with command",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", ExecInPod: &ExecInPodConfig{Pod: "web", Command: "true"}},
+			}},
+			wantErr: true,
+			errMsg:  "cannot be combined",
+		},
+		{
+			name: "valid exec_in_pod",
+			config: Config{Checks: []Check{
+				{Name: "Test", ExecInPod: &ExecInPodConfig{Selector: "app=web", Command: "true"}},
+			}},
+			wantErr: false,
+		},
+		{
+			name: "unknown summary outcome",
+			config: Config{
+				Summary: &SummaryConfig{Console: &SummaryStyle{Labels: map[string]string{"WARNING": "degraded"}}},
+				Checks:  []Check{{Name: "Test", Command: "true"}},
+			},
+			wantErr: true,
+			errMsg:  "unknown outcome",
+		},
+		{
+			name: "ssh missing host",
+			config: Config{Checks: []Check{
+				{Name: "Test", SSH: &SSHConfig{Command: "uptime"}},
+			}},
+			wantErr: true,
+			errMsg:  "ssh missing host",
+		},
+		{
+			name: "validate script syntax error",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Validate: &validate.Validation{Script: "fail(outptu)"}},
+			}},
+			wantErr: true,
+			errMsg:  "invalid validate.script",
+		},
+		{
+			name: "external without expectations",
+			config: Config{Checks: []Check{
+				{Name: "Test", External: &ExternalConfig{Host: "app.example.com"}},
+			}},
+			wantErr: true,
+			errMsg:  "external must set expect_cname, expect_addresses, or internal",
+		},
+		{
+			name: "external bad address",
+			config: Config{Checks: []Check{
+				{Name: "Test", External: &ExternalConfig{Host: "app.example.com", ExpectAddresses: []string{"cloudflare"}}},
+			}},
+			wantErr: true,
+			errMsg:  `"cloudflare" is not an IP or CIDR`,
+		},
+		{
+			name: "ssh group not in targets",
+			config: Config{Checks: []Check{
+				{Name: "Test", SSH: &SSHConfig{Group: "storage", Command: "uptime"}},
+			}},
+			wantErr: true,
+			errMsg:  `ssh group "storage" is not a non-empty targets group`,
+		},
+		{
+			name: "ssh group with host",
+			config: Config{
+				Targets: map[string][]string{"storage": {"nas1"}},
+				Checks:  []Check{{Name: "Test", SSH: &SSHConfig{Host: "nas1", Group: "storage", Command: "uptime"}}},
+			},
+			wantErr: true,
+			errMsg:  "only one of host or group",
+		},
+		{
+			name: "ssh min_pass_ratio without group",
+			config: Config{Checks: []Check{
+				{Name: "Test", SSH: &SSHConfig{Host: "nas1", MinPassRatio: 0.5, Command: "uptime"}},
+			}},
+			wantErr: true,
+			errMsg:  "min_pass_ratio requires group",
+		},
+		{
+			name: "ssh group valid",
+			config: Config{
+				Targets: map[string][]string{"storage": {"nas1", "nas2"}},
+				Checks:  []Check{{Name: "Test", SSH: &SSHConfig{Group: "storage", MinPassRatio: 0.5, Command: "uptime"}}},
+			},
+		},
+		{
+			name: "ssh malformed host_key",
+			config: Config{Checks: []Check{
+				{Name: "Test", SSH: &SSHConfig{Host: "pve1", HostKey: "AAAAC3NzaC1lZDI1NTE5", Command: "uptime"}},
+			}},
+			wantErr: true,
+			errMsg:  "ssh host_key must be",
+		},
+		{
+			name: "file ssh jump with whitespace",
+			config: Config{Checks: []Check{
+				{Name: "Test", File: &FileConfig{Path: "/data", SSH: &SSHConfig{Host: "nas", Jump: "gw -v"}}},
+			}},
+			wantErr: true,
+			errMsg:  "file ssh jump must not contain whitespace",
+		},
+		{
+			name: "grpc missing address",
+			config: Config{Checks: []Check{
+				{Name: "Test", GRPC: &GRPCConfig{Service: "api"}},
+			}},
+			wantErr: true,
+			errMsg:  "grpc missing address",
+		},
+		{
+			name: "smtp auth without encryption",
+			config: Config{Checks: []Check{
+				{Name: "Test", SMTP: &SMTPConfig{Address: "relay:25", Username: "smoke"}},
+			}},
+			wantErr: true,
+			errMsg:  "requires tls or starttls",
+		},
+		{
+			name: "smtp combined with ssh",
+			config: Config{Checks: []Check{
+				{Name: "Test", SMTP: &SMTPConfig{Address: "relay:25"}, SSH: &SSHConfig{Host: "nas", Command: "true"}},
+			}},
+			wantErr: true,
+			errMsg:  "ssh, smtp cannot be combined",
+		},
+		{
+			name: "ntp warn_offset above max_offset",
+			config: Config{Checks: []Check{
+				{Name: "Test", NTP: &NTPConfig{Server: "pool.ntp.org", WarnOffset: Duration{Duration: 2 * time.Second}}},
+			}},
+			wantErr: true,
+			errMsg:  "must be less than max_offset",
+		},
+		{
+			name: "disabled missing reason",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Disabled: &Disabled{Until: "2026-11-01"}},
+			}},
+			wantErr: true,
+			errMsg:  "disabled missing reason",
+		},
+		{
+			name: "disabled until before since",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Disabled: &Disabled{Reason: "x", Since: "2026-11-01", Until: "2026-10-01"}},
+			}},
+			wantErr: true,
+			errMsg:  "is before disabled.since",
+		},
+		{
+			name: "disabled bad date",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Disabled: &Disabled{Reason: "x", Since: "last week"}},
+			}},
+			wantErr: true,
+			errMsg:  "invalid disabled.since",
+		},
+		{
+			name: "quarantine missing reason",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Quarantine: &Quarantine{Until: "2026-12-01"}},
+			}},
+			wantErr: true,
+			errMsg:  "quarantine missing reason",
+		},
+		{
+			name: "grpc server_name without tls",
+			config: Config{Checks: []Check{
+				{Name: "Test", GRPC: &GRPCConfig{Address: "argocd:8081", ServerName: "argocd"}},
+			}},
+			wantErr: true,
+			errMsg:  "require tls",
+		},
+		{
+			name: "invalid hook payload template",
+			config: Config{
+				Checks:          []Check{{Name: "Test", Command: "true"}},
+				OnGatingFailure: &HookConfig{Webhook: "https://hooks.lab", Payload: "{{ .Cluster"},
+			},
+			wantErr: true,
+			errMsg:  "invalid payload template",
+		},
+		{
+			name: "hook payload without webhook",
+			config: Config{
+				Checks:          []Check{{Name: "Test", Command: "true"}},
+				OnGatingFailure: &HookConfig{Command: "true", Payload: "{}"},
+			},
+			wantErr: true,
+			errMsg:  "payload requires webhook",
+		},
+		{
+			name: "empty diagnostics command",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Diagnostics: []string{" "}},
+			}},
+			wantErr: true,
+			errMsg:  "empty diagnostics command",
+		},
+		{
+			name: "empty tag diagnostics command",
+			config: Config{
+				Checks:      []Check{{Name: "Test", Command: "true"}},
+				Diagnostics: map[string][]string{"media": {""}},
+			},
+			wantErr: true,
+			errMsg:  "diagnostics.media: empty command",
+		},
+		{
+			name: "secret with two sources",
+			config: Config{
+				Checks:  []Check{{Name: "Test", Command: "true"}},
+				Secrets: map[string]SecretSource{"token": {Env: "TOKEN", File: "token"}},
+			},
+			wantErr: true,
+			errMsg:  "secrets.token: must set exactly one of env, file, command, or vault",
+		},
+		{
+			name: "vault secret without field",
+			config: Config{
+				Checks:  []Check{{Name: "Test", Command: "true"}},
+				Secrets: map[string]SecretSource{"token": {Vault: &VaultSecret{Path: "secret/data/smoke"}}},
+			},
+			wantErr: true,
+			errMsg:  "secrets.token: vault needs path and field",
+		},
+		{
+			name: "vault approle without role",
+			config: Config{
+				Checks: []Check{{Name: "Test", Command: "true"}},
+				Vault:  &VaultConfig{Auth: VaultAuth{Method: "approle"}},
+			},
+			wantErr: true,
+			errMsg:  "vault.auth.role: required for approle auth",
+		},
+		{
+			name: "vars_from with both refs",
+			config: Config{
+				Checks:   []Check{{Name: "Test", Command: "true"}},
+				VarsFrom: []VarSource{{SecretRef: &ObjectRef{Name: "a"}, ConfigMapRef: &ObjectRef{Name: "b"}}},
+			},
+			wantErr: true,
+			errMsg:  "vars_from[0]: must set exactly one of secretRef or configMapRef",
+		},
+		{
+			name: "maintenance window without matcher",
+			config: Config{
+				Checks:      []Check{{Name: "Test", Command: "true"}},
+				Maintenance: []MaintenanceWindow{{Schedule: "0 3 * * sun", Duration: Duration{time.Hour}}},
+			},
+			wantErr: true,
+			errMsg:  "maintenance[0]: must match on name or tag",
+		},
+		{
+			name: "maintenance window with invalid schedule",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Maintenance: []MaintenanceWindow{{Schedule: "0 3 * * someday", Duration: Duration{time.Hour}}}},
+			}},
+			wantErr: true,
+			errMsg:  `check 0 (Test): maintenance[0]: invalid schedule "0 3 * * someday"`,
+		},
+		{
+			name: "maintenance window failing to FAIL",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Maintenance: []MaintenanceWindow{{Schedule: "0 3 * * *", Duration: Duration{time.Hour}, Outcome: "FAIL"}}},
+			}},
+			wantErr: true,
+			errMsg:  `maintenance[0]: outcome must be WARN or SKIP, got "FAIL"`,
+		},
+		{
+			name: "unknown fixture",
+			config: Config{Checks: []Check{
+				{Name: "Test", Command: "true", Uses: []string{"tunnel"}},
+			}},
+			wantErr: true,
+			errMsg:  `uses unknown fixture "tunnel"`,
+		},
+		{
+			name: "empty fixture",
+			config: Config{
+				Checks:   []Check{{Name: "Test", Command: "true", Uses: []string{"tunnel"}}},
+				Fixtures: map[string]FixtureConfig{"tunnel": {}},
+			},
+			wantErr: true,
+			errMsg:  "fixtures.tunnel: must have setup or teardown",
+		},
+		{
+			name: "empty fixture command",
+			config: Config{
+				Checks:   []Check{{Name: "Test", Command: "true"}},
+				Fixtures: map[string]FixtureConfig{"tunnel": {Setup: []string{"true"}, Teardown: []string{""}}},
+			},
+			wantErr: true,
+			errMsg:  "fixtures.tunnel: empty command",
+		},
+		{
+			name: "override without matcher",
+			config: Config{
+				Checks:    []Check{{Name: "Test", Command: "true"}},
+				Overrides: []OverrideRule{{Outcome: "WARN"}},
+			},
+			wantErr: true,
+			errMsg:  "overrides[0]: must match on name, tag, or output",
+		},
+		{
+			name: "override to expected failure outcome",
+			config: Config{
+				Checks:    []Check{{Name: "Test", Command: "true"}},
+				Overrides: []OverrideRule{{Tag: "nas", Outcome: "XFAIL"}},
+			},
+			wantErr: true,
+			errMsg:  "outcome must be PASS, FAIL, WARN, SKIP, or ERROR",
+		},
+		{
+			name: "override with invalid output regex",
+			config: Config{
+				Checks:    []Check{{Name: "Test", Command: "true"}},
+				Overrides: []OverrideRule{{Output: "(", Outcome: "WARN"}},
+			},
+			wantErr: true,
+			errMsg:  "invalid output regex",
+		},
+		{
+			name: "override with unknown from outcome",
+			config: Config{
+				Checks:    []Check{{Name: "Test", Command: "true"}},
+				Overrides: []OverrideRule{{Tag: "nas", From: []string{"BROKEN"}, Outcome: "WARN"}},
+			},
+			wantErr: true,
+			errMsg:  `unknown outcome "BROKEN" in from`,
+		},
+		{
+			name: "valid config with grpc",
+			config: Config{Checks: []Check{
+				{Name: "Test", GRPC: &GRPCConfig{Address: "argocd:8081"}},
+			}},
+			wantErr: false,
+		},
 		{
 			name: "valid config with command",
 			config: Config{Checks: []Check{
@@ -136,6 +661,8 @@ func TestConfigValidate(t *testing.T) {
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("expected error containing %q, got nil", tt.errMsg)
+				} else if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
 				}
 			} else {
 				if err != nil {
@@ -245,3 +772,455 @@ func TestCheckIsGating(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "checks.yaml")
+
+	configContent := `
+defaults:
+  timeout: 60s
+  retry: true
+  retry_delay: 5s
+  gating: false
+  tags: [infra]
+  owner: erin
+  team: platform
+checks:
+  - name: "Inherits"
+    command: "echo hello"
+  - name: "Overrides"
+    command: "echo hello"
+    owner: sam
+    timeout: 10s
+    retry: false
+    retry_delay: 1s
+    tags: [dns, infra]
+    expect:
+      gating: true
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	inherits := cfg.Checks[0]
+	if inherits.GetTimeout(0) != 60*time.Second {
+		t.Errorf("expected inherited timeout 60s, got %v", inherits.GetTimeout(0))
+	}
+	if !inherits.IsRetry() {
+		t.Error("expected inherited retry")
+	}
+	if inherits.GetRetryDelay(0) != 5*time.Second {
+		t.Errorf("expected inherited retry delay 5s, got %v", inherits.GetRetryDelay(0))
+	}
+	if inherits.IsGating() {
+		t.Error("expected inherited non-gating")
+	}
+	if len(inherits.Tags) != 1 || inherits.Tags[0] != "infra" {
+		t.Errorf("expected tags [infra], got %v", inherits.Tags)
+	}
+	if inherits.Owner != "erin" || inherits.Team != "platform" {
+		t.Errorf("expected inherited owner erin and team platform, got %q and %q", inherits.Owner, inherits.Team)
+	}
+
+	overrides := cfg.Checks[1]
+	if overrides.GetTimeout(0) != 10*time.Second {
+		t.Errorf("expected timeout 10s, got %v", overrides.GetTimeout(0))
+	}
+	if overrides.IsRetry() {
+		t.Error("expected retry disabled")
+	}
+	if overrides.GetRetryDelay(0) != time.Second {
+		t.Errorf("expected retry delay 1s, got %v", overrides.GetRetryDelay(0))
+	}
+	if !overrides.IsGating() {
+		t.Error("expected gating")
+	}
+	if overrides.Owner != "sam" || overrides.Team != "platform" {
+		t.Errorf("expected owner sam and inherited team platform, got %q and %q", overrides.Owner, overrides.Team)
+	}
+	if len(overrides.Tags) != 2 || overrides.Tags[0] != "infra" || overrides.Tags[1] != "dns" {
+		t.Errorf("expected tags [infra dns], got %v", overrides.Tags)
+	}
+}
+
+func TestLoadConfigStrict(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "checks.yaml")
+
+	configContent := `
+checks:
+  - name: "Typo"
+    commnad: "echo hello"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if _, err := LoadConfig(configPath); err != nil {
+		t.Errorf("LoadConfig should ignore unknown fields, got: %v", err)
+	}
+
+	_, err := LoadConfigStrict(configPath)
+	if err == nil {
+		t.Fatal("expected error for unknown field in strict mode")
+	}
+	if !strings.Contains(err.Error(), "commnad") {
+		t.Errorf("expected error to mention the unknown field, got: %v", err)
+	}
+}
+
+func TestCheckRequiredTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		check    Check
+		expected string
+	}{
+		{name: "command", check: Check{Command: "true"}, expected: ""},
+		{name: "declared", check: Check{Command: "curl -s x | jq .", Requires: []string{"jq", "curl", "jq"}}, expected: "curl,jq"},
+		{name: "exec_in_pod", check: Check{ExecInPod: &ExecInPodConfig{}, Requires: []string{"jq"}}, expected: "jq,kubectl"},
+		{name: "remote systemd", check: Check{Systemd: &SystemdConfig{SSH: &SSHConfig{Host: "nas"}}}, expected: "ssh"},
+		{name: "local systemd", check: Check{Systemd: &SystemdConfig{}}, expected: "systemctl"},
+		{name: "local checksum", check: Check{File: &FileConfig{SHA256: "x"}}, expected: "sha256sum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.check.RequiredTools(), ","); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckGetID(t *testing.T) {
+	tests := []struct {
+		check    Check
+		expected string
+	}{
+		{check: Check{Name: "Gateway Has IP"}, expected: "gateway-has-ip"},
+		{check: Check{Name: "  ArgoCD: Apps Synced! "}, expected: "argocd-apps-synced"},
+		{check: Check{Name: "DNS (internal)"}, expected: "dns-internal"},
+		{check: Check{ID: "custom", Name: "Whatever"}, expected: "custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := tt.check.GetID(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExpectedFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "checks.yaml")
+
+	configContent := `
+checks:
+  - name: "Bool Form"
+    command: "exit 1"
+    expected_failure: true
+  - name: "Object Form"
+    command: "exit 1"
+    expected_failure:
+      reason: "waiting on upstream fix"
+      until: "2026-06-30"
+  - name: "Disabled"
+    command: "exit 1"
+    expected_failure: false
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	before := time.Date(2026, 6, 30, 23, 0, 0, 0, time.UTC)
+	after := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	if !cfg.Checks[0].ExpectedFailure.IsActive(after) {
+		t.Error("bool form should be active")
+	}
+	if !cfg.Checks[1].ExpectedFailure.IsActive(before) {
+		t.Error("object form should be active on its expiry date")
+	}
+	if cfg.Checks[1].ExpectedFailure.IsActive(after) {
+		t.Error("object form should expire after its until date")
+	}
+	if cfg.Checks[2].ExpectedFailure.IsActive(before) {
+		t.Error("expected_failure: false should not be active")
+	}
+}
+
+func TestLoadConfigRetry(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "checks.yaml")
+
+	configContent := `
+defaults:
+  retry:
+    max: 5
+  retry_delay: 3s
+checks:
+  - name: "Bool Form"
+    command: "true"
+    retry: true
+  - name: "Object Form"
+    command: "true"
+    retry_delay: 1s
+    retry:
+      max: 10
+      delay: 10s
+      backoff: exponential
+  - name: "Inherited"
+    command: "true"
+  - name: "Disabled"
+    command: "true"
+    retry: false
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	tests := []struct {
+		retry    bool
+		max      int
+		delay    time.Duration
+		backoffs bool
+	}{
+		{retry: true, max: 5, delay: 3 * time.Second},
+		{retry: true, max: 10, delay: 10 * time.Second, backoffs: true},
+		{retry: true, max: 5, delay: 3 * time.Second},
+		{retry: false, max: 5, delay: 3 * time.Second},
+	}
+	for i, tt := range tests {
+		check := &cfg.Checks[i]
+		if got := check.IsRetry(); got != tt.retry {
+			t.Errorf("%s: IsRetry() = %v, want %v", check.Name, got, tt.retry)
+		}
+		if got := check.GetMaxRetries(3); got != tt.max {
+			t.Errorf("%s: GetMaxRetries() = %d, want %d", check.Name, got, tt.max)
+		}
+		if got := check.GetRetryDelay(2 * time.Second); got != tt.delay {
+			t.Errorf("%s: GetRetryDelay() = %s, want %s", check.Name, got, tt.delay)
+		}
+		if b := check.GetRetryBackoff(); (b != nil && b.Multiplier == 2) != tt.backoffs {
+			t.Errorf("%s: GetRetryBackoff() = %+v", check.Name, b)
+		}
+	}
+
+	out, err := yaml.Marshal(cfg.Checks[3].Retry)
+	if err != nil || strings.TrimSpace(string(out)) != "false" {
+		t.Errorf("retry: false should round-trip, got %q (%v)", out, err)
+	}
+
+	if err := os.WriteFile(configPath, []byte("checks:\n  - name: A\n    command: \"true\"\n    retry: {backoff: linear}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "backoff must be fixed, exponential, or an object") {
+		t.Errorf("expected backoff error, got %v", err)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		disabled   *Disabled
+		wantActive bool
+		wantDesc   string
+	}{
+		{
+			name:       "no expiry",
+			disabled:   &Disabled{Reason: "NAS offline", Since: "2026-10-01"},
+			wantActive: true,
+			wantDesc:   "disabled: NAS offline (since 2026-10-01) - no expiry set",
+		},
+		{
+			name:       "expires today",
+			disabled:   &Disabled{Reason: "migration", Until: "2026-10-16"},
+			wantActive: true,
+			wantDesc:   "disabled: migration - expires today (2026-10-16)",
+		},
+		{
+			name:       "expires later",
+			disabled:   &Disabled{Reason: "migration", Until: "2026-10-26"},
+			wantActive: true,
+			wantDesc:   "disabled: migration - expires in 10 days (2026-10-26)",
+		},
+		{
+			name:       "expired",
+			disabled:   &Disabled{Reason: "migration", Until: "2026-10-15"},
+			wantActive: false,
+		},
+		{
+			name:       "nil",
+			disabled:   nil,
+			wantActive: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.disabled.IsActive(now); got != tt.wantActive {
+				t.Errorf("IsActive() = %v, want %v", got, tt.wantActive)
+			}
+			if tt.wantDesc != "" {
+				if got := tt.disabled.Describe(now); got != tt.wantDesc {
+					t.Errorf("Describe() = %q, want %q", got, tt.wantDesc)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvironmentConfigEnviron(t *testing.T) {
+	host := []string{"PATH=/usr/bin", "HOME=/root", "LANG=de_DE.UTF-8", "AWS_SECRET=x"}
+
+	tests := []struct {
+		name string
+		env  *EnvironmentConfig
+		want []string
+	}{
+		{
+			name: "nil inherits",
+			env:  nil,
+			want: nil,
+		},
+		{
+			name: "empty inherits",
+			env:  &EnvironmentConfig{Pass: []string{"HOME"}},
+			want: nil,
+		},
+		{
+			name: "set overrides host",
+			env:  &EnvironmentConfig{Set: map[string]string{"LANG": "C.UTF-8", "TZ": "UTC"}},
+			want: []string{"PATH=/usr/bin", "HOME=/root", "AWS_SECRET=x", "LANG=C.UTF-8", "TZ=UTC"},
+		},
+		{
+			name: "scrub keeps PATH and pass",
+			env:  &EnvironmentConfig{Scrub: true, Pass: []string{"HOME"}, Set: map[string]string{"LANG": "C.UTF-8"}},
+			want: []string{"PATH=/usr/bin", "HOME=/root", "LANG=C.UTF-8"},
+		},
+		{
+			name: "scrub with nothing passed",
+			env:  &EnvironmentConfig{Scrub: true},
+			want: []string{"PATH=/usr/bin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.env.Environ(host)
+			if (got == nil) != (tt.want == nil) || strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Environ() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "30s", want: 30 * time.Second},
+		{input: "1m30s", want: 90 * time.Second},
+		{input: "30", want: 30 * time.Second},
+		{input: "1.5", want: 1500 * time.Millisecond},
+		{input: "soon", wantErr: true},
+		{input: "inf", wantErr: true},
+		{input: "-5", wantErr: true},
+		{input: "-0.5", wantErr: true},
+		{input: "1e20", wantErr: true},
+		{input: "9223372037", wantErr: true},
+		{input: "9223372036", want: 9223372036 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ByteSize
+		wantErr bool
+	}{
+		{input: "512", want: 512},
+		{input: "10K", want: 10_000},
+		{input: "10KB", want: 10_000},
+		{input: "10Ki", want: 10 * 1024},
+		{input: "2GiB", want: 2 << 30},
+		{input: "1tb", want: 1_000_000_000_000},
+		{input: "64B", want: 64},
+		{input: "1.5G", want: 1_500_000_000},
+		{input: "0.5KiB", want: 512},
+		{input: "1.5", wantErr: true},
+		{input: "lots", wantErr: true},
+		{input: "-5", wantErr: true},
+		{input: "8388607TiB", want: 8388607 << 40},
+		{input: "8388608TiB", wantErr: true},
+		{input: "16777216TiB", wantErr: true},
+		{input: "10000000TiB", wantErr: true},
+		{input: "9223372036854775808", wantErr: true},
+		{input: "8388608.5TiB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseByteSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
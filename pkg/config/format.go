@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format rewrites a config file in canonical form so that diffs stay clean
+// across contributors: keys follow the order of the config's fields (map
+// keys are sorted), checks are stably sorted by layer, tags are sorted, and
+// quoting is left to the encoder so a value is only quoted when it must be.
+// Comments and block scalar styles (e.g., multi-line commands) are kept.
+// Keys and checks that hold YAML aliases keep their order, since moving an
+// alias before its anchor would make the file fail to load.
+func Format(data []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return data, nil
+	}
+	formatNode(root.Content[0], reflect.TypeOf(Config{}))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatNode normalizes node in place, guided by the Go type it decodes
+// into. Nodes whose shape doesn't match the type are left in order.
+func formatNode(node *yaml.Node, typ reflect.Type) {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Style == yaml.SingleQuotedStyle || node.Style == yaml.DoubleQuotedStyle {
+			node.Style = 0
+		}
+	case yaml.MappingNode:
+		formatMapping(node, typ)
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if typ != nil && typ.Kind() == reflect.Slice {
+			elem = typ.Elem()
+		}
+		for _, item := range node.Content {
+			formatNode(item, elem)
+		}
+	}
+}
+
+// formatMapping orders a mapping's keys by struct field order (unknown keys
+// last, in their original order) or alphabetically for Go maps, then
+// formats each value and applies the check and tag sorting rules.
+func formatMapping(node *yaml.Node, typ reflect.Type) {
+	type pair struct {
+		key, value *yaml.Node
+		rank       int
+		field      reflect.Type
+	}
+
+	var fields map[string]int
+	var fieldTypes map[string]reflect.Type
+	if typ != nil && typ.Kind() == reflect.Struct {
+		fields = make(map[string]int)
+		fieldTypes = make(map[string]reflect.Type)
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			fields[name] = i
+			fieldTypes[name] = typ.Field(i).Type
+		}
+	}
+
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		p := pair{key: node.Content[i], value: node.Content[i+1]}
+		switch {
+		case fields != nil:
+			rank, ok := fields[p.key.Value]
+			if !ok {
+				rank = len(fields)
+			}
+			p.rank, p.field = rank, fieldTypes[p.key.Value]
+		case typ != nil && typ.Kind() == reflect.Map:
+			p.field = typ.Elem()
+		}
+		formatNode(p.key, nil)
+		formatNode(p.value, p.field)
+		pairs = append(pairs, p)
+	}
+
+	switch {
+	case hasAlias(node):
+		// Keep the order so every alias still follows its anchor
+	case fields != nil:
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].rank < pairs[j].rank })
+	case typ != nil && typ.Kind() == reflect.Map:
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].key.Value < pairs[j].key.Value })
+	}
+
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+
+		switch {
+		case typ == reflect.TypeOf(Config{}) && p.key.Value == "checks":
+			sortChecksByLayer(p.value)
+		case typ == reflect.TypeOf(Check{}) && p.key.Value == "tags":
+			sortScalars(p.value)
+		}
+	}
+}
+
+// sortChecksByLayer stably sorts a checks sequence by layer, matching the
+// order the runner executes them in. Checks that use aliases are left in
+// order.
+func sortChecksByLayer(node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode || hasAlias(node) {
+		return
+	}
+	layer := func(check *yaml.Node) int {
+		for i := 0; i+1 < len(check.Content); i += 2 {
+			if check.Content[i].Value == "layer" {
+				n, _ := strconv.Atoi(check.Content[i+1].Value)
+				return n
+			}
+		}
+		return 0
+	}
+	sort.SliceStable(node.Content, func(i, j int) bool {
+		return layer(node.Content[i]) < layer(node.Content[j])
+	})
+}
+
+// hasAlias reports whether node or any node under it is an alias
+// (including a << merge key's value).
+func hasAlias(node *yaml.Node) bool {
+	if node.Kind == yaml.AliasNode {
+		return true
+	}
+	for _, child := range node.Content {
+		if hasAlias(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortScalars sorts a sequence of scalars by value.
+func sortScalars(node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode {
+		return
+	}
+	sort.SliceStable(node.Content, func(i, j int) bool {
+		return node.Content[i].Value < node.Content[j].Value
+	})
+}
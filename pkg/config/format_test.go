@@ -0,0 +1,116 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFormat(t *testing.T) {
+	input := `checks:
+  # DNS comes after the cluster is reachable
+  - name: 'DNS'
+    layer: 2
+    tags: [network, dns]
+    command: "dig +short example.com"
+  - command: kubectl get nodes
+    name: Nodes
+    timeout: 30s
+    capture:
+      zone: "a"
+      node: "b"
+    layer: 1
+    exec_in_pod:
+      selector: app=x
+      namespace: default
+  - name: Quoted
+    command: "true"
+defaults:
+  timeout: 10s
+`
+	want := `defaults:
+  timeout: 10s
+checks:
+  - name: Quoted
+    command: "true"
+  - name: Nodes
+    layer: 1
+    command: kubectl get nodes
+    exec_in_pod:
+      namespace: default
+      selector: app=x
+    capture:
+      node: b
+      zone: a
+    timeout: 30s
+  # DNS comes after the cluster is reachable
+  - name: DNS
+    layer: 2
+    command: dig +short example.com
+    tags: [dns, network]
+`
+
+	got, err := Format([]byte(input))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+
+	again, err := Format(got)
+	if err != nil {
+		t.Fatalf("Format of formatted output failed: %v", err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("Format is not idempotent:\n%s", again)
+	}
+}
+
+func TestFormatKeepsAliasOrder(t *testing.T) {
+	input := `checks:
+  - name: Second
+    layer: 2
+    command: "true"
+    validate: &v
+      contains: ok
+  - validate: *v
+    name: First
+    layer: 1
+    command: "true"
+`
+	got, err := Format([]byte(input))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	want := `checks:
+  - name: Second
+    layer: 2
+    command: "true"
+    validate: &v
+      contains: ok
+  - validate: *v
+    name: First
+    layer: 1
+    command: "true"
+`
+	if string(got) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(got, &cfg); err != nil {
+		t.Fatalf("formatted output does not parse: %v", err)
+	}
+	if len(cfg.Checks) != 2 || cfg.Checks[1].Validate == nil || cfg.Checks[1].Validate.Contains != "ok" {
+		t.Errorf("alias not resolved after formatting: %+v", cfg.Checks)
+	}
+}
+
+func TestFormatInvalidYAML(t *testing.T) {
+	_, err := Format([]byte("checks: [\n"))
+	if err == nil || !strings.Contains(err.Error(), "failed to parse") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// httpGetTimeout bounds the httpGet template function.
+const httpGetTimeout = 10 * time.Second
+
+// httpGetMaxBytes caps the response body returned by httpGet.
+const httpGetMaxBytes = 1 << 20
+
+// TemplateFuncs returns the helper functions available in templates:
+//
+//	now                  current time (e.g., {{ now.Unix }}, {{ now.Format "2006-01-02" }})
+//	httpGet URL          response body of a GET request (errors on non-2xx)
+//	randAlphaNum N       random alphanumeric string of length N
+//	env NAME             value of an environment variable
+//	default DEF VALUE    VALUE, or DEF if VALUE is empty
+//	quote S              S quoted for safe shell usage
+//	json V               V encoded as JSON (e.g., a string with quotes escaped)
+//	trim, lower, upper   string helpers
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"now":          time.Now,
+		"httpGet":      httpGet,
+		"randAlphaNum": randAlphaNum,
+		"env":          os.Getenv,
+		"default":      defaultValue,
+		"quote":        exec.ShellQuote,
+		"json":         toJSON,
+		"trim":         strings.TrimSpace,
+		"lower":        strings.ToLower,
+		"upper":        strings.ToUpper,
+	}
+}
+
+// httpGet fetches a URL and returns the response body.
+func httpGet(url string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpGetTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("httpGet %s: %w", url, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("httpGet %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("httpGet %s: %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpGetMaxBytes))
+	if err != nil {
+		return "", fmt.Errorf("httpGet %s: %w", url, err)
+	}
+	return string(body), nil
+}
+
+const alphaNum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randAlphaNum returns a random alphanumeric string of length n.
+func randAlphaNum(n int) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("randAlphaNum: negative length %d", n)
+	}
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(alphaNum)))
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("randAlphaNum: %w", err)
+		}
+		b[i] = alphaNum[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+// defaultValue returns value, or def if value is empty.
+func defaultValue(def, value string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// toJSON encodes v as JSON.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestApplyTemplateFuncs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("10.0.0.5"))
+	}))
+	defer server.Close()
+
+	t.Setenv("SMOKE_TEST_VAR", "from-env")
+	vars := TemplateVars{Cluster: "home"}
+
+	tests := []struct {
+		name    string
+		input   string
+		match   string
+		wantErr bool
+	}{
+		{name: "httpGet", input: `curl http://{{ httpGet "` + server.URL + `" }}/`, match: `^curl http://10\.0\.0\.5/$`},
+		{name: "httpGet non-2xx", input: `{{ httpGet "` + server.URL + `/missing" }}`, wantErr: true},
+		{name: "now", input: `{{ now.Year }}`, match: `^\d{4}$`},
+		{name: "randAlphaNum", input: `ns-{{ randAlphaNum 8 }}`, match: `^ns-[a-zA-Z0-9]{8}$`},
+		{name: "env", input: `{{ env "SMOKE_TEST_VAR" }}`, match: `^from-env$`},
+		{name: "default", input: `{{ .Namespace | default "default" }}`, match: `^default$`},
+		{name: "quote", input: `echo {{ quote "a b" }}`, match: `^echo 'a b'$`},
+		{name: "json", input: `{"text": {{ printf "%s says \"hi\"" .Cluster | json }}}`, match: `^\{"text": "home says \\"hi\\""\}$`},
+		{name: "upper", input: `{{ upper .Cluster }}`, match: `^HOME$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyTemplate(tt.input, vars)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !regexp.MustCompile(tt.match).MatchString(result) {
+				t.Errorf("expected match for %q, got %q", tt.match, result)
+			}
+		})
+	}
+}
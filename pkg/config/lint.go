@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a configuration issue found by Lint.
+type Problem struct {
+	// Line is the 1-based line in the config file (0 if unknown).
+	Line int
+
+	// Message describes the issue.
+	Message string
+}
+
+// lineErrorPattern splits a yaml decode error into line and message.
+var lineErrorPattern = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// LintOptions controls optional Lint checks.
+type LintOptions struct {
+	// Policy, if set, reports every policy violation as a problem.
+	Policy *Policy
+}
+
+// Lint checks a config file more thoroughly than Validate and reports
+// every problem rather than stopping at the first: unknown fields, invalid
+// values, templates that don't render with vars, and scripts (resolved
+// against the file's directory) that are missing or not executable.
+// Problems are sorted by line. The error is only for a file that can't be
+// read or isn't YAML at all.
+func Lint(path string, vars TemplateVars) ([]Problem, error) {
+	return LintWith(path, vars, LintOptions{})
+}
+
+// LintWith lints a config file like Lint, with options applied.
+func LintWith(path string, vars TemplateVars, opts LintOptions) ([]Problem, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Path is user-provided config file
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var problems []Problem
+	report := func(line int, err error) {
+		problems = append(problems, Problem{Line: line, Message: err.Error()})
+	}
+
+	// Decode strictly; field and value errors are collected, not fatal
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		for _, msg := range typeErr.Errors {
+			line := 0
+			if m := lineErrorPattern.FindStringSubmatch(msg); m != nil {
+				line, _ = strconv.Atoi(m[1])
+				msg = m[2]
+			}
+			problems = append(problems, Problem{Line: line, Message: msg})
+		}
+	}
+	config.ApplyDefaults()
+
+	keyLines, checkLines := configLines(data)
+
+	if err := checkAPIVersion(config.APIVersion); err != nil {
+		report(keyLines["apiVersion"], err)
+	}
+
+	// Included files are linted on their own; here they only need to load
+	// and provide checks and targets
+	merged := &config
+	if len(config.Include) > 0 {
+		if m, err := LoadConfigWith(path, LoadOptions{}); err != nil {
+			report(keyLines["include"], err)
+		} else {
+			merged = m
+		}
+	}
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if profile := config.Profiles[name]; profile.APIVersion != "" || len(profile.Include) > 0 || len(profile.Profiles) > 0 {
+			report(keyLines["profiles"], fmt.Errorf("profiles.%s: apiVersion, include, and profiles are not allowed in a profile", name))
+		}
+	}
+
+	if len(merged.Checks) == 0 {
+		report(keyLines["checks"], fmt.Errorf("no checks defined"))
+	}
+	if err := config.validateSettings(); err != nil {
+		key, _, _ := strings.Cut(err.Error(), ":")
+		key, _, _ = strings.Cut(key, ".")
+		report(keyLines[key], err)
+	}
+	if h := config.OnGatingFailure; h != nil {
+		if _, err := ApplyTemplate(h.Command, vars); err != nil {
+			report(keyLines["on_gating_failure"], fmt.Errorf("on_gating_failure: %w", err))
+		}
+	}
+	if opts.Policy != nil {
+		for _, err := range opts.Policy.validateSettings(&config) {
+			key, _, _ := strings.Cut(err.Error(), ":")
+			key, _, _ = strings.Cut(key, ".")
+			report(keyLines[key], err)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	index := newCheckIndex(len(config.Checks))
+	for i := range config.Checks {
+		check := &config.Checks[i]
+		line := 0
+		if i < len(checkLines) {
+			line = checkLines[i]
+		}
+
+		if err := validateCheck(i, check); err != nil {
+			report(line, err)
+		}
+		if err := merged.validateGroup(i, check); err != nil {
+			report(line, err)
+		}
+		if err := index.add(i, check); err != nil {
+			report(line, err)
+		}
+		if _, err := ApplyTemplateToCheck(check, vars); err != nil {
+			report(line, fmt.Errorf("check %d (%s): %w", i, check.Name, err))
+		}
+		if err := lintScript(check, dir); err != nil {
+			report(line, fmt.Errorf("check %d (%s): %w", i, check.Name, err))
+		}
+		if opts.Policy != nil {
+			for _, err := range opts.Policy.validateCheck(i, check) {
+				report(line, err)
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// lintScript checks that a script check's file exists and is executable.
+// Scripts inside a sandbox root can't be resolved from here and are skipped.
+func lintScript(check *Check, dir string) error {
+	if check.Script == nil || check.Script.Path == "" || (check.Sandbox != nil && check.Sandbox.Root != "") {
+		return nil
+	}
+
+	path := check.Script.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		return fmt.Errorf("script %s not found", path)
+	case info.IsDir():
+		return fmt.Errorf("script %s is a directory", path)
+	case info.Mode()&0o111 == 0:
+		return fmt.Errorf("script %s is not executable", path)
+	}
+	return nil
+}
+
+// configLines returns the lines of the top-level keys and of each check.
+// Missing entries are 0 when the file isn't the expected shape.
+func configLines(data []byte) (map[string]int, []int) {
+	keys := make(map[string]int)
+	var checks []int
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return keys, checks
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return keys, checks
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		keys[key.Value] = key.Line
+		if key.Value == "checks" && value.Kind == yaml.SequenceNode {
+			for _, item := range value.Content {
+				checks = append(checks, item.Line)
+			}
+		}
+	}
+	return keys, checks
+}
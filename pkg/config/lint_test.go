@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ok.sh"), []byte("#!/bin/sh\n"), 0o755); err != nil { //nolint:gosec // Test script must be executable
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "noexec.sh"), []byte("#!/bin/sh\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "checks.yaml")
+	content := `checks:
+  - name: "Good Script"
+    script:
+      path: ok.sh
+  - name: "Typo"
+    commnad: "true"
+  - name: "Bad Timeout"
+    command: "true"
+    timeout: soon
+  - name: "Bad Regex"
+    command: "true"
+    validate:
+      regex: "("
+  - name: "Missing Script"
+    script:
+      path: missing.sh
+  - name: "Not Executable"
+    script:
+      path: noexec.sh
+  - name: "Bad Template"
+    command: "echo {{.Nope}}"
+  - name: "Good Script"
+    command: "true"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := Lint(path, TemplateVars{Cluster: "home"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		line int
+		msg  string
+	}{
+		{6, "field commnad not found"},
+		{5, "check 1 (Typo): must have one of command, script, exec_in_pod, ssh, grpc, smtp, ntp, certificate, ingress, external, file, systemd, gpu"},
+		{9, `invalid duration "soon"`},
+		{10, "check 3 (Bad Regex): invalid regex"},
+		{14, "missing.sh not found"},
+		{17, "noexec.sh is not executable"},
+		{20, "check 6 (Bad Template): failed to apply template to command"},
+		{22, "check 7 (Good Script): duplicate name"},
+	}
+	if len(problems) != len(want) {
+		t.Errorf("expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+	for _, w := range want {
+		found := false
+		for _, p := range problems {
+			if p.Line == w.line && strings.Contains(p.Message, w.msg) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected problem at line %d containing %q, got %v", w.line, w.msg, problems)
+		}
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checks.yaml")
+	if err := os.WriteFile(path, []byte("checks:\n  - name: Ok\n    command: \"true\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := Lint(path, TemplateVars{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
@@ -0,0 +1,432 @@
+package config
+
+import (
+	"fmt"
+	"math/bits"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/engine"
+)
+
+// maxWindowDuration bounds how long a maintenance window can last.
+const maxWindowDuration = 31 * 24 * time.Hour
+
+// MaintenanceWindow mutes failures during planned maintenance (e.g., a
+// weekly NAS reboot): while a window is open, FAIL and ERROR are reported
+// as WARN or SKIP and never block.
+type MaintenanceWindow struct {
+	// Name matches the check name or ID as a glob; top-level windows only.
+	Name string `yaml:"name,omitempty"`
+
+	// Tag matches checks carrying this tag; top-level windows only.
+	Tag string `yaml:"tag,omitempty"`
+
+	// Schedule is when the window opens, as a cron expression ("0 3 * *
+	// sun") or an RRULE ("FREQ=WEEKLY;BYDAY=SU;BYHOUR=3").
+	Schedule string `yaml:"schedule"`
+
+	// Duration is how long the window stays open.
+	Duration Duration `yaml:"duration"`
+
+	// Timezone is the IANA zone the schedule is in (default: local).
+	Timezone string `yaml:"timezone,omitempty"`
+
+	// Outcome is what failures become: WARN (default) or SKIP.
+	Outcome string `yaml:"outcome,omitempty"`
+
+	// Reason explains the maintenance in the check's reason.
+	Reason string `yaml:"reason,omitempty"`
+
+	// compiled is the parsed schedule and zone, kept by validate so a run
+	// doesn't parse them for every check.
+	compiled *compiledWindow
+}
+
+// compiledWindow is a window's parsed schedule and time zone.
+type compiledWindow struct {
+	schedule *schedule
+	location *time.Location
+}
+
+// IsActive returns whether the window is open at the given time, i.e. it
+// last opened less than its duration ago.
+func (w *MaintenanceWindow) IsActive(now time.Time) bool {
+	c := w.compiled
+	if c == nil {
+		var err error
+		if c, err = w.compile(); err != nil {
+			return false
+		}
+	}
+
+	now = now.In(c.location)
+	opened, ok := c.schedule.lastFire(now, now.Add(-w.Duration.Duration))
+	return ok && now.Sub(opened) < w.Duration.Duration
+}
+
+// compile parses the window's schedule and time zone.
+func (w *MaintenanceWindow) compile() (*compiledWindow, error) {
+	sched, err := parseSchedule(w.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := w.location()
+	if err != nil {
+		return nil, err
+	}
+	return &compiledWindow{schedule: sched, location: loc}, nil
+}
+
+// GetOutcome returns the outcome failures become, WARN by default.
+func (w *MaintenanceWindow) GetOutcome() engine.Outcome {
+	if w.Outcome == "" {
+		return engine.OutcomeWarn
+	}
+	return engine.Outcome(w.Outcome)
+}
+
+// Describe returns the window's reason, or its schedule if it has none.
+func (w *MaintenanceWindow) Describe() string {
+	if w.Reason != "" {
+		return w.Reason
+	}
+	return fmt.Sprintf("window %q for %s", w.Schedule, w.Duration.Duration)
+}
+
+// location returns the window's time zone.
+func (w *MaintenanceWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+// matches returns whether a top-level window applies to a check.
+func (w *MaintenanceWindow) matches(check *Check) bool {
+	if w.Name != "" && !check.matchesAny([]string{w.Name}) {
+		return false
+	}
+	return w.Tag == "" || slices.Contains(check.Tags, w.Tag)
+}
+
+// validate checks the window's schedule, duration, zone, and outcome.
+// Top-level windows must match on name or tag; a check's own may not.
+func (w *MaintenanceWindow) validate(field string, topLevel bool) error {
+	if topLevel && w.Name == "" && w.Tag == "" {
+		return fmt.Errorf("%s: must match on name or tag", field)
+	}
+	if !topLevel && (w.Name != "" || w.Tag != "") {
+		return fmt.Errorf("%s: name and tag only apply to top-level windows", field)
+	}
+	if _, err := path.Match(w.Name, ""); err != nil {
+		return fmt.Errorf("%s: invalid name pattern %q: %w", field, w.Name, err)
+	}
+	sched, err := parseSchedule(w.Schedule)
+	if err != nil {
+		return fmt.Errorf("%s: invalid schedule %q: %w", field, w.Schedule, err)
+	}
+	if w.Duration.Duration <= 0 || w.Duration.Duration > maxWindowDuration {
+		return fmt.Errorf("%s: duration must be positive and at most 31 days", field)
+	}
+	loc, err := w.location()
+	if err != nil {
+		return fmt.Errorf("%s: invalid timezone %q", field, w.Timezone)
+	}
+	switch w.Outcome {
+	case "", string(engine.OutcomeWarn), string(engine.OutcomeSkip):
+	default:
+		return fmt.Errorf("%s: outcome must be WARN or SKIP, got %q", field, w.Outcome)
+	}
+	w.compiled = &compiledWindow{schedule: sched, location: loc}
+	return nil
+}
+
+// MaintenanceFor returns the first open maintenance window for a check,
+// its own windows first, or nil if none is open.
+func (c *Config) MaintenanceFor(check *Check, now time.Time) *MaintenanceWindow {
+	for i := range check.Maintenance {
+		if check.Maintenance[i].IsActive(now) {
+			return &check.Maintenance[i]
+		}
+	}
+	for i := range c.Maintenance {
+		if w := &c.Maintenance[i]; w.matches(check) && w.IsActive(now) {
+			return w
+		}
+	}
+	return nil
+}
+
+// schedule is a parsed cron expression or RRULE: the minutes, hours,
+// days, months, and weekdays a window opens on, as bitsets.
+type schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// either matches a day on its day of month or its weekday, as cron
+	// does when both are restricted; otherwise both must match.
+	either bool
+}
+
+// matches returns whether the schedule fires at t's minute.
+func (s *schedule) matches(t time.Time) bool {
+	return s.minute&(1<<t.Minute()) != 0 && s.hour&(1<<t.Hour()) != 0 && s.matchesDay(t)
+}
+
+// matchesDay returns whether the schedule fires at some time on t's day.
+func (s *schedule) matchesDay(t time.Time) bool {
+	if s.month&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	if s.either {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// lastFire returns the latest minute at or before now (in now's zone) the
+// schedule fires at, looking back no further than the day of since. It
+// steps back a day at a time, taking each matching day's latest hour and
+// minute, so a month-long window costs a month of days, not of minutes.
+func (s *schedule) lastFire(now, since time.Time) (time.Time, bool) {
+	loc := now.Location()
+	y, m, d := since.In(loc).Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	y, m, d = now.Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, loc); !day.Before(first); day = day.AddDate(0, 0, -1) {
+		if !s.matchesDay(day) {
+			continue
+		}
+		today := day.Day() == now.Day() && day.Month() == now.Month() && day.Year() == now.Year()
+
+		hours := s.hour
+		if today {
+			hours &= 2<<now.Hour() - 1
+		}
+		for ; hours != 0; hours &^= 1 << (bits.Len64(hours) - 1) {
+			hour := bits.Len64(hours) - 1
+			minutes := s.minute
+			if today && hour == now.Hour() {
+				minutes &= 2<<now.Minute() - 1
+			}
+			for ; minutes != 0; minutes &^= 1 << (bits.Len64(minutes) - 1) {
+				t := time.Date(day.Year(), day.Month(), day.Day(), hour, bits.Len64(minutes)-1, 0, 0, loc)
+				// A wall time repeated or skipped by a DST change can land
+				// after now
+				if !t.After(now) {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseSchedule parses a cron expression or, if it has a FREQ, an RRULE.
+func parseSchedule(expr string) (*schedule, error) {
+	if strings.Contains(strings.ToUpper(expr), "FREQ=") {
+		return parseRRule(expr)
+	}
+	return parseCron(expr)
+}
+
+// cronField is the range and names of one cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	monthNames   = []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+	cronFields = []cronField{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12, names: monthNames},
+		{name: "day of week", min: 0, max: 7, names: weekdayNames},
+	}
+)
+
+// parseCron parses a five-field cron expression (minute hour
+// day-of-month month day-of-week). Fields take *, values, ranges, steps,
+// lists, and month and weekday names; 7 is also Sunday.
+func parseCron(expr string) (*schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("want 5 cron fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	var bits [5]uint64
+	for i, f := range cronFields {
+		b, err := f.parse(fields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+
+	// Sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	s := &schedule{minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4]}
+	s.either = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// parse returns the bitset of values a cron field selects.
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s: range %q is backwards", f.name, rng)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single cron value, a number or a name.
+func (f cronField) value(s string) (int, error) {
+	if i := slices.Index(f.names, strings.ToLower(s)); i >= 0 && s != "" {
+		return i, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s: %q is not a value from %d to %d", f.name, s, f.min, f.max)
+	}
+	return n, nil
+}
+
+// rruleDays maps RRULE weekday codes to time.Weekday values.
+var rruleDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// parseRRule parses the subset of an RFC 5545 RRULE that describes a
+// recurring time of day: FREQ (DAILY, WEEKLY, MONTHLY, or YEARLY) with
+// BYMONTH, BYMONTHDAY, BYDAY (without ordinals), BYHOUR, and BYMINUTE.
+// BYHOUR and BYMINUTE default to 0, since there is no DTSTART, and for the
+// same reason WEEKLY needs BYDAY and MONTHLY and YEARLY need BYMONTHDAY or
+// BYDAY. INTERVAL, COUNT, and UNTIL are rejected: without a start date
+// there is nothing to count from.
+func parseRRule(expr string) (*schedule, error) {
+	parts := make(map[string]string)
+	present := make(map[string]bool)
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(expr)), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		parts[key] = value
+		present[key] = true
+	}
+
+	s := &schedule{minute: 1, hour: 1, dom: allBits(1, 31), month: allBits(1, 12), dow: allBits(0, 6)}
+	lists := []struct {
+		key      string
+		bits     *uint64
+		min, max int
+	}{
+		{"BYMINUTE", &s.minute, 0, 59},
+		{"BYHOUR", &s.hour, 0, 23},
+		{"BYMONTHDAY", &s.dom, 1, 31},
+		{"BYMONTH", &s.month, 1, 12},
+	}
+	for _, l := range lists {
+		value, ok := parts[l.key]
+		if !ok {
+			continue
+		}
+		delete(parts, l.key)
+		*l.bits = 0
+		for _, item := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(item)
+			if err != nil || n < l.min || n > l.max {
+				return nil, fmt.Errorf("%s: %q is not a value from %d to %d", l.key, item, l.min, l.max)
+			}
+			*l.bits |= 1 << n
+		}
+	}
+	if value, ok := parts["BYDAY"]; ok {
+		delete(parts, "BYDAY")
+		s.dow = 0
+		for _, day := range strings.Split(value, ",") {
+			i := slices.Index(rruleDays, day)
+			if i < 0 {
+				return nil, fmt.Errorf("BYDAY: %q is not a weekday (MO-SU, without ordinals)", day)
+			}
+			s.dow |= 1 << i
+		}
+	}
+
+	freq := parts["FREQ"]
+	delete(parts, "FREQ")
+	for key := range parts {
+		return nil, fmt.Errorf("unsupported RRULE part %s", key)
+	}
+	switch freq {
+	case "DAILY":
+	case "WEEKLY":
+		if !present["BYDAY"] {
+			return nil, fmt.Errorf("FREQ=WEEKLY needs BYDAY")
+		}
+	case "MONTHLY":
+		if !present["BYMONTHDAY"] && !present["BYDAY"] {
+			return nil, fmt.Errorf("FREQ=MONTHLY needs BYMONTHDAY or BYDAY")
+		}
+	case "YEARLY":
+		if !present["BYMONTH"] || (!present["BYMONTHDAY"] && !present["BYDAY"]) {
+			return nil, fmt.Errorf("FREQ=YEARLY needs BYMONTH and BYMONTHDAY or BYDAY")
+		}
+	default:
+		return nil, fmt.Errorf("FREQ must be DAILY, WEEKLY, MONTHLY, or YEARLY, got %q", freq)
+	}
+	return s, nil
+}
+
+// allBits returns a bitset with bits lo through hi set.
+func allBits(lo, hi int) uint64 {
+	var bits uint64
+	for v := lo; v <= hi; v++ {
+		bits |= 1 << v
+	}
+	return bits
+}
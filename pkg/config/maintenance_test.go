@@ -0,0 +1,129 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// Sunday 2026-03-01 03:00 and the following days
+	sun3am := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr  string
+		match []time.Time
+		miss  []time.Time
+	}{
+		{"0 3 * * sun", []time.Time{sun3am}, []time.Time{sun3am.Add(time.Minute), sun3am.AddDate(0, 0, 1)}},
+		{"0 3 * * 7", []time.Time{sun3am}, []time.Time{sun3am.AddDate(0, 0, 6)}},
+		{"*/15 1-4 * * *", []time.Time{sun3am, sun3am.Add(45 * time.Minute)}, []time.Time{sun3am.Add(10 * time.Minute), sun3am.Add(2 * time.Hour)}},
+		{"0 3 15 * mon", []time.Time{sun3am.AddDate(0, 0, 1), sun3am.AddDate(0, 0, 14)}, []time.Time{sun3am}},
+		{"0 3 1 jan,mar *", []time.Time{sun3am}, []time.Time{sun3am.AddDate(0, 1, 0)}},
+		{"FREQ=WEEKLY;BYDAY=SU;BYHOUR=3", []time.Time{sun3am}, []time.Time{sun3am.Add(time.Minute), sun3am.AddDate(0, 0, 1)}},
+		{"RRULE:FREQ=DAILY;BYHOUR=3;BYMINUTE=0,30", []time.Time{sun3am, sun3am.Add(30 * time.Minute), sun3am.AddDate(0, 0, 1)}, []time.Time{sun3am.Add(time.Hour)}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1;BYDAY=SU;BYHOUR=3", []time.Time{sun3am}, []time.Time{sun3am.AddDate(0, 1, 0), sun3am.AddDate(0, 0, 7)}},
+		{"FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=1;BYHOUR=3", []time.Time{sun3am, sun3am.AddDate(1, 0, 0)}, []time.Time{sun3am.AddDate(0, 0, 1), sun3am.AddDate(0, 1, 0)}},
+	}
+	for _, tt := range tests {
+		sched, err := parseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("parseSchedule(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		for _, at := range tt.match {
+			if !sched.matches(at) {
+				t.Errorf("%q should match %s", tt.expr, at.Format(time.RFC1123))
+			}
+		}
+		for _, at := range tt.miss {
+			if sched.matches(at) {
+				t.Errorf("%q should not match %s", tt.expr, at.Format(time.RFC1123))
+			}
+		}
+	}
+
+	invalid := map[string]string{
+		"0 3 * *":                "want 5 cron fields",
+		"60 3 * * *":             `minute: "60" is not a value from 0 to 59`,
+		"0 3 * * funday":         "day of week",
+		"*/0 * * * *":            "invalid step",
+		"0 5-3 * * *":            "backwards",
+		"FREQ=WEEKLY;BYHOUR=3":   "FREQ=WEEKLY needs BYDAY",
+		"FREQ=DAILY;INTERVAL=2":  "unsupported RRULE part INTERVAL",
+		"FREQ=MONTHLY;BYDAY=1SU": "without ordinals",
+		"FREQ=HOURLY":            "FREQ must be DAILY, WEEKLY, MONTHLY, or YEARLY",
+		"FREQ=DAILY;BYHOUR=24":   `BYHOUR: "24" is not a value from 0 to 23`,
+		"FREQ=YEARLY;BYMONTH=3":  "FREQ=YEARLY needs BYMONTH and BYMONTHDAY or BYDAY",
+	}
+	for expr, want := range invalid {
+		if _, err := parseSchedule(expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseSchedule(%q): expected error containing %q, got %v", expr, want, err)
+		}
+	}
+}
+
+func TestMaintenanceFor(t *testing.T) {
+	window := func(schedule string) MaintenanceWindow {
+		return MaintenanceWindow{Schedule: schedule, Duration: Duration{time.Hour}, Timezone: "America/Chicago", Reason: "NAS reboot"}
+	}
+	nas := &Check{Name: "NAS Mounted", Tags: []string{"storage"}}
+	dns := &Check{Name: "DNS", Maintenance: []MaintenanceWindow{window("30 2 * * *")}}
+
+	cfg := &Config{Maintenance: []MaintenanceWindow{window("0 3 * * sun")}}
+	cfg.Maintenance[0].Tag = "storage"
+
+	chicago, _ := time.LoadLocation("America/Chicago")
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, chicago) }
+
+	tests := []struct {
+		check *Check
+		now   time.Time
+		want  bool
+	}{
+		{nas, at(1, 3, 0), true},
+		{nas, at(1, 3, 59), true},
+		{nas, at(1, 4, 0), false},
+		{nas, at(1, 2, 59), false},
+		{nas, at(2, 3, 30), false},
+		{nas, at(1, 3, 30).UTC(), true},
+		{dns, at(1, 3, 0), true},
+		{dns, at(1, 3, 30), false},
+		{&Check{Name: "Other"}, at(1, 3, 30), false},
+	}
+	for _, tt := range tests {
+		if got := cfg.MaintenanceFor(tt.check, tt.now) != nil; got != tt.want {
+			t.Errorf("MaintenanceFor(%s, %s) open = %v, want %v", tt.check.Name, tt.now.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceWindowIsActive(t *testing.T) {
+	// Opens at midnight on the 1st of each month for 30 days
+	w := MaintenanceWindow{Tag: "storage", Schedule: "0 0 1 * *", Duration: Duration{30 * 24 * time.Hour}, Timezone: "UTC"}
+	if err := w.validate("maintenance[0]", true); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+	if w.compiled == nil {
+		t.Fatal("validate should keep the parsed schedule")
+	}
+
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(3, 1, 0, 0), true},
+		{at(3, 30, 23, 59), true},
+		{at(3, 31, 0, 0), false},
+		{at(4, 1, 0, 0), true},
+		{at(2, 28, 23, 59), true},
+	}
+	for _, tt := range tests {
+		if got := w.IsActive(tt.now); got != tt.want {
+			t.Errorf("IsActive(%s) = %v, want %v", tt.now.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
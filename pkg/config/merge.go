@@ -0,0 +1,291 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StdinPath is the config path that reads the config from standard
+// input, e.g. to pipe in checks rendered by `helm template`. Includes in
+// a config read from stdin resolve from the working directory.
+const StdinPath = "-"
+
+// stdin is where a StdinPath config is read from (replaced in tests).
+var stdin io.Reader = os.Stdin
+
+// LoadOptions controls how a config file is loaded.
+type LoadOptions struct {
+	// Strict rejects unknown fields so typos like "commnad:" fail loudly.
+	Strict bool
+
+	// Profiles are applied in order over the merged file.
+	Profiles []string
+}
+
+// LoadConfigWith loads a config file with its includes and the selected
+// profiles merged in, then applies defaults. Sources are merged in this
+// order, later ones taking precedence:
+//
+//  1. files listed in include, in order (each merged with its own includes)
+//  2. the file itself
+//  3. the selected profiles, in order
+//
+// Merging is deterministic: mappings merge key by key with the later value
+// winning, lists and scalars are replaced whole, and checks merge by ID (a
+// check whose ID already exists is merged into that check in place; new
+// checks are appended). Defaults then fill fields no source set, and CLI
+// flags like -timeout fill whatever is still unset at run time.
+func LoadConfigWith(path string, opts LoadOptions) (*Config, error) {
+	root, err := loadNode(path, nil, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyProfiles(root, opts.Profiles); err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	config.ApplyDefaults()
+	return &config, nil
+}
+
+// loadNode reads a config file into a mapping node with its includes
+// merged in. stack holds the files being included, to detect cycles. In
+// strict mode each file is checked for unknown fields on its own, so
+// errors point at the right file and line.
+func loadNode(path string, stack []string, strict bool) (*yaml.Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	for _, p := range stack {
+		if p == abs {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, abs), " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	var data []byte
+	if path == StdinPath && len(stack) == 1 {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path) //nolint:gosec // Path is user-provided config file
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if strict {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&Config{}); err != nil && !errors.Is(err, io.EOF) {
+			return nil, parseError(path, stack, err)
+		}
+	}
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, parseError(path, stack, err)
+	}
+	own := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) > 0 {
+		own = doc.Content[0]
+	}
+	if own.Kind != yaml.MappingNode {
+		return nil, parseError(path, stack, fmt.Errorf("line %d: expected a mapping", own.Line))
+	}
+	if _, err := migrateNode(own); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	includes, err := takeIncludes(own)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, pattern := range includes {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: include %q: %w", path, pattern, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s: include %q matches no files", path, pattern)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			included, err := loadNode(file, stack, strict)
+			if err != nil {
+				return nil, err
+			}
+			mergeConfigNodes(merged, included)
+		}
+	}
+	mergeConfigNodes(merged, own)
+	return merged, nil
+}
+
+// takeIncludes removes the include key from a config mapping and returns
+// its paths.
+func takeIncludes(node *yaml.Node) ([]string, error) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "include" {
+			continue
+		}
+		var includes []string
+		if err := node.Content[i+1].Decode(&includes); err != nil {
+			return nil, fmt.Errorf("include: %w", err)
+		}
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		return includes, nil
+	}
+	return nil, nil
+}
+
+// applyProfiles merges the named profiles over root, in order, and removes
+// the profiles key.
+func applyProfiles(root *yaml.Node, names []string) error {
+	var profiles *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "profiles" {
+			profiles = root.Content[i+1]
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+
+	var available []string
+	if profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			available = append(available, profiles.Content[i].Value)
+		}
+	}
+	sort.Strings(available)
+
+	for _, name := range names {
+		profile := mappingValue(profiles, name)
+		if profile == nil {
+			return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(available, ", "))
+		}
+		if profile.Kind != yaml.MappingNode {
+			return fmt.Errorf("profiles.%s: line %d: expected a mapping", name, profile.Line)
+		}
+		for _, key := range []string{"apiVersion", "include", "profiles"} {
+			if mappingValue(profile, key) != nil {
+				return fmt.Errorf("profiles.%s: %s is not allowed in a profile", name, key)
+			}
+		}
+		mergeConfigNodes(root, profile)
+	}
+	return nil
+}
+
+// mergeConfigNodes merges a config mapping src into dst: checks merge by
+// ID, everything else as in mergeNodes.
+func mergeConfigNodes(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		existing := mappingValue(dst, key.Value)
+		switch {
+		case existing == nil:
+			dst.Content = append(dst.Content, key, value)
+		case key.Value == "checks" && existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			mergeChecks(existing, value)
+		default:
+			setMappingValue(dst, key.Value, mergeNodes(existing, value))
+		}
+	}
+}
+
+// mergeNodes returns src merged over dst: two mappings merge key by key
+// (recursively), anything else is replaced by src.
+func mergeNodes(dst, src *yaml.Node) *yaml.Node {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if existing := mappingValue(dst, key.Value); existing != nil {
+			setMappingValue(dst, key.Value, mergeNodes(existing, value))
+		} else {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+	return dst
+}
+
+// mergeChecks merges a checks sequence src into dst by check ID.
+func mergeChecks(dst, src *yaml.Node) {
+	index := make(map[string]int, len(dst.Content))
+	for i, check := range dst.Content {
+		if id := checkNodeID(check); id != "" {
+			index[id] = i
+		}
+	}
+	for _, check := range src.Content {
+		id := checkNodeID(check)
+		if i, ok := index[id]; ok && id != "" {
+			dst.Content[i] = mergeNodes(dst.Content[i], check)
+			continue
+		}
+		if id != "" {
+			index[id] = len(dst.Content)
+		}
+		dst.Content = append(dst.Content, check)
+	}
+}
+
+// checkNodeID returns the ID of a check node: its id, or a slug of its
+// name ("" if neither is a plain string).
+func checkNodeID(node *yaml.Node) string {
+	var ref struct {
+		ID   string `yaml:"id"`
+		Name string `yaml:"name"`
+	}
+	if node.Kind != yaml.MappingNode || node.Decode(&ref) != nil {
+		return ""
+	}
+	return (&Check{ID: ref.ID, Name: ref.Name}).GetID()
+}
+
+// mappingValue returns the value for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value for an existing key in a mapping node.
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+}
+
+// parseError wraps a parse error, naming the file if it was included.
+func parseError(path string, stack []string, err error) error {
+	if len(stack) > 1 {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return fmt.Errorf("failed to parse config file: %w", err)
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles writes name → content files into a temp dir and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfigWithIncludesAndProfiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": `
+defaults:
+  timeout: 10s
+  tags: [base]
+environment:
+  set: {TZ: UTC, LANG: C}
+checks:
+  - name: "API Server"
+    command: "kubectl version"
+    layer: 1
+  - name: "DNS"
+    command: "dig example.com"
+    layer: 1
+`,
+		"apps.d/10-media.yaml": `
+checks:
+  - name: "Jellyfin"
+    command: "curl jellyfin"
+    layer: 3
+`,
+		"checks.yaml": `
+include: [base.yaml, "apps.d/*.yaml"]
+defaults:
+  retry: true
+environment:
+  set: {TZ: America/Chicago}
+checks:
+  - name: "DNS"
+    command: "dig @1.1.1.1 example.com"
+profiles:
+  ci:
+    defaults:
+      timeout: 5s
+    checks:
+      - id: jellyfin
+        disabled:
+          reason: "no media in CI"
+`,
+	})
+
+	cfg, err := LoadConfigWith(filepath.Join(dir, "checks.yaml"), LoadOptions{Strict: true, Profiles: []string{"ci"}})
+	if err != nil {
+		t.Fatalf("LoadConfigWith failed: %v", err)
+	}
+
+	var names []string
+	for _, c := range cfg.Checks {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "API Server,DNS,Jellyfin" {
+		t.Errorf("checks = %s, want includes first, merged by ID", got)
+	}
+	dns := cfg.Checks[1]
+	if dns.Command != "dig @1.1.1.1 example.com" || dns.Layer != 1 {
+		t.Errorf("DNS should keep layer from base and command from file: %+v", dns)
+	}
+	if cfg.Checks[2].Disabled == nil {
+		t.Error("profile should disable Jellyfin")
+	}
+	if d := cfg.Defaults; d.Timeout.Duration != 5*time.Second || d.Retry == nil || d.Retry.disabled {
+		t.Errorf("defaults should merge key by key with profile last: %+v", d)
+	}
+	if cfg.Checks[0].Timeout.Duration != 5*time.Second || !cfg.Checks[0].IsRetry() {
+		t.Errorf("defaults should apply after merging: %+v", cfg.Checks[0])
+	}
+	if set := cfg.Environment.Set; set["TZ"] != "America/Chicago" || set["LANG"] != "C" {
+		t.Errorf("environment.set should merge key by key: %v", set)
+	}
+	if cfg.Include != nil || cfg.Profiles != nil {
+		t.Error("include and profiles should be consumed by loading")
+	}
+
+	// Without the profile
+	cfg, err = LoadConfigWith(filepath.Join(dir, "checks.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadConfigWith failed: %v", err)
+	}
+	if cfg.Checks[2].Disabled != nil || cfg.Defaults.Timeout.Duration != 10*time.Second {
+		t.Error("profile should only apply when selected")
+	}
+}
+
+func TestLoadConfigWithErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml":       "include: [b.yaml]\nchecks: []\n",
+		"b.yaml":       "include: [a.yaml]\n",
+		"missing.yaml": "include: [nope/*.yaml]\n",
+		"typo.yaml":    "include: [bad.yaml]\n",
+		"bad.yaml":     "checks:\n  - name: X\n    commnad: true\n",
+		"prof.yaml":    "profiles:\n  ci: {}\nchecks: []\n",
+		"nested.yaml":  "profiles:\n  ci:\n    include: [a.yaml]\nchecks: []\n",
+	})
+
+	tests := []struct {
+		file     string
+		profiles []string
+		errMsg   string
+	}{
+		{file: "a.yaml", errMsg: "include cycle"},
+		{file: "missing.yaml", errMsg: "matches no files"},
+		{file: "typo.yaml", errMsg: "bad.yaml: yaml: unmarshal errors:\n  line 3: field commnad not found"},
+		{file: "prof.yaml", profiles: []string{"prod"}, errMsg: `unknown profile "prod" (available: ci)`},
+		{file: "nested.yaml", profiles: []string{"ci"}, errMsg: "include is not allowed in a profile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := LoadConfigWith(filepath.Join(dir, tt.file), LoadOptions{Strict: true, Profiles: tt.profiles})
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromStdin(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": "defaults:\n  timeout: 10s\n",
+	})
+	t.Chdir(dir)
+
+	old := stdin
+	defer func() { stdin = old }()
+	stdin = strings.NewReader("include: [base.yaml]\nchecks:\n  - name: Piped\n    command: \"true\"\n")
+
+	cfg, err := LoadConfig(StdinPath)
+	if err != nil {
+		t.Fatalf("LoadConfig(-) failed: %v", err)
+	}
+	if len(cfg.Checks) != 1 || cfg.Checks[0].Name != "Piped" {
+		t.Fatalf("unexpected checks: %+v", cfg.Checks)
+	}
+	if got := cfg.Checks[0].GetTimeout(0); got != 10*time.Second {
+		t.Errorf("include should resolve from the working directory, timeout = %s", got)
+	}
+}
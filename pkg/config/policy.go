@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy holds organization-wide constraints on checks files, so shared
+// check repos stay consistent across clusters.
+type Policy struct {
+	// MaxTimeout is the largest per-check timeout allowed.
+	MaxTimeout Duration `yaml:"max_timeout,omitempty"`
+
+	// RequiredTags must all be present on every check (after defaults).
+	RequiredTags []string `yaml:"required_tags,omitempty"`
+
+	// ForbiddenCommands are regexes that no check command may match.
+	ForbiddenCommands []string `yaml:"forbidden_commands,omitempty"`
+
+	// RequireOwner requires every check to set owner.
+	RequireOwner bool `yaml:"require_owner,omitempty"`
+
+	// Owners, if set, lists the allowed owner values.
+	Owners []string `yaml:"owners,omitempty"`
+
+	// DefaultTimeout is the timeout of checks that don't set their own,
+	// held to max_timeout like theirs (default: 30s, the -timeout
+	// default). It isn't read from the policy file.
+	DefaultTimeout time.Duration `yaml:"-"`
+
+	forbidden []*regexp.Regexp
+}
+
+// defaultCheckTimeout is the CLI's -timeout default.
+const defaultCheckTimeout = 30 * time.Second
+
+// LoadPolicy reads a policy file. Unknown fields are rejected, so a typo
+// can't silently disable a constraint.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Path is user-provided policy file
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	for _, pattern := range policy.ForbiddenCommands {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("policy: invalid forbidden_commands regex %q: %w", pattern, err)
+		}
+		policy.forbidden = append(policy.forbidden, re)
+	}
+
+	return &policy, nil
+}
+
+// Validate checks a config against the policy and returns every violation,
+// joined, or nil if the config complies.
+func (p *Policy) Validate(c *Config) error {
+	errs := p.validateSettings(c)
+	for i := range c.Checks {
+		errs = append(errs, p.validateCheck(i, &c.Checks[i])...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateSettings returns the violations in the suite-level commands:
+// setup, teardown, fixtures, tag diagnostics, secrets, and the
+// on_gating_failure hook.
+func (p *Policy) validateSettings(c *Config) []error {
+	var errs []error
+	for _, cmd := range c.commands() {
+		if re := p.forbiddenMatch(cmd.command); re != nil {
+			errs = append(errs, fmt.Errorf("%s: policy: command %q matches forbidden pattern %q", cmd.field, cmd.command, re.String()))
+		}
+	}
+	return errs
+}
+
+// validateCheck returns the check's violations.
+func (p *Policy) validateCheck(i int, check *Check) []error {
+	var errs []error
+	violation := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("check %d (%s): policy: %s", i, check.Name, fmt.Sprintf(format, args...)))
+	}
+
+	if limit := p.MaxTimeout.Duration; limit > 0 {
+		if timeout := check.GetTimeout(p.defaultTimeout()); timeout > limit {
+			violation("timeout %v exceeds max_timeout %v", timeout, limit)
+		}
+		if w := check.WaitFor; w != nil && w.Timeout.Duration > limit {
+			violation("wait_for.timeout %v exceeds max_timeout %v", w.Timeout.Duration, limit)
+		}
+	}
+
+	for _, tag := range p.RequiredTags {
+		if !containsString(check.Tags, tag) {
+			violation("missing required tag %q", tag)
+		}
+	}
+
+	for _, command := range check.commands() {
+		if re := p.forbiddenMatch(command); re != nil {
+			violation("command %q matches forbidden pattern %q", command, re.String())
+		}
+	}
+
+	switch {
+	case check.Owner == "" && p.RequireOwner:
+		violation("missing owner")
+	case check.Owner != "" && len(p.Owners) > 0 && !containsString(p.Owners, check.Owner):
+		violation("owner %q is not one of %s", check.Owner, strings.Join(p.Owners, ", "))
+	}
+
+	return errs
+}
+
+// defaultTimeout returns the timeout of checks that don't set their own.
+func (p *Policy) defaultTimeout() time.Duration {
+	if p.DefaultTimeout > 0 {
+		return p.DefaultTimeout
+	}
+	return defaultCheckTimeout
+}
+
+// forbiddenMatch returns the first forbidden pattern command matches, or
+// nil.
+func (p *Policy) forbiddenMatch(command string) *regexp.Regexp {
+	for _, re := range p.forbidden {
+		if re.MatchString(command) {
+			return re
+		}
+	}
+	return nil
+}
+
+// settingsCommand is a suite-level shell command and the field it is in.
+type settingsCommand struct {
+	field, command string
+}
+
+// commands returns every suite-level shell command, in a stable order.
+func (c *Config) commands() []settingsCommand {
+	var commands []settingsCommand
+	add := func(field string, list ...string) {
+		for _, command := range list {
+			if command != "" {
+				commands = append(commands, settingsCommand{field, command})
+			}
+		}
+	}
+
+	add("setup", c.Setup...)
+	add("teardown", c.Teardown...)
+	for _, name := range slices.Sorted(maps.Keys(c.Fixtures)) {
+		add("fixtures."+name, c.Fixtures[name].Setup...)
+		add("fixtures."+name, c.Fixtures[name].Teardown...)
+	}
+	for _, tag := range slices.Sorted(maps.Keys(c.Diagnostics)) {
+		add("diagnostics."+tag, c.Diagnostics[tag]...)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Secrets)) {
+		add("secrets."+name, c.Secrets[name].Command)
+	}
+	if h := c.OnGatingFailure; h != nil {
+		add("on_gating_failure", h.Command)
+	}
+	return commands
+}
+
+// commands returns every shell command a check may run, including its
+// conditions, fallback, before and after commands, and diagnostics, plus
+// the paths and units that file and systemd checks inspect.
+func (c *Check) commands() []string {
+	commands := []string{c.Command, c.When, c.SkipIf, c.FallbackCommand}
+	commands = append(commands, c.Before...)
+	commands = append(commands, c.After...)
+	commands = append(commands, c.Diagnostics...)
+	if c.Script != nil {
+		commands = append(commands, strings.TrimSpace(c.Script.Path+" "+strings.Join(c.Script.Args, " ")))
+	}
+	if c.ExecInPod != nil {
+		commands = append(commands, c.ExecInPod.Command)
+	}
+	if c.SSH != nil {
+		commands = append(commands, c.SSH.Command)
+	}
+	if c.File != nil {
+		commands = append(commands, c.File.Path)
+	}
+	if c.Systemd != nil {
+		for _, unit := range c.Systemd.Units {
+			commands = append(commands, "systemctl is-active "+unit)
+		}
+	}
+	if c.GPU != nil && c.GPU.Job != nil {
+		commands = append(commands, strings.Join(c.GPU.Job.GetCommand(), " "))
+	}
+	return slices.DeleteFunc(commands, func(command string) bool { return command == "" })
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
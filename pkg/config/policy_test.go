@@ -0,0 +1,201 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, `
+max_timeout: 60s
+required_tags: [homelab]
+forbidden_commands: ["\\brm\\s+-rf\\b"]
+require_owner: true
+owners: [platform, media]
+`))
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+	if policy.MaxTimeout.Duration != 60*time.Second || len(policy.forbidden) != 1 {
+		t.Errorf("LoadPolicy() = %+v", policy)
+	}
+
+	if _, err := LoadPolicy(writePolicy(t, "max_timout: 60s\n")); err == nil {
+		t.Error("LoadPolicy() expected error for unknown field")
+	}
+	if _, err := LoadPolicy(writePolicy(t, "forbidden_commands: [\"(\"]\n")); err == nil {
+		t.Error("LoadPolicy() expected error for invalid regex")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, `
+max_timeout: 60s
+required_tags: [homelab]
+forbidden_commands: ["\\brm\\s+-rf\\b", "kubectl\\s+delete"]
+require_owner: true
+owners: [platform, media]
+`))
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		check    Check
+		wantErrs []string
+	}{
+		{
+			name:  "compliant",
+			check: Check{Name: "Test", Owner: "platform", Tags: []string{"homelab"}, Command: "kubectl get pods", Timeout: Duration{Duration: 30 * time.Second}},
+		},
+		{
+			name:     "timeout too long",
+			check:    Check{Name: "Test", Owner: "platform", Tags: []string{"homelab"}, Command: "true", Timeout: Duration{Duration: 5 * time.Minute}},
+			wantErrs: []string{"exceeds max_timeout"},
+		},
+		{
+			name:     "forbidden fallback and missing tag",
+			check:    Check{Name: "Test", Owner: "media", Command: "true", FallbackCommand: "kubectl delete pod web"},
+			wantErrs: []string{`missing required tag "homelab"`, "matches forbidden pattern"},
+		},
+		{
+			name:     "forbidden inside ssh",
+			check:    Check{Name: "Test", Owner: "media", Tags: []string{"homelab"}, SSH: &SSHConfig{Host: "nas", Command: "rm -rf /tmp/x"}},
+			wantErrs: []string{"matches forbidden pattern"},
+		},
+		{
+			name:     "default timeout too long",
+			check:    Check{Name: "Test", Owner: "platform", Tags: []string{"homelab"}, Command: "true", WaitFor: &WaitFor{Timeout: Duration{Duration: 10 * time.Minute}}},
+			wantErrs: []string{"wait_for.timeout 10m0s exceeds max_timeout"},
+		},
+		{
+			name: "forbidden in before, after, and diagnostics",
+			check: Check{Name: "Test", Owner: "platform", Tags: []string{"homelab"}, Command: "true",
+				Before: []string{"rm -rf /srv/seed"}, After: []string{"kubectl delete ns scratch"}, Diagnostics: []string{"rm -rf /tmp/logs"}},
+			wantErrs: []string{`"rm -rf /srv/seed"`, `"kubectl delete ns scratch"`, `"rm -rf /tmp/logs"`},
+		},
+		{
+			name:     "missing owner",
+			check:    Check{Name: "Test", Tags: []string{"homelab"}, Command: "true"},
+			wantErrs: []string{"missing owner"},
+		},
+		{
+			name:     "unknown owner",
+			check:    Check{Name: "Test", Owner: "someone", Tags: []string{"homelab"}, Command: "true"},
+			wantErrs: []string{`owner "someone" is not one of platform, media`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(&Config{Checks: []Check{tt.check}})
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want errors %v", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want substring %q", err.Error(), want)
+				}
+			}
+			if got := len(strings.Split(err.Error(), "\n")); got != len(tt.wantErrs) {
+				t.Errorf("Validate() reported %d violations, want %d", got, len(tt.wantErrs))
+			}
+		})
+	}
+}
+
+func TestPolicyValidateSettings(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, `
+max_timeout: 60s
+forbidden_commands: ["kubectl\\s+delete"]
+`))
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+
+	cfg := &Config{
+		Setup:           []string{"kubectl create ns scratch"},
+		Teardown:        []string{"kubectl delete ns scratch"},
+		Fixtures:        map[string]FixtureConfig{"db": {Teardown: []string{"kubectl delete pod db"}}},
+		Diagnostics:     map[string][]string{"media": {"kubectl delete pod -l app=plex"}},
+		OnGatingFailure: &HookConfig{Command: "kubectl delete deploy web"},
+		Checks:          []Check{{Name: "Test", Command: "true"}},
+	}
+	err = policy.Validate(cfg)
+	if err == nil {
+		t.Fatal("Validate() = nil, want violations")
+	}
+	for _, want := range []string{"teardown: policy:", "fixtures.db: policy:", "diagnostics.media: policy:", "on_gating_failure: policy:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %q, want substring %q", err.Error(), want)
+		}
+	}
+	if strings.Contains(err.Error(), "setup:") {
+		t.Errorf("Validate() flagged a compliant setup command: %v", err)
+	}
+
+	// Config.Validate enforces the policy it carries, and the CLI default
+	// timeout counts toward max_timeout
+	cfg = &Config{Checks: []Check{{Name: "Test", Command: "true"}}, Policy: policy}
+	policy.DefaultTimeout = 2 * time.Minute
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "timeout 2m0s exceeds max_timeout") {
+		t.Errorf("Config.Validate() error = %v, want max_timeout violation", err)
+	}
+}
+
+func TestLintPolicy(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, "forbidden_commands: [\"rm\\\\s+-rf\"]\nrequire_owner: true\n"))
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "checks.yaml")
+	content := `setup:
+  - rm -rf /tmp/scratch
+checks:
+  - name: Ok
+    owner: platform
+    command: "true"
+  - name: Unowned
+    command: "true"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := LintWith(path, TemplateVars{}, LintOptions{Policy: policy})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Problem{
+		{Line: 1, Message: `setup: policy: command "rm -rf /tmp/scratch" matches forbidden pattern "rm\\s+-rf"`},
+		{Line: 7, Message: "check 1 (Unowned): policy: missing owner"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %v", len(want), problems)
+	}
+	for i := range want {
+		if problems[i] != want[i] {
+			t.Errorf("problem %d = %+v, want %+v", i, problems[i], want[i])
+		}
+	}
+}
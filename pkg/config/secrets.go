@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SecretSource says where a secret's value comes from. Exactly one field
+// is set.
+type SecretSource struct {
+	// Env names an environment variable holding the value.
+	Env string `yaml:"env,omitempty"`
+
+	// File is a file holding the value, relative to the checks file's
+	// directory.
+	File string `yaml:"file,omitempty"`
+
+	// Command is a shell command whose stdout is the value (e.g., `pass
+	// show homelab/grafana`).
+	Command string `yaml:"command,omitempty"`
+
+	// Vault is a field of a Vault KV secret, read with the top-level vault
+	// settings.
+	Vault *VaultSecret `yaml:"vault,omitempty"`
+}
+
+// validateSecret checks that a secret has exactly one source.
+func validateSecret(name string, s SecretSource) error {
+	set := 0
+	for _, field := range []string{s.Env, s.File, s.Command} {
+		if strings.TrimSpace(field) != "" {
+			set++
+		}
+	}
+	if s.Vault != nil {
+		set++
+		if s.Vault.Path == "" || s.Vault.Field == "" {
+			return fmt.Errorf("secrets.%s: vault needs path and field", name)
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("secrets.%s: must set exactly one of env, file, command, or vault", name)
+	}
+	return nil
+}
+
+// ResolveSecrets reads every secret's value, resolving files relative to
+// dir, running commands with the configured environment, and logging in
+// to Vault once if any secret is stored there. A trailing
+// newline is dropped from file and command values. An unset variable, an
+// unreadable file, a failing command, or an empty value is an error, so
+// checks never run with a blank credential.
+func (c *Config) ResolveSecrets(ctx context.Context, dir string) (map[string]string, error) {
+	if len(c.Secrets) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(c.Secrets))
+	for name := range c.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	secrets := make(map[string]string, len(names))
+	var vault *vaultClient
+	for _, name := range names {
+		source := c.Secrets[name]
+		if source.Vault != nil && vault == nil {
+			var err error
+			if vault, err = c.Vault.login(ctx); err != nil {
+				return nil, fmt.Errorf("secrets.%s: %w", name, err)
+			}
+		}
+		value, err := c.resolveSecret(ctx, source, dir, vault)
+		if err != nil {
+			return nil, fmt.Errorf("secrets.%s: %w", name, err)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("secrets.%s: empty value", name)
+		}
+		secrets[name] = value
+	}
+	return secrets, nil
+}
+
+// resolveSecret reads a single secret's value from its source.
+func (c *Config) resolveSecret(ctx context.Context, s SecretSource, dir string, vault *vaultClient) (string, error) {
+	switch {
+	case s.Vault != nil:
+		return vault.read(ctx, s.Vault)
+
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", s.Env)
+		}
+		return value, nil
+
+	case s.File != "":
+		path := s.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		data, err := os.ReadFile(path) //nolint:gosec // Path comes from the user's config
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+
+	default:
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", s.Command) //nolint:gosec // Command comes from the user's config
+		cmd.Env = c.Environment.Environ(os.Environ())
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("command %q failed: %w: %s", s.Command, err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSuffix(stdout.String(), "\n"), nil
+	}
+}
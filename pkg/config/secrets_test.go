@@ -0,0 +1,54 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SMOKE_TEST_PASSWORD", "env-password")
+
+	cfg := &Config{Secrets: map[string]SecretSource{
+		"password": {Env: "SMOKE_TEST_PASSWORD"},
+		"token":    {File: "token"},
+		"api_key":  {Command: "echo command-key"},
+	}}
+	secrets, err := cfg.ResolveSecrets(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ResolveSecrets failed: %v", err)
+	}
+	want := map[string]string{"password": "env-password", "token": "file-token", "api_key": "command-key"}
+	for name, value := range want {
+		if secrets[name] != value {
+			t.Errorf("secret %s = %q, want %q", name, secrets[name], value)
+		}
+	}
+
+	rendered, err := ApplyTemplate("curl -u admin:{{.Secret.password}}", TemplateVars{Secret: secrets})
+	if err != nil || rendered != "curl -u admin:env-password" {
+		t.Errorf("unexpected template result %q, %v", rendered, err)
+	}
+
+	failures := []struct {
+		source SecretSource
+		errMsg string
+	}{
+		{SecretSource{Env: "SMOKE_TEST_UNSET"}, "secrets.s: environment variable SMOKE_TEST_UNSET is not set"},
+		{SecretSource{File: "missing"}, "secrets.s: open"},
+		{SecretSource{Command: "echo vault sealed >&2; exit 1"}, "vault sealed"},
+		{SecretSource{Command: "true"}, "secrets.s: empty value"},
+	}
+	for _, tt := range failures {
+		cfg := &Config{Secrets: map[string]SecretSource{"s": tt.source}}
+		if _, err := cfg.ResolveSecrets(context.Background(), dir); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("%+v: expected error containing %q, got %v", tt.source, tt.errMsg, err)
+		}
+	}
+}
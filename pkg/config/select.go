@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Select narrows the config to the checks matching any of the only
+// patterns (all checks if only is empty), minus those matching any skip
+// pattern. Patterns are globs (e.g., "argocd-*") matched against each
+// check's name and ID. It is an error for a pattern to be malformed or for
+// the selection to leave no checks.
+func (c *Config) Select(only, skip []string) error {
+	for _, pattern := range append(append([]string{}, only...), skip...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid check pattern %q: %w", pattern, err)
+		}
+	}
+	if len(only) == 0 && len(skip) == 0 {
+		return nil
+	}
+
+	selected := c.Checks[:0:0]
+	for _, check := range c.Checks {
+		if len(only) > 0 && !check.matchesAny(only) {
+			continue
+		}
+		if check.matchesAny(skip) {
+			continue
+		}
+		selected = append(selected, check)
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no checks selected (only: %s; skip: %s)", strings.Join(only, ","), strings.Join(skip, ","))
+	}
+	c.Checks = selected
+	return nil
+}
+
+// matchesAny returns whether the check's name or ID matches any pattern.
+func (c *Check) matchesAny(patterns []string) bool {
+	id := c.GetID()
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, c.Name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLayerRange parses a layer range: "N" for one layer, "N-M" for an
+// inclusive range, and "N-" or "-M" for an open one. The empty string
+// selects every layer.
+func ParseLayerRange(s string) (lo, hi int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, math.MaxInt, nil
+	}
+
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	if !isRange {
+		hiStr = loStr
+	}
+	lo, hi = 0, math.MaxInt
+	if loStr != "" {
+		if lo, err = strconv.Atoi(loStr); err != nil || lo < 0 {
+			return 0, 0, fmt.Errorf("invalid layer range %q", s)
+		}
+	}
+	if hiStr != "" {
+		if hi, err = strconv.Atoi(hiStr); err != nil || hi < 0 {
+			return 0, 0, fmt.Errorf("invalid layer range %q", s)
+		}
+	}
+	if lo > hi {
+		return 0, 0, fmt.Errorf("invalid layer range %q: %d is after %d", s, lo, hi)
+	}
+	return lo, hi, nil
+}
+
+// SelectLayers narrows the config to checks whose layer is within lo..hi
+// (inclusive). It is an error for the range to leave no checks.
+func (c *Config) SelectLayers(lo, hi int) error {
+	selected := c.Checks[:0:0]
+	for _, check := range c.Checks {
+		if check.Layer >= lo && check.Layer <= hi {
+			selected = append(selected, check)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no checks in layers %s", describeLayers(lo, hi))
+	}
+	c.Checks = selected
+	return nil
+}
+
+// describeLayers formats a layer range for messages.
+func describeLayers(lo, hi int) string {
+	switch {
+	case hi == math.MaxInt:
+		return fmt.Sprintf("%d and up", lo)
+	case lo == hi:
+		return strconv.Itoa(lo)
+	default:
+		return fmt.Sprintf("%d-%d", lo, hi)
+	}
+}
+
+// ParseSampleSize parses a sample size for a pool of n checks: "P%" for a
+// share of them (rounded up, so any share above zero keeps at least one)
+// or "N" for a count (capped at n).
+func ParseSampleSize(s string, n int) (int, error) {
+	s = strings.TrimSpace(s)
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		p, err := strconv.ParseFloat(pct, 64)
+		if err != nil || p < 0 || p > 100 {
+			return 0, fmt.Errorf("invalid sample %q: want a percentage from 0%% to 100%%", s)
+		}
+		return int(math.Ceil(p / 100 * float64(n))), nil
+	}
+	count, err := strconv.Atoi(s)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid sample %q: want a count like 25 or a percentage like 20%%", s)
+	}
+	return min(count, n), nil
+}
+
+// SelectSample narrows the config to every gating check plus a random
+// sample of the non-gating ones, sized by spec (see ParseSampleSize) and
+// drawn from rng. Checks keep their config order. Returns how many
+// non-gating checks were kept out of how many.
+func (c *Config) SelectSample(spec string, rng *rand.Rand) (kept, pool int, err error) {
+	var candidates []int
+	for i := range c.Checks {
+		if !c.Checks[i].IsGating() {
+			candidates = append(candidates, i)
+		}
+	}
+	size, err := ParseSampleSize(spec, len(candidates))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	drop := make(map[int]bool, len(candidates))
+	for _, i := range candidates {
+		drop[i] = true
+	}
+	for _, p := range rng.Perm(len(candidates))[:size] {
+		delete(drop, candidates[p])
+	}
+
+	selected := c.Checks[:0:0]
+	for i, check := range c.Checks {
+		if !drop[i] {
+			selected = append(selected, check)
+		}
+	}
+	if len(selected) == 0 {
+		return 0, 0, fmt.Errorf("no checks selected (sample: %s)", spec)
+	}
+	c.Checks = selected
+	return size, len(candidates), nil
+}
+
+// ParseShuffle parses a -shuffle value, as go test -shuffle does: "off"
+// disables shuffling, "on" shuffles with a random seed, and a number
+// shuffles with that seed. ok reports whether to shuffle.
+func ParseShuffle(s string) (seed uint64, ok bool, err error) {
+	switch s {
+	case "", "off":
+		return 0, false, nil
+	case "on":
+		return rand.Uint64(), true, nil
+	}
+	seed, err = strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid shuffle %q (want off, on, or a seed)", s)
+	}
+	return seed, true, nil
+}
+
+// Shuffle randomizes the order of the checks, so hidden ordering
+// dependencies between them surface. The runner still groups checks by
+// layer, keeping this order within each layer.
+func (c *Config) Shuffle(rng *rand.Rand) {
+	rng.Shuffle(len(c.Checks), func(i, j int) {
+		c.Checks[i], c.Checks[j] = c.Checks[j], c.Checks[i]
+	})
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestConfigSelect(t *testing.T) {
+	checks := []Check{
+		{Name: "ArgoCD Healthy"},
+		{Name: "ArgoCD Apps Synced"},
+		{Name: "Gateway Has IP", ID: "gw-ip"},
+		{Name: "DNS Resolves"},
+	}
+
+	tests := []struct {
+		name     string
+		only     []string
+		skip     []string
+		expected string
+		errMsg   string
+	}{
+		{name: "no patterns", expected: "ArgoCD Healthy,ArgoCD Apps Synced,Gateway Has IP,DNS Resolves"},
+		{name: "only by name", only: []string{"DNS Resolves"}, expected: "DNS Resolves"},
+		{name: "only by id", only: []string{"gw-ip"}, expected: "Gateway Has IP"},
+		{name: "only by slug glob", only: []string{"argocd-*"}, expected: "ArgoCD Healthy,ArgoCD Apps Synced"},
+		{name: "skip by name glob", skip: []string{"ArgoCD*"}, expected: "Gateway Has IP,DNS Resolves"},
+		{name: "only and skip", only: []string{"argocd-*"}, skip: []string{"*Synced"}, expected: "ArgoCD Healthy"},
+		{name: "nothing selected", only: []string{"nope"}, errMsg: "no checks selected"},
+		{name: "bad pattern", skip: []string{"[a-"}, errMsg: "invalid check pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Checks: append([]Check{}, checks...)}
+			err := cfg.Select(tt.only, tt.skip)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, c := range cfg.Checks {
+				names = append(names, c.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseLayerRange(t *testing.T) {
+	tests := []struct {
+		input  string
+		lo, hi int
+		errMsg string
+	}{
+		{input: "", lo: 0, hi: math.MaxInt},
+		{input: "2", lo: 2, hi: 2},
+		{input: "1-3", lo: 1, hi: 3},
+		{input: "2-", lo: 2, hi: math.MaxInt},
+		{input: "-3", lo: 0, hi: 3},
+		{input: "3-1", errMsg: "3 is after 1"},
+		{input: "a-b", errMsg: "invalid layer range"},
+		{input: "1-2-3", errMsg: "invalid layer range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lo, hi, err := ParseLayerRange(tt.input)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if lo != tt.lo || hi != tt.hi {
+				t.Errorf("expected %d-%d, got %d-%d", tt.lo, tt.hi, lo, hi)
+			}
+		})
+	}
+}
+
+func TestConfigSelectLayers(t *testing.T) {
+	cfg := &Config{Checks: []Check{
+		{Name: "A", Layer: 1},
+		{Name: "B", Layer: 2},
+		{Name: "C", Layer: 3},
+		{Name: "D"},
+	}}
+	if err := cfg.SelectLayers(1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Checks) != 2 || cfg.Checks[0].Name != "A" || cfg.Checks[1].Name != "B" {
+		t.Errorf("expected A and B, got %+v", cfg.Checks)
+	}
+
+	if err := cfg.SelectLayers(5, math.MaxInt); err == nil || !strings.Contains(err.Error(), "5 and up") {
+		t.Errorf("expected empty selection error, got %v", err)
+	}
+}
+
+func TestParseSampleSize(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    int
+		wantErr bool
+	}{
+		{"20%", 2, false},
+		{"25%", 3, false},
+		{"0%", 0, false},
+		{"100%", 10, false},
+		{"4", 4, false},
+		{"25", 10, false},
+		{"0", 0, false},
+		{"150%", 0, true},
+		{"-1", 0, true},
+		{"some", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSampleSize(tt.spec, 10)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSampleSize(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSampleSize(%q) = %d, want %d", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestConfigSelectSample(t *testing.T) {
+	no := false
+	cfg := &Config{Checks: []Check{{Name: "Gate A"}}}
+	for i := 0; i < 10; i++ {
+		cfg.Checks = append(cfg.Checks, Check{Name: fmt.Sprintf("Optional %d", i), Expect: &ExpectConfig{Gating: &no}})
+	}
+	cfg.Checks = append(cfg.Checks, Check{Name: "Gate B"})
+
+	kept, pool, err := cfg.SelectSample("30%", rand.New(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kept != 3 || pool != 10 || len(cfg.Checks) != 5 {
+		t.Fatalf("expected 3 of 10 sampled plus 2 gating, got %d of %d and %d checks", kept, pool, len(cfg.Checks))
+	}
+	if cfg.Checks[0].Name != "Gate A" || cfg.Checks[4].Name != "Gate B" {
+		t.Errorf("gating checks should always run, in config order: %+v", cfg.Checks)
+	}
+
+	if _, _, err := cfg.SelectSample("lots", rand.New(rand.NewPCG(1, 2))); err == nil {
+		t.Error("expected an error for an invalid sample")
+	}
+}
+
+func TestParseShuffle(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantOK  bool
+		wantErr bool
+	}{
+		{"off", false, false},
+		{"", false, false},
+		{"on", true, false},
+		{"42", true, false},
+		{"sometimes", false, true},
+		{"-1", false, true},
+	}
+	for _, tt := range tests {
+		seed, ok, err := ParseShuffle(tt.in)
+		if (err != nil) != tt.wantErr || ok != tt.wantOK {
+			t.Errorf("ParseShuffle(%q) = %d, %t, %v", tt.in, seed, ok, err)
+		}
+	}
+	if seed, _, _ := ParseShuffle("42"); seed != 42 {
+		t.Errorf("expected seed 42, got %d", seed)
+	}
+}
+
+func TestConfigShuffle(t *testing.T) {
+	order := func(seed uint64) string {
+		cfg := &Config{}
+		for i := 0; i < 8; i++ {
+			cfg.Checks = append(cfg.Checks, Check{Name: fmt.Sprintf("check-%d", i)})
+		}
+		cfg.Shuffle(rand.New(rand.NewPCG(seed, seed)))
+		names := make([]string, len(cfg.Checks))
+		for i, check := range cfg.Checks {
+			names[i] = check.Name
+		}
+		return strings.Join(names, ",")
+	}
+
+	if order(7) != order(7) {
+		t.Error("the same seed should give the same order")
+	}
+	if order(7) == order(8) {
+		t.Error("different seeds should give different orders")
+	}
+	got := strings.Split(order(7), ",")
+	sort.Strings(got)
+	if strings.Join(got, ",") != "check-0,check-1,check-2,check-3,check-4,check-5,check-6,check-7" {
+		t.Errorf("shuffle should keep every check, got %v", got)
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// sopsMetadata is the subset of a SOPS file's metadata needed to decrypt
+// it with age.
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	LastModified      string `yaml:"lastmodified"`
+	MAC               string `yaml:"mac"`
+	MACOnlyEncrypted  bool   `yaml:"mac_only_encrypted"`
+	UnencryptedSuffix string `yaml:"unencrypted_suffix"`
+	EncryptedSuffix   string `yaml:"encrypted_suffix"`
+	UnencryptedRegex  string `yaml:"unencrypted_regex"`
+	EncryptedRegex    string `yaml:"encrypted_regex"`
+}
+
+// sopsValue matches a value encrypted by SOPS.
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]`)
+
+// decryptSOPSAge decrypts a SOPS-encrypted var file whose data key is
+// encrypted to age recipients, and verifies the file's MAC. It reports
+// false if the file has no age recipients, so another key type (PGP, a
+// cloud KMS, key groups) must decrypt it.
+func decryptSOPSAge(data []byte) (map[string]string, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse var file: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, false, fmt.Errorf("var file must be a mapping")
+	}
+	root := doc.Content[0]
+
+	var meta sopsMetadata
+	var metaNode *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "sops" && isSOPSMetadata(root.Content[i+1]) {
+			metaNode = root.Content[i+1]
+		}
+	}
+	if metaNode == nil {
+		return nil, false, fmt.Errorf("missing sops metadata")
+	}
+	if err := metaNode.Decode(&meta); err != nil {
+		return nil, false, fmt.Errorf("invalid sops metadata: %w", err)
+	}
+	if len(meta.Age) == 0 {
+		return nil, false, nil
+	}
+
+	key, err := sopsAgeDataKey(&meta)
+	if err != nil {
+		return nil, true, err
+	}
+	encrypted, err := sopsEncryptedKeyFunc(&meta)
+	if err != nil {
+		return nil, true, err
+	}
+
+	// The MAC covers every value in file order, as SOPS computes it
+	hash := sha512.New()
+	vars := make(map[string]string, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, node := root.Content[i].Value, root.Content[i+1]
+		if node == metaNode {
+			continue
+		}
+		if node.Kind != yaml.ScalarNode {
+			return nil, true, fmt.Errorf("%s must be a string, number, or boolean (line %d)", name, node.Line)
+		}
+		if !encrypted(name) {
+			if !meta.MACOnlyEncrypted {
+				b, err := sopsPlainBytes(node)
+				if err != nil {
+					return nil, true, fmt.Errorf("%s: %w", name, err)
+				}
+				hash.Write(b)
+			}
+			vars[name] = node.Value
+			continue
+		}
+
+		plaintext, datatype, err := sopsDecryptValue(node.Value, key, name+":")
+		if err != nil {
+			return nil, true, fmt.Errorf("%s: %w", name, err)
+		}
+		hash.Write(plaintext)
+		value := string(plaintext)
+		if datatype == "bool" {
+			// SOPS stores booleans as True/False
+			value = strings.ToLower(value)
+		}
+		vars[name] = value
+	}
+
+	lastModified, err := time.Parse(time.RFC3339, meta.LastModified)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid sops lastmodified %q", meta.LastModified)
+	}
+	mac, _, err := sopsDecryptValue(meta.MAC, key, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return nil, true, fmt.Errorf("sops mac: %w", err)
+	}
+	if fmt.Sprintf("%X", hash.Sum(nil)) != string(mac) {
+		return nil, true, fmt.Errorf("sops mac mismatch: the file was modified after it was encrypted")
+	}
+	return vars, true, nil
+}
+
+// sopsAgeDataKey decrypts the file's data key with the first age identity
+// that matches one of its recipients.
+func sopsAgeDataKey(meta *sopsMetadata) ([]byte, error) {
+	identities, err := sopsAgeIdentities()
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("no age identity found (set SOPS_AGE_KEY_FILE or SOPS_AGE_KEY)")
+	}
+
+	for _, recipient := range meta.Age {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(recipient.Enc)), identities...)
+		if err != nil {
+			continue
+		}
+		key, err := io.ReadAll(r)
+		if err == nil && len(key) == 32 {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no age identity matches the file's recipients")
+}
+
+// sopsAgeIdentities loads age identities from where sops looks for them:
+// SOPS_AGE_KEY, then SOPS_AGE_KEY_FILE or the user's sops/age/keys.txt.
+func sopsAgeIdentities() ([]age.Identity, error) {
+	var identities []age.Identity
+	if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("SOPS_AGE_KEY: %w", err)
+		}
+		identities = append(identities, ids...)
+	}
+
+	path := os.Getenv("SOPS_AGE_KEY_FILE")
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return identities, nil
+		}
+		path = filepath.Join(dir, "sops", "age", "keys.txt")
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return identities, nil
+		}
+	}
+	f, err := os.Open(path) //nolint:gosec // Path is the user's age key file
+	if err != nil {
+		return nil, fmt.Errorf("age key file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return append(identities, ids...), nil
+}
+
+// sopsEncryptedKeyFunc returns whether a top-level key's value is
+// encrypted, following the file's encrypted/unencrypted suffix or regex.
+func sopsEncryptedKeyFunc(meta *sopsMetadata) (func(string) bool, error) {
+	switch {
+	case meta.UnencryptedSuffix != "":
+		return func(k string) bool { return !strings.HasSuffix(k, meta.UnencryptedSuffix) }, nil
+	case meta.EncryptedSuffix != "":
+		return func(k string) bool { return strings.HasSuffix(k, meta.EncryptedSuffix) }, nil
+	case meta.UnencryptedRegex != "", meta.EncryptedRegex != "":
+		pattern, want := meta.EncryptedRegex, true
+		if meta.UnencryptedRegex != "" {
+			pattern, want = meta.UnencryptedRegex, false
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sops regex %q: %w", pattern, err)
+		}
+		return func(k string) bool { return re.MatchString(k) == want }, nil
+	default:
+		return func(string) bool { return true }, nil
+	}
+}
+
+// sopsDecryptValue decrypts one ENC[AES256_GCM,...] value, authenticating
+// it against additionalData (the value's key path). It returns the
+// plaintext and the value's SOPS datatype.
+func sopsDecryptValue(value string, key []byte, additionalData string) ([]byte, string, error) {
+	if value == "" {
+		return nil, "str", nil
+	}
+	m := sopsValue.FindStringSubmatch(value)
+	if m == nil {
+		return nil, "", fmt.Errorf("value is not SOPS-encrypted")
+	}
+	var parts [3][]byte
+	for i := range parts {
+		b, err := base64.StdEncoding.DecodeString(m[i+1])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid encrypted value: %w", err)
+		}
+		parts[i] = b
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, "", fmt.Errorf("decryption failed: %w", err)
+	}
+	return plaintext, m[4], nil
+}
+
+// sopsPlainBytes returns the bytes SOPS hashes into the MAC for an
+// unencrypted scalar, which depend on its YAML type.
+func sopsPlainBytes(node *yaml.Node) ([]byte, error) {
+	switch node.Tag {
+	case "!!int":
+		var i int
+		if err := node.Decode(&i); err != nil {
+			return nil, err
+		}
+		return []byte(strconv.Itoa(i)), nil
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, err
+		}
+		return []byte(strconv.FormatFloat(f, 'f', -1, 64)), nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, err
+		}
+		if b {
+			return []byte("True"), nil
+		}
+		return []byte("False"), nil
+	case "!!null":
+		return nil, nil
+	default:
+		return []byte(node.Value), nil
+	}
+}
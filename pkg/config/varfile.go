@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadVarFile reads a YAML mapping of template variables. A file
+// encrypted with SOPS (one whose top-level sops key holds SOPS metadata)
+// is decrypted first and reported as encrypted so its values can be
+// treated as secrets: in-process if its data key is encrypted to age
+// recipients, otherwise (PGP, cloud KMS, key groups) with the sops CLI.
+// Values must be scalars.
+func LoadVarFile(ctx context.Context, path string) (map[string]string, bool, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Path is a user-provided var file
+	if err != nil {
+		return nil, false, err
+	}
+	vars, encrypted, err := parseVarFile(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+	if !encrypted {
+		return vars, false, nil
+	}
+
+	vars, ok, err := decryptSOPSAge(data)
+	if err != nil {
+		return nil, true, fmt.Errorf("%s: %w", path, err)
+	}
+	if ok {
+		return vars, true, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, true, fmt.Errorf("%s is SOPS-encrypted, but sops is not installed", path)
+		}
+		return nil, true, fmt.Errorf("%s: sops --decrypt failed: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	vars, _, err = parseVarFile(stdout.Bytes())
+	if err != nil {
+		return nil, true, fmt.Errorf("%s (decrypted): %w", path, err)
+	}
+	return vars, true, nil
+}
+
+// parseVarFile parses a YAML mapping of scalar variables and reports
+// whether it is SOPS-encrypted. The sops metadata is left out of vars.
+func parseVarFile(data []byte) (map[string]string, bool, error) {
+	var doc map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse var file: %w", err)
+	}
+	vars := make(map[string]string, len(doc))
+	encrypted := false
+	for name, node := range doc {
+		if name == "sops" && isSOPSMetadata(&node) {
+			encrypted = true
+			continue
+		}
+		if node.Kind != yaml.ScalarNode {
+			return nil, false, fmt.Errorf("%s must be a string, number, or boolean (line %d)", name, node.Line)
+		}
+		vars[name] = node.Value
+	}
+	return vars, encrypted, nil
+}
+
+// isSOPSMetadata reports whether node looks like the metadata SOPS adds
+// to an encrypted file: a mapping with mac and version keys. A plain
+// variable that happens to be named sops is not.
+func isSOPSMetadata(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	var mac, version bool
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "mac":
+			mac = true
+		case "version":
+			version = true
+		}
+	}
+	return mac && version
+}
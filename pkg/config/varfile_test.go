@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+func TestLoadVarFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"plain.yaml":     "grafana_url: https://grafana.lab\nreplicas: 3\n",
+		"encrypted.yaml": "grafana_password: ENC[AES256_GCM,data:xyz,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:abc,type:str]\n  version: 3.8.1\n",
+		"named.yaml":     "sops: enabled\nregion: lab\n",
+		"lookalike.yaml": "sops:\n  version: 3.8.1\n",
+		"nested.yaml":    "hosts:\n  - a\n",
+	})
+
+	vars, encrypted, err := LoadVarFile(context.Background(), filepath.Join(dir, "plain.yaml"))
+	if err != nil {
+		t.Fatalf("LoadVarFile failed: %v", err)
+	}
+	if encrypted || vars["grafana_url"] != "https://grafana.lab" || vars["replicas"] != "3" {
+		t.Errorf("unexpected plain vars %v (encrypted %v)", vars, encrypted)
+	}
+
+	// A variable named sops doesn't make a file encrypted
+	vars, encrypted, err = LoadVarFile(context.Background(), filepath.Join(dir, "named.yaml"))
+	if err != nil {
+		t.Fatalf("LoadVarFile failed: %v", err)
+	}
+	if encrypted || vars["sops"] != "enabled" || vars["region"] != "lab" {
+		t.Errorf("unexpected plain vars %v (encrypted %v)", vars, encrypted)
+	}
+	if _, encrypted, err := LoadVarFile(context.Background(), filepath.Join(dir, "lookalike.yaml")); encrypted || err == nil || !strings.Contains(err.Error(), "sops must be a string") {
+		t.Errorf("expected a sops mapping without a mac to be a plain var error, got %v (encrypted %v)", err, encrypted)
+	}
+
+	if _, _, err := LoadVarFile(context.Background(), filepath.Join(dir, "nested.yaml")); err == nil || !strings.Contains(err.Error(), "hosts must be a string, number, or boolean") {
+		t.Errorf("expected scalar error, got %v", err)
+	}
+
+	// A stand-in sops that decrypts by printing fixed plaintext
+	bin := t.TempDir()
+	sops := "#!/bin/sh\nprintf 'grafana_password: hunter2\\n'\n"
+	if err := os.WriteFile(filepath.Join(bin, "sops"), []byte(sops), 0o755); err != nil { //nolint:gosec // Test script must be executable
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	vars, encrypted, err = LoadVarFile(context.Background(), filepath.Join(dir, "encrypted.yaml"))
+	if err != nil {
+		t.Fatalf("LoadVarFile of an encrypted file failed: %v", err)
+	}
+	if !encrypted || len(vars) != 1 || vars["grafana_password"] != "hunter2" {
+		t.Errorf("unexpected decrypted vars %v (encrypted %v)", vars, encrypted)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, _, err := LoadVarFile(context.Background(), filepath.Join(dir, "encrypted.yaml")); err == nil || !strings.Contains(err.Error(), "sops is not installed") {
+		t.Errorf("expected missing sops error, got %v", err)
+	}
+}
+
+// sopsEncryptValue encrypts value the way SOPS does, authenticated by aad.
+func sopsEncryptValue(t *testing.T, key []byte, value, datatype, aad string) string {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := make([]byte, 32)
+	_, _ = rand.Read(iv)
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(aad))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	b64 := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", b64(data), b64(iv), b64(tag), datatype)
+}
+
+// sopsAgeFile builds a SOPS-encrypted var file for recipient, as sops
+// would write it with the default unencrypted_suffix.
+func sopsAgeFile(t *testing.T, recipient *age.X25519Recipient) string {
+	t.Helper()
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+
+	var armored bytes.Buffer
+	aw := armor.NewWriter(&armored)
+	w, err := age.Encrypt(aw, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(key)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	const lastModified = "2026-10-16T09:00:00Z"
+	mac := sha512.New()
+	mac.Write([]byte("hunter2" + "3" + "True" + "lab"))
+
+	var b strings.Builder
+	b.WriteString("grafana_password: " + sopsEncryptValue(t, key, "hunter2", "str", "grafana_password:") + "\n")
+	b.WriteString("replicas: " + sopsEncryptValue(t, key, "3", "int", "replicas:") + "\n")
+	b.WriteString("debug: " + sopsEncryptValue(t, key, "True", "bool", "debug:") + "\n")
+	b.WriteString("region_unencrypted: lab\n")
+	b.WriteString("sops:\n    age:\n        - recipient: " + recipient.String() + "\n          enc: |\n")
+	for _, line := range strings.Split(strings.TrimSpace(armored.String()), "\n") {
+		b.WriteString("            " + line + "\n")
+	}
+	b.WriteString("    lastmodified: \"" + lastModified + "\"\n")
+	b.WriteString("    mac: " + sopsEncryptValue(t, key, fmt.Sprintf("%X", mac.Sum(nil)), "str", lastModified) + "\n")
+	b.WriteString("    unencrypted_suffix: _unencrypted\n    version: 3.9.4\n")
+	return b.String()
+}
+
+func TestLoadVarFileAge(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := sopsAgeFile(t, identity.Recipient())
+	dir := writeFiles(t, map[string]string{
+		"secrets.sops.yaml":  encrypted,
+		"tampered.sops.yaml": strings.Replace(encrypted, "region_unencrypted: lab", "region_unencrypted: prod", 1),
+		"keys.txt":           "# created: 2026-10-16\n" + identity.String() + "\n",
+	})
+	path := filepath.Join(dir, "secrets.sops.yaml")
+
+	// Decrypted in-process: no sops on PATH, no default key file
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("SOPS_AGE_KEY", "")
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(dir, "keys.txt"))
+
+	vars, isEncrypted, err := LoadVarFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("LoadVarFile failed: %v", err)
+	}
+	want := map[string]string{"grafana_password": "hunter2", "replicas": "3", "debug": "true", "region_unencrypted": "lab"}
+	if !isEncrypted || len(vars) != len(want) {
+		t.Fatalf("unexpected decrypted vars %v (encrypted %v)", vars, isEncrypted)
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("vars[%s] = %q, want %q", k, vars[k], v)
+		}
+	}
+
+	if _, _, err := LoadVarFile(context.Background(), filepath.Join(dir, "tampered.sops.yaml")); err == nil || !strings.Contains(err.Error(), "sops mac mismatch") {
+		t.Errorf("expected mac mismatch for a tampered file, got %v", err)
+	}
+
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+	t.Setenv("SOPS_AGE_KEY", other.String())
+	if _, _, err := LoadVarFile(context.Background(), path); err == nil || !strings.Contains(err.Error(), "no age identity matches") {
+		t.Errorf("expected recipient mismatch error, got %v", err)
+	}
+
+	t.Setenv("SOPS_AGE_KEY", "")
+	if _, _, err := LoadVarFile(context.Background(), path); err == nil || !strings.Contains(err.Error(), "no age identity found") {
+		t.Errorf("expected missing identity error, got %v", err)
+	}
+}
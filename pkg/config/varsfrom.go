@@ -0,0 +1,123 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// VarSource populates template variables from a Kubernetes object in the
+// target cluster. Exactly one ref is set.
+type VarSource struct {
+	// SecretRef is a Secret whose keys become secrets, available as
+	// {{.Secret.<key>}} and masked in output.
+	SecretRef *ObjectRef `yaml:"secretRef,omitempty"`
+
+	// ConfigMapRef is a ConfigMap whose keys become variables, available
+	// as {{.Custom.<key>}}.
+	ConfigMapRef *ObjectRef `yaml:"configMapRef,omitempty"`
+}
+
+// ObjectRef names a namespaced Kubernetes object.
+type ObjectRef struct {
+	Name string `yaml:"name"`
+
+	// Namespace defaults to the run's -namespace, or kubectl's default.
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// validate checks that the source names exactly one object.
+func (v VarSource) validate(i int) error {
+	if (v.SecretRef == nil) == (v.ConfigMapRef == nil) {
+		return fmt.Errorf("vars_from[%d]: must set exactly one of secretRef or configMapRef", i)
+	}
+	if ref := v.ref(); ref.Name == "" {
+		return fmt.Errorf("vars_from[%d]: name is required", i)
+	}
+	return nil
+}
+
+// ref returns the object the source names.
+func (v VarSource) ref() *ObjectRef {
+	if v.SecretRef != nil {
+		return v.SecretRef
+	}
+	return v.ConfigMapRef
+}
+
+// ResolveVarsFrom reads the vars_from objects with kubectl, in order,
+// later keys overriding earlier ones, and returns ConfigMap keys as vars
+// and Secret keys as secrets. Objects without a namespace are read from
+// namespace, or kubectl's default namespace if that is empty.
+func (c *Config) ResolveVarsFrom(ctx context.Context, kubeContext, namespace string) (map[string]string, map[string]string, error) {
+	var vars, secrets map[string]string
+	for i, source := range c.VarsFrom {
+		kind, target := "configmap", &vars
+		if source.SecretRef != nil {
+			kind, target = "secret", &secrets
+		}
+		ref := source.ref()
+		ns := ref.Namespace
+		if ns == "" {
+			ns = namespace
+		}
+
+		data, err := c.readObjectData(ctx, kind, ref.Name, ns, kubeContext)
+		if err != nil {
+			return nil, nil, fmt.Errorf("vars_from[%d]: %w", i, err)
+		}
+		if *target == nil {
+			*target = make(map[string]string, len(data))
+		}
+		for key, value := range data {
+			(*target)[key] = value
+		}
+	}
+	return vars, secrets, nil
+}
+
+// readObjectData returns a Secret's or ConfigMap's data, decoding Secret
+// values.
+func (c *Config) readObjectData(ctx context.Context, kind, name, namespace, kubeContext string) (map[string]string, error) {
+	args := []string{"get", kind, name, "-o", "json"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	if kubeContext != "" {
+		args = append(args, "--context", kubeContext)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Env = c.Environment.Environ(os.Environ())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("kubectl get %s %s failed: %w: %s", kind, name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var object struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &object); err != nil {
+		return nil, fmt.Errorf("failed to parse %s %s: %w", kind, name, err)
+	}
+	if kind != "secret" {
+		return object.Data, nil
+	}
+
+	data := make(map[string]string, len(object.Data))
+	for key, encoded := range object.Data {
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: key %s is not base64: %w", name, key, err)
+		}
+		data[key] = string(value)
+	}
+	return data, nil
+}
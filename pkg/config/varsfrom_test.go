@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveVarsFrom(t *testing.T) {
+	// Fake kubectl: serves a ConfigMap and a Secret, logging its arguments
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	fake := `#!/bin/sh
+echo "$@" >> "` + calls + `"
+case "$2 $3" in
+  "configmap smoke-vars") echo '{"data":{"grafana_url":"https://grafana.lab","replicas":"2"}}' ;;
+  "secret smoke-creds") echo '{"data":{"grafana_password":"aHVudGVyMg==","replicas":"Mw=="}}' ;;
+  *) echo "Error from server (NotFound): $2 \"$3\" not found" >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(fake), 0o755); err != nil { //nolint:gosec // Test helper must be executable
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &Config{VarsFrom: []VarSource{
+		{ConfigMapRef: &ObjectRef{Name: "smoke-vars"}},
+		{SecretRef: &ObjectRef{Name: "smoke-creds", Namespace: "smoke"}},
+	}}
+	vars, secrets, err := cfg.ResolveVarsFrom(context.Background(), "home-admin", "monitoring")
+	if err != nil {
+		t.Fatalf("ResolveVarsFrom failed: %v", err)
+	}
+	if len(vars) != 2 || vars["grafana_url"] != "https://grafana.lab" || vars["replicas"] != "2" {
+		t.Errorf("unexpected vars: %v", vars)
+	}
+	if len(secrets) != 2 || secrets["grafana_password"] != "hunter2" || secrets["replicas"] != "3" {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "get configmap smoke-vars -o json -n monitoring --context home-admin\nget secret smoke-creds -o json -n smoke --context home-admin\n"
+	if string(data) != want {
+		t.Errorf("unexpected kubectl calls:\n%s\nwant:\n%s", data, want)
+	}
+
+	cfg.VarsFrom = []VarSource{{SecretRef: &ObjectRef{Name: "missing"}}}
+	if _, _, err := cfg.ResolveVarsFrom(context.Background(), "", ""); err == nil || !strings.Contains(err.Error(), `vars_from[0]: kubectl get secret missing failed: exit status 1: Error from server (NotFound): secret "missing" not found`) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// serviceAccountTokenPath is where Kubernetes mounts a pod's service
+// account token, used for Vault's kubernetes auth.
+var serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultConfig says how to reach HashiCorp Vault for secrets with a vault
+// source.
+type VaultConfig struct {
+	// Address is Vault's URL (default: $VAULT_ADDR).
+	Address string `yaml:"address,omitempty"`
+
+	// Auth is how the runner logs in.
+	Auth VaultAuth `yaml:"auth,omitempty"`
+}
+
+// VaultAuth is the Vault auth method the runner logs in with. Credentials
+// come from the environment or the pod, never from the checks file.
+type VaultAuth struct {
+	// Method is token (the default, using $VAULT_TOKEN), approle (the
+	// secret ID is read from $VAULT_SECRET_ID), or kubernetes (the pod's
+	// service account token).
+	Method string `yaml:"method,omitempty"`
+
+	// Mount is the auth method's mount path (default: the method name).
+	Mount string `yaml:"mount,omitempty"`
+
+	// Role is the kubernetes auth role, or the AppRole role ID.
+	Role string `yaml:"role,omitempty"`
+}
+
+// VaultSecret is a field of a Vault KV secret.
+type VaultSecret struct {
+	// Path is the secret's API path. KV v2 paths include data/ (e.g.,
+	// secret/data/homelab/grafana).
+	Path string `yaml:"path"`
+
+	// Field is the key within the secret (e.g., password).
+	Field string `yaml:"field"`
+}
+
+// validate checks the auth method and its settings.
+func (v *VaultConfig) validate() error {
+	if v == nil {
+		return nil
+	}
+	switch v.Auth.Method {
+	case "", "token":
+	case "approle", "kubernetes":
+		if v.Auth.Role == "" {
+			return fmt.Errorf("vault.auth.role: required for %s auth", v.Auth.Method)
+		}
+	default:
+		return fmt.Errorf("vault.auth.method: must be token, approle, or kubernetes, got %q", v.Auth.Method)
+	}
+	return nil
+}
+
+// vaultClient reads secrets from Vault with a logged-in token, caching
+// each path so secrets sharing one are read once.
+type vaultClient struct {
+	address string
+	token   string
+	cache   map[string]map[string]any
+}
+
+// login authenticates with Vault using the configured method. A nil
+// config uses $VAULT_ADDR and $VAULT_TOKEN.
+func (v *VaultConfig) login(ctx context.Context) (*vaultClient, error) {
+	var auth VaultAuth
+	address := os.Getenv("VAULT_ADDR")
+	if v != nil {
+		auth = v.Auth
+		if v.Address != "" {
+			address = v.Address
+		}
+	}
+	if address == "" {
+		return nil, fmt.Errorf("vault: no address (set vault.address or VAULT_ADDR)")
+	}
+	client := &vaultClient{address: strings.TrimRight(address, "/"), cache: make(map[string]map[string]any)}
+
+	method := auth.Method
+	if method == "" {
+		method = "token"
+	}
+	mount := auth.Mount
+	if mount == "" {
+		mount = method
+	}
+
+	var body map[string]string
+	switch method {
+	case "token":
+		client.token = os.Getenv("VAULT_TOKEN")
+		if client.token == "" {
+			return nil, fmt.Errorf("vault: token auth needs VAULT_TOKEN")
+		}
+		return client, nil
+	case "approle":
+		secretID := os.Getenv("VAULT_SECRET_ID")
+		if secretID == "" {
+			return nil, fmt.Errorf("vault: approle auth needs VAULT_SECRET_ID")
+		}
+		body = map[string]string{"role_id": auth.Role, "secret_id": secretID}
+	case "kubernetes":
+		jwt, err := os.ReadFile(serviceAccountTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("vault: kubernetes auth needs a service account token: %w", err)
+		}
+		body = map[string]string{"role": auth.Role, "jwt": strings.TrimSpace(string(jwt))}
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := client.do(ctx, http.MethodPost, "auth/"+mount+"/login", body, &resp); err != nil {
+		return nil, fmt.Errorf("vault: %s login failed: %w", method, err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("vault: %s login returned no token", method)
+	}
+	client.token = resp.Auth.ClientToken
+	return client, nil
+}
+
+// read returns a field of the secret at a KV v1 or v2 path.
+func (c *vaultClient) read(ctx context.Context, ref *VaultSecret) (string, error) {
+	data, ok := c.cache[ref.Path]
+	if !ok {
+		var resp struct {
+			Data map[string]any `json:"data"`
+		}
+		if err := c.do(ctx, http.MethodGet, ref.Path, nil, &resp); err != nil {
+			return "", fmt.Errorf("vault: reading %s: %w", ref.Path, err)
+		}
+		data = resp.Data
+
+		// KV v2 nests the secret under data, next to its metadata
+		if nested, isV2 := data["data"].(map[string]any); isV2 && data["metadata"] != nil {
+			data = nested
+		}
+		c.cache[ref.Path] = data
+	}
+
+	value, ok := data[ref.Field]
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no field %q", ref.Path, ref.Field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("vault: %s field %q: %w", ref.Path, ref.Field, err)
+	}
+	return string(encoded), nil
+}
+
+// do calls a Vault API path, decoding the JSON response into out.
+func (c *vaultClient) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.address+"/v1/"+strings.TrimLeft(path, "/"), reader)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, httpGetMaxBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeVault serves AppRole and Kubernetes logins and a KV v2 and a KV v1
+// secret, requiring the token the logins hand out. It counts secret
+// reads.
+func fakeVault(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	reads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+			if body["role_id"] != "smoke-role" || body["secret_id"] != "smoke-secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"s.smoke"}}`))
+		case "/v1/auth/k8s/login":
+			if body["role"] != "smoke" || body["jwt"] != "sa-jwt" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"s.smoke"}}`))
+		case "/v1/secret/data/homelab/grafana", "/v1/kv/homelab/minio":
+			if r.Header.Get("X-Vault-Token") != "s.smoke" {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+			reads++
+			if strings.HasPrefix(r.URL.Path, "/v1/secret/") {
+				_, _ = w.Write([]byte(`{"data":{"data":{"user":"admin","password":"hunter2"},"metadata":{"version":3}}}`))
+			} else {
+				_, _ = w.Write([]byte(`{"data":{"access_key":"minio-key"}}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reads
+}
+
+func TestResolveSecretsVault(t *testing.T) {
+	srv, reads := fakeVault(t)
+	t.Setenv("VAULT_SECRET_ID", "smoke-secret")
+
+	cfg := &Config{
+		Vault: &VaultConfig{Address: srv.URL, Auth: VaultAuth{Method: "approle", Role: "smoke-role"}},
+		Secrets: map[string]SecretSource{
+			"grafana_user":     {Vault: &VaultSecret{Path: "secret/data/homelab/grafana", Field: "user"}},
+			"grafana_password": {Vault: &VaultSecret{Path: "secret/data/homelab/grafana", Field: "password"}},
+			"minio_key":        {Vault: &VaultSecret{Path: "kv/homelab/minio", Field: "access_key"}},
+		},
+	}
+	secrets, err := cfg.ResolveSecrets(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("ResolveSecrets failed: %v", err)
+	}
+	want := map[string]string{"grafana_user": "admin", "grafana_password": "hunter2", "minio_key": "minio-key"}
+	for name, value := range want {
+		if secrets[name] != value {
+			t.Errorf("secret %s = %q, want %q", name, secrets[name], value)
+		}
+	}
+	if *reads != 2 {
+		t.Errorf("expected each path to be read once, got %d reads", *reads)
+	}
+
+	// Kubernetes auth on a custom mount, with the pod's token
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("sa-jwt\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := serviceAccountTokenPath
+	serviceAccountTokenPath = tokenPath
+	t.Cleanup(func() { serviceAccountTokenPath = old })
+
+	cfg.Vault.Auth = VaultAuth{Method: "kubernetes", Mount: "k8s", Role: "smoke"}
+	cfg.Secrets = map[string]SecretSource{"minio_key": {Vault: &VaultSecret{Path: "kv/homelab/minio", Field: "access_key"}}}
+	if secrets, err := cfg.ResolveSecrets(context.Background(), t.TempDir()); err != nil || secrets["minio_key"] != "minio-key" {
+		t.Errorf("kubernetes auth: got %v, %v", secrets, err)
+	}
+}
+
+func TestResolveSecretsVaultErrors(t *testing.T) {
+	srv, _ := fakeVault(t)
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "s.wrong")
+	t.Setenv("VAULT_SECRET_ID", "nope")
+
+	tests := []struct {
+		name   string
+		vault  *VaultConfig
+		ref    VaultSecret
+		errMsg string
+	}{
+		{"token denied", nil, VaultSecret{Path: "kv/homelab/minio", Field: "access_key"}, "vault: reading kv/homelab/minio: 403 Forbidden: permission denied"},
+		{"approle rejected", &VaultConfig{Auth: VaultAuth{Method: "approle", Role: "smoke-role"}}, VaultSecret{Path: "kv/homelab/minio", Field: "access_key"}, "vault: approle login failed: 400 Bad Request: invalid role or secret ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Vault: tt.vault, Secrets: map[string]SecretSource{"s": {Vault: &tt.ref}}}
+			if _, err := cfg.ResolveSecrets(context.Background(), t.TempDir()); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	t.Setenv("VAULT_SECRET_ID", "smoke-secret")
+	cfg := &Config{
+		Vault:   &VaultConfig{Auth: VaultAuth{Method: "approle", Role: "smoke-role"}},
+		Secrets: map[string]SecretSource{"s": {Vault: &VaultSecret{Path: "kv/homelab/minio", Field: "secret_key"}}},
+	}
+	if _, err := cfg.ResolveSecrets(context.Background(), t.TempDir()); err == nil || !strings.Contains(err.Error(), `kv/homelab/minio has no field "secret_key"`) {
+		t.Errorf("expected missing field error, got %v", err)
+	}
+}
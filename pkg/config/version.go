@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion is the config schema version this build reads natively and
+// writes. Files declare theirs with a top-level apiVersion.
+const APIVersion = "smoke/v1"
+
+// migration upgrades a config document from one schema version to the
+// next.
+type migration struct {
+	from, to string
+
+	// apply rewrites the document's top-level mapping in place and
+	// returns a note for each change made.
+	apply func(doc *yaml.Node) []string
+}
+
+// migrations upgrade older schemas to APIVersion, oldest first; a breaking
+// config change adds a version and the migration to it. Files without an
+// apiVersion predate versioning, and smoke/v1 adopted their schema as is.
+var migrations = []migration{
+	{from: "", to: "smoke/v1", apply: func(*yaml.Node) []string { return nil }},
+}
+
+// SupportedAPIVersions lists the schema versions the loader accepts,
+// oldest first ("" for unversioned files).
+func SupportedAPIVersions() []string {
+	versions := make([]string, 0, len(migrations)+1)
+	for _, m := range migrations {
+		versions = append(versions, m.from)
+	}
+	return append(versions, APIVersion)
+}
+
+// migrateNode upgrades a config mapping node in place to APIVersion and
+// sets its apiVersion, returning a note for each change. It is an error
+// for the file to declare a version this build doesn't know, e.g. one
+// written for a newer smoke.
+func migrateNode(doc *yaml.Node) ([]string, error) {
+	version := ""
+	if v := mappingValue(doc, "apiVersion"); v != nil {
+		if v.Kind != yaml.ScalarNode || v.Value == "" {
+			return nil, fmt.Errorf("line %d: apiVersion must be a version like %s", v.Line, APIVersion)
+		}
+		version = v.Value
+	}
+
+	var notes []string
+	for version != APIVersion {
+		if err := checkAPIVersion(version); err != nil {
+			return nil, err
+		}
+		m := findMigration(version)
+		notes = append(notes, m.apply(doc)...)
+		if version == "" {
+			notes = append(notes, fmt.Sprintf("set apiVersion to %s", m.to))
+		} else {
+			notes = append(notes, fmt.Sprintf("upgraded apiVersion %s to %s", version, m.to))
+		}
+		version = m.to
+	}
+
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: APIVersion}
+	if mappingValue(doc, "apiVersion") != nil {
+		setMappingValue(doc, "apiVersion", value)
+	} else {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "apiVersion"}
+		if len(doc.Content) > 0 {
+			// Keep the file's leading comment at the top
+			key.HeadComment, doc.Content[0].HeadComment = doc.Content[0].HeadComment, ""
+		}
+		doc.Content = append([]*yaml.Node{key, value}, doc.Content...)
+	}
+	return notes, nil
+}
+
+// checkAPIVersion returns an error if files declaring version can't be
+// loaded.
+func checkAPIVersion(version string) error {
+	if version == APIVersion || findMigration(version) != nil {
+		return nil
+	}
+	return fmt.Errorf("unsupported apiVersion %q (supported: %s)", version, strings.Join(SupportedAPIVersions()[1:], ", "))
+}
+
+// findMigration returns the migration from version, or nil if none.
+func findMigration(version string) *migration {
+	for i := range migrations {
+		if migrations[i].from == version {
+			return &migrations[i]
+		}
+	}
+	return nil
+}
+
+// Migrate upgrades a config file's contents to APIVersion, keeping
+// comments, and returns the result with a note for each change. A file
+// that is already current is returned unchanged.
+func Migrate(data []byte) ([]byte, []string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("failed to parse config file: expected a mapping")
+	}
+	doc := root.Content[0]
+
+	notes, err := migrateNode(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(notes) == 0 {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), notes, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	legacy := `# Homelab checks
+checks:
+  - name: "API Ready" # the API server
+    command: "true"
+`
+	migrated, notes, err := Migrate([]byte(legacy))
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	want := `# Homelab checks
+apiVersion: smoke/v1
+checks:
+  - name: "API Ready" # the API server
+    command: "true"
+`
+	if string(migrated) != want {
+		t.Errorf("unexpected migration:\n%s\nwant:\n%s", migrated, want)
+	}
+	if len(notes) != 1 || notes[0] != "set apiVersion to smoke/v1" {
+		t.Errorf("unexpected notes: %q", notes)
+	}
+
+	again, notes, err := Migrate(migrated)
+	if err != nil {
+		t.Fatalf("Migrate of a current file failed: %v", err)
+	}
+	if string(again) != string(migrated) || len(notes) != 0 {
+		t.Errorf("a current file should be unchanged, got notes %q:\n%s", notes, again)
+	}
+
+	if _, _, err := Migrate([]byte("apiVersion: smoke/v9\nchecks: []\n")); err == nil || !strings.Contains(err.Error(), `unsupported apiVersion "smoke/v9" (supported: smoke/v1)`) {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
+
+func TestLoadConfigAPIVersion(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"legacy.yaml":  "checks:\n  - name: A\n    command: \"true\"\n",
+		"current.yaml": "apiVersion: smoke/v1\ninclude: [legacy.yaml]\nchecks:\n  - name: B\n    command: \"true\"\n",
+		"future.yaml":  "apiVersion: smoke/v2\nchecks:\n  - name: C\n    command: \"true\"\n",
+		"profile.yaml": "checks:\n  - name: D\n    command: \"true\"\nprofiles:\n  ci:\n    apiVersion: smoke/v1\n",
+	})
+
+	cfg, err := LoadConfigStrict(filepath.Join(dir, "current.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfigStrict failed: %v", err)
+	}
+	if cfg.APIVersion != APIVersion || len(cfg.Checks) != 2 {
+		t.Errorf("expected a %s config with 2 checks, got %q with %d", APIVersion, cfg.APIVersion, len(cfg.Checks))
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "future.yaml")); err == nil || !strings.Contains(err.Error(), "future.yaml: unsupported apiVersion") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+	if _, err := LoadConfigWith(filepath.Join(dir, "profile.yaml"), LoadOptions{Profiles: []string{"ci"}}); err == nil || !strings.Contains(err.Error(), "apiVersion is not allowed in a profile") {
+		t.Errorf("expected profile apiVersion error, got %v", err)
+	}
+
+	problems, err := Lint(filepath.Join(dir, "future.yaml"), TemplateVars{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if len(problems) != 1 || problems[0].Line != 1 || !strings.Contains(problems[0].Message, "unsupported apiVersion") {
+		t.Errorf("expected one apiVersion problem on line 1, got %+v", problems)
+	}
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestOutcomeFromExitCode(t *testing.T) {
@@ -152,11 +153,7 @@ func TestCheckResult_ShouldRetry(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := &CheckResult{
-				ExitCode:         tt.exitCode,
-				ExecutionError:   tt.execErr,
-				ValidationErrors: tt.valErrs,
-			}
+			result := ClassifyResult(tt.exitCode, tt.execErr, tt.valErrs, true)
 			got := result.ShouldRetry()
 			if got != tt.want {
 				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
@@ -180,3 +177,184 @@ func TestCheckResult_AllErrors(t *testing.T) {
 		t.Errorf("AllErrors() returned %d errors, want 3", len(errs))
 	}
 }
+
+func TestCheckResult_ApplyExpectedFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		exitCode    int
+		wantOutcome Outcome
+	}{
+		{"FAIL → XFAIL", ExitFail, OutcomeXFail},
+		{"PASS → XPASS", ExitPass, OutcomeXPass},
+		{"ERROR unchanged", ExitError, OutcomeError},
+		{"WARN unchanged", ExitWarn, OutcomeWarn},
+		{"SKIP unchanged", ExitSkip, OutcomeSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ClassifyResult(tt.exitCode, nil, nil, true)
+			result.ApplyExpectedFailure("tracked upstream")
+			if result.Outcome != tt.wantOutcome {
+				t.Errorf("Outcome = %v, want %v", result.Outcome, tt.wantOutcome)
+			}
+			if result.Outcome == OutcomeXFail && result.IsGatingFailure() {
+				t.Error("XFAIL should not block")
+			}
+		})
+	}
+}
+
+func TestCheckResult_ApplyOverride(t *testing.T) {
+	result := ClassifyResult(ExitError, nil, nil, true)
+	result.ApplyOverride(OutcomeWarn, "known rate limiting")
+
+	if result.Outcome != OutcomeWarn || result.IsGatingFailure() {
+		t.Errorf("expected non-blocking WARN, got %v", result.Outcome)
+	}
+	want := "known rate limiting (was ERROR: script error (exit code 2))"
+	if result.OutcomeReason != want {
+		t.Errorf("OutcomeReason = %q, want %q", result.OutcomeReason, want)
+	}
+}
+
+func TestNormalizeExitCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      int
+		passCodes []int
+		want      int
+	}{
+		{"no override", 1, nil, 1},
+		{"listed nonzero is PASS", 1, []int{0, 1}, ExitPass},
+		{"listed 4 is PASS", 4, []int{0, 4}, ExitPass},
+		{"unlisted 0 is FAIL", 0, []int{1}, ExitFail},
+		{"unlisted 2 stays ERROR", 2, []int{0, 1}, ExitError},
+		{"unlisted 3 stays SKIP", 3, []int{0}, ExitSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeExitCode(tt.code, tt.passCodes); got != tt.want {
+				t.Errorf("NormalizeExitCode(%d, %v) = %d, want %d", tt.code, tt.passCodes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckResult_ApplyNegativeExpectation(t *testing.T) {
+	tests := []struct {
+		name        string
+		exitCode    int
+		wantOutcome Outcome
+	}{
+		{"FAIL → PASS", ExitFail, OutcomePass},
+		{"PASS → FAIL", ExitPass, OutcomeFail},
+		{"ERROR unchanged", ExitError, OutcomeError},
+		{"WARN unchanged", ExitWarn, OutcomeWarn},
+		{"SKIP unchanged", ExitSkip, OutcomeSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ClassifyResult(tt.exitCode, nil, nil, true)
+			result.ApplyNegativeExpectation()
+			if result.Outcome != tt.wantOutcome {
+				t.Errorf("Outcome = %v, want %v", result.Outcome, tt.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestCheckResult_ApplyMaxDuration(t *testing.T) {
+	tests := []struct {
+		name        string
+		exitCode    int
+		duration    time.Duration
+		wantOutcome Outcome
+	}{
+		{"fast PASS unchanged", ExitPass, time.Second, OutcomePass},
+		{"slow PASS → WARN", ExitPass, 3 * time.Second, OutcomeWarn},
+		{"slow FAIL unchanged", ExitFail, 3 * time.Second, OutcomeFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ClassifyResult(tt.exitCode, nil, nil, true)
+			result.Duration = tt.duration
+			result.ApplyMaxDuration(2 * time.Second)
+			if result.Outcome != tt.wantOutcome {
+				t.Errorf("Outcome = %v, want %v", result.Outcome, tt.wantOutcome)
+			}
+		})
+	}
+
+	result := ClassifyResult(ExitPass, nil, nil, true)
+	result.Duration = 2500 * time.Millisecond
+	result.ApplyMaxDuration(2 * time.Second)
+	if want := "slow: took 2.5s (expect.max_duration 2s)"; result.OutcomeReason != want {
+		t.Errorf("OutcomeReason = %q, want %q", result.OutcomeReason, want)
+	}
+}
+
+func TestCheckResult_ApplyQuarantine(t *testing.T) {
+	tests := []struct {
+		name        string
+		exitCode    int
+		wantOutcome Outcome
+	}{
+		{"FAIL → WARN", ExitFail, OutcomeWarn},
+		{"ERROR → WARN", ExitError, OutcomeWarn},
+		{"PASS unchanged", ExitPass, OutcomePass},
+		{"SKIP unchanged", ExitSkip, OutcomeSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ClassifyResult(tt.exitCode, nil, nil, true)
+			result.ApplyQuarantine("flaky upstream")
+			if result.Outcome != tt.wantOutcome {
+				t.Errorf("Outcome = %v, want %v", result.Outcome, tt.wantOutcome)
+			}
+			if result.Gating || result.IsGatingFailure() {
+				t.Error("quarantined results must not gate")
+			}
+		})
+	}
+
+	result := ClassifyResult(ExitFail, nil, nil, true)
+	result.ApplyQuarantine("flaky upstream")
+	if want := "quarantined: flaky upstream (was FAIL: check failed (exit code 1))"; result.OutcomeReason != want {
+		t.Errorf("OutcomeReason = %q, want %q", result.OutcomeReason, want)
+	}
+}
+
+func TestCheckResult_ApplyMaintenance(t *testing.T) {
+	tests := []struct {
+		name        string
+		exitCode    int
+		outcome     Outcome
+		wantOutcome Outcome
+		wantGating  bool
+	}{
+		{"FAIL → WARN", ExitFail, OutcomeWarn, OutcomeWarn, false},
+		{"ERROR → SKIP", ExitError, OutcomeSkip, OutcomeSkip, false},
+		{"PASS unchanged", ExitPass, OutcomeSkip, OutcomePass, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ClassifyResult(tt.exitCode, nil, nil, true)
+			result.ApplyMaintenance(tt.outcome, "NAS reboot")
+			if result.Outcome != tt.wantOutcome || result.Gating != tt.wantGating {
+				t.Errorf("got %v (gating %v), want %v (gating %v)", result.Outcome, result.Gating, tt.wantOutcome, tt.wantGating)
+			}
+		})
+	}
+
+	result := ClassifyResult(ExitFail, nil, nil, true)
+	result.ApplyMaintenance(OutcomeWarn, "NAS reboot")
+	if want := "maintenance: NAS reboot (was FAIL: check failed (exit code 1))"; result.OutcomeReason != want {
+		t.Errorf("OutcomeReason = %q, want %q", result.OutcomeReason, want)
+	}
+}
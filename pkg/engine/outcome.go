@@ -15,8 +15,17 @@ const (
 	OutcomeSkip Outcome = "SKIP"
 	// OutcomeWarn indicates a warning (non-blocking).
 	OutcomeWarn Outcome = "WARN"
+	// OutcomeXFail indicates an expected failure failed (non-blocking).
+	OutcomeXFail Outcome = "XFAIL"
+	// OutcomeXPass indicates an expected failure unexpectedly passed (non-blocking).
+	OutcomeXPass Outcome = "XPASS"
 )
 
+// AllOutcomes returns every outcome in display order.
+func AllOutcomes() []Outcome {
+	return []Outcome{OutcomePass, OutcomeFail, OutcomeWarn, OutcomeSkip, OutcomeError, OutcomeXFail, OutcomeXPass}
+}
+
 // ExitCode constants matching the exit code contract.
 const (
 	ExitPass  = 0
@@ -45,6 +54,24 @@ func OutcomeFromExitCode(code int) Outcome {
 	}
 }
 
+// NormalizeExitCode applies a check's declared PASS exit codes to a raw
+// exit code. Listed codes map to ExitPass; an unlisted 0 maps to ExitFail;
+// anything else keeps its canonical meaning. An empty list is a no-op.
+func NormalizeExitCode(code int, passCodes []int) int {
+	if len(passCodes) == 0 {
+		return code
+	}
+	for _, c := range passCodes {
+		if c == code {
+			return ExitPass
+		}
+	}
+	if code == ExitPass {
+		return ExitFail
+	}
+	return code
+}
+
 // IsBlocking returns true if this outcome should block rollouts.
 // ERROR always blocks. FAIL blocks if gating=true.
 // PASS, SKIP, and WARN never block.
@@ -72,6 +99,10 @@ func (o Outcome) Symbol() string {
 		return "⊘"
 	case OutcomeWarn:
 		return "⚠"
+	case OutcomeXFail:
+		return "x"
+	case OutcomeXPass:
+		return "+"
 	default:
 		return "?"
 	}
@@ -90,6 +121,10 @@ func (o Outcome) Color() string {
 		return "\033[0;90m" // Gray
 	case OutcomeWarn:
 		return "\033[0;33m" // Yellow
+	case OutcomeXFail:
+		return "\033[0;90m" // Gray
+	case OutcomeXPass:
+		return "\033[0;33m" // Yellow
 	default:
 		return "\033[0m" // Reset
 	}
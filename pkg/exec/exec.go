@@ -5,6 +5,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,11 +21,52 @@ type CommandResult struct {
 	Output   string
 	ExitCode int
 	Error    error
+
+	// CPUTime is the user+system CPU time of the child process.
+	CPUTime time.Duration
+
+	// MaxRSS is the peak resident set size of the child process in bytes
+	// (0 if unavailable).
+	MaxRSS int64
+}
+
+// Options holds optional settings for command execution.
+type Options struct {
+	// Environ, if non-nil, replaces the runner's environment as the base
+	// the command inherits.
+	Environ []string
+
+	// Env holds extra environment variables ("KEY=value") appended to the
+	// base environment.
+	Env []string
+
+	// Stream, if set, receives the combined output as it is produced.
+	Stream io.Writer
+
+	// Sandbox, if set, confines the command (Linux only).
+	Sandbox *Sandbox
+}
+
+// Sandbox restricts what a command can reach, for untrusted check scripts.
+// The command runs in its own user and mount namespaces and, unless Network
+// is set, an empty network namespace with only a (down) loopback interface.
+type Sandbox struct {
+	// Network keeps the host's network instead of isolating the command.
+	Network bool
+
+	// Root, if set, chroots the command into this directory, which must
+	// contain the shell and any tools the command uses.
+	Root string
 }
 
 // RunCommand executes a shell command with the given timeout.
 // Returns the combined stdout/stderr, exit code, and any execution error.
 func RunCommand(ctx context.Context, command string, timeout time.Duration) CommandResult {
+	return RunCommandOpts(ctx, command, timeout, Options{})
+}
+
+// RunCommandOpts executes a shell command like RunCommand, with options.
+func RunCommandOpts(ctx context.Context, command string, timeout time.Duration, opts Options) CommandResult {
 	if timeout <= 0 {
 		timeout = 30 * time.Second
 	}
@@ -32,10 +76,26 @@ func RunCommand(ctx context.Context, command string, timeout time.Duration) Comm
 
 	// Execute via shell for proper command parsing
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if opts.Environ != nil || len(opts.Env) > 0 {
+		base := opts.Environ
+		if base == nil {
+			base = os.Environ()
+		}
+		cmd.Env = append(append([]string{}, base...), opts.Env...)
+	}
+	if opts.Sandbox != nil {
+		if err := applySandbox(cmd, opts.Sandbox); err != nil {
+			return CommandResult{ExitCode: -1, Error: err}
+		}
+	}
 
 	var output bytes.Buffer
-	cmd.Stdout = &output
-	cmd.Stderr = &output
+	var w io.Writer = &output
+	if opts.Stream != nil {
+		w = io.MultiWriter(&output, opts.Stream)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
 
 	err := cmd.Run()
 
@@ -43,6 +103,7 @@ func RunCommand(ctx context.Context, command string, timeout time.Duration) Comm
 		Output:   output.String(),
 		ExitCode: 0,
 	}
+	result.CPUTime, result.MaxRSS = resourceUsage(cmd.ProcessState)
 
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
@@ -93,7 +154,7 @@ func RunScript(ctx context.Context, scriptPath string, args []string, checksDir
 	// Build command with properly quoted arguments
 	command := scriptPath
 	for _, arg := range args {
-		command += " " + shellQuote(arg)
+		command += " " + ShellQuote(arg)
 	}
 
 	return RunCommand(ctx, command, timeout)
@@ -102,22 +163,83 @@ func RunScript(ctx context.Context, scriptPath string, args []string, checksDir
 // RunWithRetry executes a command with retry logic.
 // Returns the result and the number of attempts made.
 func RunWithRetry(ctx context.Context, command string, timeout time.Duration, maxRetries int, retryDelay time.Duration) (CommandResult, int) {
+	return RunWithRetryOpts(ctx, command, timeout, maxRetries, retryDelay, Options{})
+}
+
+// RunWithRetryOpts executes a command with retry logic, like RunWithRetry,
+// with options applied to every attempt.
+func RunWithRetryOpts(ctx context.Context, command string, timeout time.Duration, maxRetries int, retryDelay time.Duration, opts Options) (CommandResult, int) {
+	return Retry(ctx, maxRetries, retryDelay, func(ctx context.Context) CommandResult {
+		return RunCommandOpts(ctx, command, timeout, opts)
+	})
+}
+
+// Backoff spaces out retries. The zero value retries every 2s.
+type Backoff struct {
+	// Delay is the delay before the first retry (default: 2s).
+	Delay time.Duration
+
+	// Multiplier grows the delay after each retry, e.g. 2 doubles it
+	// (<= 1 keeps it fixed).
+	Multiplier float64
+
+	// MaxDelay caps the delay (0 = no cap).
+	MaxDelay time.Duration
+
+	// Jitter shortens each delay by a random fraction up to this (0-1), so
+	// checks retrying against the same service don't stay in lockstep.
+	Jitter float64
+}
+
+// Next returns the delay before the given retry (1 for the first).
+func (b Backoff) Next(retry int) time.Duration {
+	delay := float64(b.Delay)
+	if delay <= 0 {
+		delay = float64(2 * time.Second)
+	}
+	if b.Multiplier > 1 && retry > 1 {
+		delay *= math.Pow(b.Multiplier, float64(retry-1))
+	}
+	if b.MaxDelay > 0 {
+		delay = min(delay, float64(b.MaxDelay))
+	}
+	delay = min(delay, float64(math.MaxInt64/2))
+	if b.Jitter > 0 {
+		delay -= rand.Float64() * min(b.Jitter, 1) * delay
+	}
+	return time.Duration(delay)
+}
+
+// Retry runs attempt until it succeeds or maxRetries retries are used,
+// sleeping retryDelay between attempts. Only FAIL (exit 1) or execution
+// errors are retried. Returns the last result and the number of attempts.
+func Retry(ctx context.Context, maxRetries int, retryDelay time.Duration, attempt func(context.Context) CommandResult) (CommandResult, int) {
+	return RetryBackoff(ctx, maxRetries, Backoff{Delay: retryDelay}, attempt)
+}
+
+// RetryBackoff runs attempt like Retry, with delays between attempts
+// taken from backoff.
+func RetryBackoff(ctx context.Context, maxRetries int, backoff Backoff, attempt func(context.Context) CommandResult) (CommandResult, int) {
+	return RetryBackoffIf(ctx, maxRetries, backoff, attempt, shouldRetry)
+}
+
+// RetryBackoffIf runs attempt like RetryBackoff, retrying while retry
+// reports that a result warrants it, e.g. after mapping its exit code
+// through a check's expectations.
+func RetryBackoffIf(ctx context.Context, maxRetries int, backoff Backoff, attempt func(context.Context) CommandResult, retry func(CommandResult) bool) (CommandResult, int) {
 	if maxRetries < 0 {
 		maxRetries = 0
 	}
-	if retryDelay <= 0 {
-		retryDelay = 2 * time.Second
-	}
 
 	var result CommandResult
 	attempts := 0
 
 	for attempts <= maxRetries {
 		attempts++
-		result = RunCommand(ctx, command, timeout)
+		result = attempt(ctx)
 
 		// Check if we should retry
-		if !shouldRetry(result) {
+		if !retry(result) {
 			return result, attempts
 		}
 
@@ -127,7 +249,7 @@ func RunWithRetry(ctx context.Context, command string, timeout time.Duration, ma
 			case <-ctx.Done():
 				result.Error = ctx.Err()
 				return result, attempts
-			case <-time.After(retryDelay):
+			case <-time.After(backoff.Next(attempts)):
 			}
 		}
 	}
@@ -149,13 +271,14 @@ func shouldRetry(result CommandResult) bool {
 	return false
 }
 
-// shellQuote quotes a string for safe shell usage.
-func shellQuote(s string) string {
+// ShellQuote quotes a string for safe use as a single shell word. It is the
+// one quoting helper every package that builds shell commands should use.
+func ShellQuote(s string) string {
 	if s == "" {
 		return "''"
 	}
 	// If no special characters, return as-is
-	if !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}|<>&;()") {
+	if !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}|<>&;()#") {
 		return s
 	}
 	// Use single quotes, escaping any single quotes in the string
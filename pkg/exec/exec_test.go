@@ -1,7 +1,9 @@
 package exec
 
 import (
+	"bytes"
 	"context"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -126,6 +128,51 @@ func TestRunWithRetry(t *testing.T) {
 	})
 }
 
+func TestRetryBackoffIf(t *testing.T) {
+	calls := 0
+	attempt := func(context.Context) CommandResult {
+		calls++
+		return CommandResult{ExitCode: 1}
+	}
+
+	// A predicate that treats exit 1 as PASS stops after one attempt
+	_, attempts := RetryBackoffIf(context.Background(), 3, Backoff{Delay: time.Millisecond}, attempt, func(res CommandResult) bool {
+		return res.ExitCode != 1
+	})
+	if attempts != 1 || calls != 1 {
+		t.Errorf("expected 1 attempt, got %d (%d calls)", attempts, calls)
+	}
+}
+
+func TestBackoffNext(t *testing.T) {
+	fixed := Backoff{Delay: time.Second}
+	for retry := 1; retry <= 3; retry++ {
+		if got := fixed.Next(retry); got != time.Second {
+			t.Errorf("fixed retry %d: got %s, want 1s", retry, got)
+		}
+	}
+	if got := (Backoff{}).Next(1); got != 2*time.Second {
+		t.Errorf("zero value: got %s, want 2s", got)
+	}
+
+	exp := Backoff{Delay: time.Second, Multiplier: 2, MaxDelay: 5 * time.Second}
+	for retry, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 100: 5 * time.Second} {
+		if got := exp.Next(retry); got != want {
+			t.Errorf("exponential retry %d: got %s, want %s", retry, got, want)
+		}
+	}
+	if got := (Backoff{Delay: time.Second, Multiplier: 10}).Next(1000); got <= 0 {
+		t.Errorf("uncapped growth should saturate, got %s", got)
+	}
+
+	jittered := Backoff{Delay: 10 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if got := jittered.Next(1); got < 5*time.Second || got > 10*time.Second {
+			t.Fatalf("jittered delay %s outside 5s-10s", got)
+		}
+	}
+}
+
 func TestRetryBehavior(t *testing.T) {
 	ctx := context.Background()
 
@@ -177,3 +224,87 @@ func TestRetryBehavior(t *testing.T) {
 		})
 	}
 }
+
+func TestRunCommandResourceUsage(t *testing.T) {
+	result := RunCommand(context.Background(), "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done", 5*time.Second)
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.CPUTime <= 0 {
+		t.Errorf("expected CPU time to be recorded, got %v", result.CPUTime)
+	}
+	if runtime.GOOS != "windows" && result.MaxRSS <= 0 {
+		t.Errorf("expected max RSS to be recorded, got %d", result.MaxRSS)
+	}
+}
+
+func TestRunCommandOptsStream(t *testing.T) {
+	var stream bytes.Buffer
+	result := RunCommandOpts(context.Background(), "echo out; echo err >&2; echo $SMOKE_TEST_ENV", 5*time.Second, Options{
+		Env:    []string{"SMOKE_TEST_ENV=from-opts"},
+		Stream: &stream,
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	expected := "out\nerr\nfrom-opts\n"
+	if result.Output != expected {
+		t.Errorf("expected output %q, got %q", expected, result.Output)
+	}
+	if stream.String() != expected {
+		t.Errorf("expected streamed output %q, got %q", expected, stream.String())
+	}
+}
+
+func TestRunCommandOptsSandbox(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("sandbox is Linux-only")
+	}
+	if res := RunCommandOpts(context.Background(), "true", 5*time.Second, Options{Sandbox: &Sandbox{}}); res.Error != nil {
+		t.Skipf("user namespaces unavailable: %v", res.Error)
+	}
+
+	// /proc/net/dev lists one interface (lo) in an empty network namespace
+	countIfaces := `test "$(grep -c : /proc/net/dev)" -eq 1`
+
+	isolated := RunCommandOpts(context.Background(), countIfaces, 5*time.Second, Options{Sandbox: &Sandbox{}})
+	if isolated.Error != nil || isolated.ExitCode != 0 {
+		t.Errorf("expected only loopback in sandbox, got exit %d (%v): %s", isolated.ExitCode, isolated.Error, isolated.Output)
+	}
+
+	echo := RunCommandOpts(context.Background(), "echo sandboxed", 5*time.Second, Options{Sandbox: &Sandbox{Network: true}})
+	if echo.Error != nil || echo.Output != "sandboxed\n" {
+		t.Errorf("expected sandboxed command to run, got %q (%v)", echo.Output, echo.Error)
+	}
+
+	missing := RunCommandOpts(context.Background(), "true", 5*time.Second, Options{Sandbox: &Sandbox{Root: t.TempDir()}})
+	if missing.Error == nil {
+		t.Error("expected error for root without a shell")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "", expected: "''"},
+		{input: "simple", expected: "simple"},
+		{input: "app=web,tier=api", expected: "app=web,tier=api"},
+		{input: "with space", expected: "'with space'"},
+		{input: "with'quote", expected: "'with'\"'\"'quote'"},
+		{input: "special$var", expected: "'special$var'"},
+		{input: "#comment", expected: "'#comment'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := ShellQuote(tt.input)
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
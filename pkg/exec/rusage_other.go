@@ -0,0 +1,17 @@
+//go:build !unix
+
+package exec
+
+import (
+	"os"
+	"time"
+)
+
+// resourceUsage extracts CPU time from a finished process. Peak RSS is not
+// available on this platform.
+func resourceUsage(state *os.ProcessState) (cpu time.Duration, maxRSS int64) {
+	if state == nil {
+		return 0, 0
+	}
+	return state.UserTime() + state.SystemTime(), 0
+}
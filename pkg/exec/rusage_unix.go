@@ -0,0 +1,29 @@
+//go:build unix
+
+package exec
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// resourceUsage extracts CPU time and peak RSS from a finished process.
+func resourceUsage(state *os.ProcessState) (cpu time.Duration, maxRSS int64) {
+	if state == nil {
+		return 0, 0
+	}
+	cpu = state.UserTime() + state.SystemTime()
+
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return cpu, 0
+	}
+	// ru_maxrss is reported in bytes on macOS and kilobytes elsewhere
+	maxRSS = int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" {
+		maxRSS *= 1024
+	}
+	return cpu, maxRSS
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// applySandbox starts cmd in new user and mount namespaces (plus a network
+// namespace unless s.Network), mapping the runner's own uid/gid so no
+// privileges are needed beyond unprivileged user namespaces.
+func applySandbox(cmd *exec.Cmd, s *Sandbox) error {
+	flags := syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS
+	if !s.Network {
+		flags |= syscall.CLONE_NEWNET
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:                 uintptr(flags),
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Chroot:                     s.Root,
+	}
+	if s.Root != "" {
+		// Don't leave the working directory outside the new root
+		cmd.Dir = "/"
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package exec
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// applySandbox reports that sandboxing is unavailable on this platform.
+func applySandbox(_ *exec.Cmd, _ *Sandbox) error {
+	return fmt.Errorf("sandbox is only supported on Linux")
+}
@@ -0,0 +1,242 @@
+package generate
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+	"github.com/erauner/homelab-smoke/pkg/validate"
+	"gopkg.in/yaml.v3"
+)
+
+// gatusConfig is the subset of a Gatus config that is imported.
+type gatusConfig struct {
+	Endpoints []gatusEndpoint `yaml:"endpoints"`
+}
+
+// gatusEndpoint is a single Gatus endpoint monitor.
+type gatusEndpoint struct {
+	Name       string   `yaml:"name"`
+	Group      string   `yaml:"group"`
+	URL        string   `yaml:"url"`
+	Enabled    *bool    `yaml:"enabled"`
+	Conditions []string `yaml:"conditions"`
+	DNS        *struct {
+		QueryName string `yaml:"query-name"`
+		QueryType string `yaml:"query-type"`
+	} `yaml:"dns"`
+}
+
+// gatusCondition matches a Gatus condition like "[STATUS] == 200".
+var gatusCondition = regexp.MustCompile(`^\[([A-Z_]+)\]\s*(==|!=|<=|>=|<|>)\s*(.+)$`)
+
+// httpStatusMarker prefixes the status code curl appends to the body, so
+// status and body conditions can be validated on one output.
+const httpStatusMarker = "HTTP_STATUS="
+
+// ImportGatus translates Gatus endpoints into checks: HTTP(S) endpoints use
+// curl, tcp:// uses nc, icmp:// uses ping, and DNS endpoints use dig.
+// Conditions that can't be expressed are dropped and described in the
+// returned warnings.
+func ImportGatus(data []byte) ([]config.Check, []string, error) {
+	var cfg gatusConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse gatus config: %w", err)
+	}
+	if len(cfg.Endpoints) == 0 {
+		return nil, nil, fmt.Errorf("gatus config has no endpoints")
+	}
+
+	var checks []config.Check
+	var warnings []string
+	for _, e := range cfg.Endpoints {
+		name := e.Name
+		if e.Group != "" {
+			name = e.Group + "/" + e.Name
+		}
+		warn := func(format string, args ...any) {
+			warnings = append(warnings, name+": "+fmt.Sprintf(format, args...))
+		}
+
+		check, err := gatusCheck(e, warn)
+		if err != nil {
+			warn("skipped: %v", err)
+			continue
+		}
+		check.Name = name
+		if e.Group != "" {
+			check.Tags = []string{e.Group}
+		}
+		if e.Enabled != nil && !*e.Enabled {
+			check.Disabled = &config.Disabled{Reason: "disabled in gatus"}
+		}
+		checks = append(checks, check)
+	}
+	return checks, warnings, nil
+}
+
+// gatusCheck builds the check for one endpoint (without name or tags).
+func gatusCheck(e gatusEndpoint, warn func(string, ...any)) (config.Check, error) {
+	if e.DNS != nil {
+		return gatusDNSCheck(e, warn)
+	}
+
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return config.Check{}, fmt.Errorf("invalid url %q: %w", e.URL, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return gatusHTTPCheck(e, warn), nil
+	case "tcp":
+		host, port, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			return config.Check{}, fmt.Errorf("invalid tcp address %q: %w", u.Host, err)
+		}
+		for _, c := range e.Conditions {
+			if normalizeCondition(c) != "[CONNECTED] == true" {
+				warn("dropped condition %q (only [CONNECTED] == true is supported for tcp)", c)
+			}
+		}
+		return config.Check{Command: tcpCommand(host, port)}, nil
+	case "icmp":
+		for _, c := range e.Conditions {
+			if normalizeCondition(c) != "[CONNECTED] == true" {
+				warn("dropped condition %q (only [CONNECTED] == true is supported for icmp)", c)
+			}
+		}
+		return config.Check{Command: pingCommand(u.Hostname())}, nil
+	default:
+		return config.Check{}, fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+}
+
+// gatusHTTPCheck builds a curl check validating status and body conditions.
+func gatusHTTPCheck(e gatusEndpoint, warn func(string, ...any)) config.Check {
+	v := &validate.Validation{}
+	var statusPattern string
+
+	for _, c := range e.Conditions {
+		m := gatusCondition.FindStringSubmatch(strings.TrimSpace(c))
+		if m == nil {
+			warn("dropped unparseable condition %q", c)
+			continue
+		}
+		placeholder, op, value := m[1], m[2], strings.TrimSpace(m[3])
+
+		switch {
+		case placeholder == "STATUS" && op == "==" && statusPattern == "":
+			statusPattern = regexp.QuoteMeta(value)
+		case placeholder == "STATUS" && op == "<" && (value == "300" || value == "400" || value == "500") && statusPattern == "":
+			statusPattern = fmt.Sprintf("[1-%c]\\d\\d", value[0]-1)
+		case placeholder == "BODY" && op == "==" && v.Contains == "" && !strings.Contains(strings.Trim(unwrapPattern(value), "*"), "*"):
+			v.Contains = strings.Trim(unwrapPattern(value), "*")
+		default:
+			warn("dropped condition %q (not expressible as a smoke validation)", c)
+		}
+	}
+
+	if statusPattern != "" {
+		v.Regex = httpStatusMarker + statusPattern + "$"
+	}
+	check := config.Check{Command: curlCommand(e.URL, "", false)}
+	if !v.IsEmpty() {
+		check.Validate = v
+	}
+	return check
+}
+
+// gatusDNSCheck builds a dig check validating rcode and answer conditions.
+func gatusDNSCheck(e gatusEndpoint, warn func(string, ...any)) (config.Check, error) {
+	if e.DNS.QueryName == "" {
+		return config.Check{}, fmt.Errorf("dns endpoint missing query-name")
+	}
+	queryType := e.DNS.QueryType
+	if queryType == "" {
+		queryType = "A"
+	}
+	server := strings.TrimPrefix(e.URL, "dns://")
+	if host, _, err := net.SplitHostPort(server); err == nil {
+		server = host
+	}
+
+	v := &validate.Validation{}
+	for _, c := range e.Conditions {
+		m := gatusCondition.FindStringSubmatch(strings.TrimSpace(c))
+		switch {
+		case m != nil && m[1] == "DNS_RCODE" && m[2] == "==":
+			v.Contains = "status: " + strings.TrimSpace(m[3])
+		case m != nil && m[1] == "BODY" && m[2] == "==" && v.Regex == "":
+			v.Regex = `(?m)\s` + regexp.QuoteMeta(strings.TrimSpace(m[3])) + `$`
+		default:
+			warn("dropped condition %q (not expressible as a smoke validation)", c)
+		}
+	}
+
+	check := config.Check{Command: digCommand(server, e.DNS.QueryName, queryType)}
+	if !v.IsEmpty() {
+		check.Validate = v
+	}
+	return check, nil
+}
+
+// curlCommand fetches url, appending the status code after
+// httpStatusMarker. An empty method means GET; insecure skips TLS
+// verification.
+func curlCommand(url, method string, insecure bool) string {
+	command := "curl -sS --max-time 10"
+	switch method {
+	case "", "GET":
+	case "HEAD":
+		// -X HEAD would wait for a body that never comes.
+		command += " -I"
+	default:
+		command += " -X " + exec.ShellQuote(method)
+	}
+	if insecure {
+		command += " -k"
+	}
+	command += fmt.Sprintf(" -w '\\n%s%%{http_code}' %s", httpStatusMarker, exec.ShellQuote(url))
+	return unreachableIsFail(command)
+}
+
+// tcpCommand checks that host accepts connections on port.
+func tcpCommand(host, port string) string {
+	return unreachableIsFail("nc -z -w 5 " + exec.ShellQuote(host) + " " + exec.ShellQuote(port))
+}
+
+// pingCommand checks that host answers a single ping.
+func pingCommand(host string) string {
+	return unreachableIsFail("ping -c 1 -W 2 " + exec.ShellQuote(host))
+}
+
+// digCommand queries server for name, printing the status header and
+// answer section.
+func digCommand(server, name, queryType string) string {
+	return unreachableIsFail("dig " + exec.ShellQuote("@"+server) + " " + exec.ShellQuote(name) + " " +
+		exec.ShellQuote(queryType) + " +noall +comments +answer")
+}
+
+// unreachableIsFail maps any tool failure to exit 1, since an unreachable
+// endpoint is unhealthy in Gatus (FAIL), not a runner error.
+func unreachableIsFail(command string) string {
+	return command + " || exit 1"
+}
+
+// unwrapPattern strips Gatus's pat(...) wrapper from a condition value.
+func unwrapPattern(value string) string {
+	if strings.HasPrefix(value, "pat(") && strings.HasSuffix(value, ")") {
+		return value[len("pat(") : len(value)-1]
+	}
+	return value
+}
+
+// normalizeCondition collapses whitespace in a condition for comparison.
+func normalizeCondition(c string) string {
+	return strings.Join(strings.Fields(c), " ")
+}
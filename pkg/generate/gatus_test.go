@@ -0,0 +1,132 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+)
+
+const gatusConfigYAML = `
+endpoints:
+  - name: jellyfin
+    group: media
+    url: "https://jellyfin.lab/health"
+    interval: 1m
+    conditions:
+      - "[STATUS] == 200"
+      - "[BODY] == pat(*Healthy*)"
+      - "[RESPONSE_TIME] < 500"
+  - name: api
+    url: "https://api.lab"
+    conditions:
+      - "[STATUS] < 400"
+  - name: postgres
+    url: "tcp://db.lab:5432"
+    conditions:
+      - "[CONNECTED] == true"
+  - name: router
+    url: "icmp://10.0.0.1"
+    enabled: false
+  - name: resolver
+    url: "10.0.0.53"
+    dns:
+      query-name: "nas.lab"
+      query-type: "A"
+    conditions:
+      - "[DNS_RCODE] == NOERROR"
+      - "[BODY] == 10.0.0.20"
+  - name: mqtt
+    url: "mqtt://broker.lab:1883"
+`
+
+func TestImportGatus(t *testing.T) {
+	checks, warnings, err := ImportGatus([]byte(gatusConfigYAML))
+	if err != nil {
+		t.Fatalf("ImportGatus() error = %v", err)
+	}
+
+	if len(checks) != 5 {
+		t.Fatalf("ImportGatus() returned %d checks, want 5", len(checks))
+	}
+	byName := make(map[string]int)
+	for i, c := range checks {
+		byName[c.Name] = i
+	}
+
+	jellyfin := checks[byName["media/jellyfin"]]
+	if !strings.HasPrefix(jellyfin.Command, "curl ") || !strings.Contains(jellyfin.Command, " https://jellyfin.lab/health ") {
+		t.Errorf("jellyfin command = %q", jellyfin.Command)
+	}
+	if jellyfin.Validate == nil || jellyfin.Validate.Regex != "HTTP_STATUS=200$" || jellyfin.Validate.Contains != "Healthy" {
+		t.Errorf("jellyfin validate = %+v", jellyfin.Validate)
+	}
+	if len(jellyfin.Tags) != 1 || jellyfin.Tags[0] != "media" {
+		t.Errorf("jellyfin tags = %v, want [media]", jellyfin.Tags)
+	}
+
+	if api := checks[byName["api"]]; api.Validate == nil || api.Validate.Regex != `HTTP_STATUS=[1-3]\d\d$` {
+		t.Errorf("api validate = %+v", api.Validate)
+	}
+	if pg := checks[byName["postgres"]]; pg.Command != "nc -z -w 5 db.lab 5432 || exit 1" {
+		t.Errorf("postgres command = %q", pg.Command)
+	}
+	if router := checks[byName["router"]]; router.Disabled == nil || !strings.HasPrefix(router.Command, "ping ") {
+		t.Errorf("router = %+v, want disabled ping check", router)
+	}
+	resolver := checks[byName["resolver"]]
+	if !strings.HasPrefix(resolver.Command, "dig @10.0.0.53 nas.lab A") {
+		t.Errorf("resolver command = %q", resolver.Command)
+	}
+	if resolver.Validate == nil || resolver.Validate.Contains != "status: NOERROR" {
+		t.Errorf("resolver validate = %+v", resolver.Validate)
+	}
+
+	wantWarnings := []string{"RESPONSE_TIME", "mqtt: skipped"}
+	if len(warnings) != len(wantWarnings) {
+		t.Fatalf("warnings = %v, want %d", warnings, len(wantWarnings))
+	}
+	for i, want := range wantWarnings {
+		if !strings.Contains(warnings[i], want) {
+			t.Errorf("warnings[%d] = %q, want substring %q", i, warnings[i], want)
+		}
+	}
+}
+
+func TestImportGatusQuotesValues(t *testing.T) {
+	data := `
+endpoints:
+  - name: tcp
+    url: "tcp://db.lab;touch$HOME:5432"
+  - name: icmp
+    url: "icmp://$(reboot)"
+  - name: dns
+    url: "10.0.0.53"
+    dns:
+      query-name: "nas.lab;id"
+      query-type: "A|sh"
+`
+	checks, _, err := ImportGatus([]byte(data))
+	if err != nil {
+		t.Fatalf("ImportGatus() error = %v", err)
+	}
+	want := []string{
+		"nc -z -w 5 'db.lab;touch$HOME' 5432 || exit 1",
+		"ping -c 1 -W 2 '$(reboot)' || exit 1",
+		"dig @10.0.0.53 'nas.lab;id' 'A|sh' +noall +comments +answer || exit 1",
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("ImportGatus() returned %d checks, want %d", len(checks), len(want))
+	}
+	for i, c := range checks {
+		if c.Command != want[i] {
+			t.Errorf("%s command = %q, want %q", c.Name, c.Command, want[i])
+		}
+	}
+}
+
+func TestImportGatusInvalid(t *testing.T) {
+	for _, data := range []string{"endpoints: {", "endpoints: []"} {
+		if _, _, err := ImportGatus([]byte(data)); err == nil {
+			t.Errorf("ImportGatus(%q) expected error", data)
+		}
+	}
+}
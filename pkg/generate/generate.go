@@ -0,0 +1,224 @@
+// Package generate builds starter check configurations from existing
+// sources, such as a cluster's inventory.
+package generate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	osexec "os/exec"
+	"sort"
+	"strings"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/validate"
+	"gopkg.in/yaml.v3"
+)
+
+// Layers for generated checks, following the smoke test pyramid.
+const (
+	layerStorage   = 1
+	layerWorkloads = 3
+	layerEndpoints = 5
+)
+
+// certExpiryWindow is how soon (in seconds) a certificate may expire
+// before its check fails, passed to openssl x509 -checkend.
+const certExpiryWindow = 7 * 24 * 60 * 60
+
+// kubectl is the templated kubectl prefix used in generated commands, so
+// they honor the runner's -context flag.
+const kubectl = "kubectl{{if .Context}} --context {{quote .Context}}{{end}}"
+
+// Resource identifies a namespaced Kubernetes object.
+type Resource struct {
+	Namespace string
+	Name      string
+}
+
+// String returns the resource as namespace/name.
+func (r Resource) String() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// Host is an ingress hostname and whether it is served over TLS.
+type Host struct {
+	Name string
+	TLS  bool
+}
+
+// Inventory is the set of cluster objects checks are generated for.
+type Inventory struct {
+	Deployments []Resource
+	PVCs        []Resource
+	CronJobs    []Resource
+	Hosts       []Host
+}
+
+// kubeList is the subset of a `kubectl get -o json` list that is used.
+type kubeList struct {
+	Items []struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Rules []struct {
+				Host string `json:"host"`
+			} `json:"rules"`
+			TLS []struct {
+				Hosts []string `json:"hosts"`
+			} `json:"tls"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// ParseInventory builds an Inventory from `kubectl get -o json` output.
+// Objects of other kinds are ignored; results are sorted and hosts
+// deduplicated so the generated file is stable.
+func ParseInventory(data []byte) (*Inventory, error) {
+	var list kubeList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse kubectl output: %w", err)
+	}
+
+	inv := &Inventory{}
+	hosts := make(map[string]bool)
+	for _, item := range list.Items {
+		res := Resource{Namespace: item.Metadata.Namespace, Name: item.Metadata.Name}
+		switch item.Kind {
+		case "Deployment":
+			inv.Deployments = append(inv.Deployments, res)
+		case "PersistentVolumeClaim":
+			inv.PVCs = append(inv.PVCs, res)
+		case "CronJob":
+			inv.CronJobs = append(inv.CronJobs, res)
+		case "Ingress":
+			tlsHosts := make(map[string]bool)
+			for _, t := range item.Spec.TLS {
+				for _, h := range t.Hosts {
+					tlsHosts[h] = true
+				}
+			}
+			for _, rule := range item.Spec.Rules {
+				// Wildcards and host-less rules have no single URL to probe
+				if rule.Host == "" || strings.HasPrefix(rule.Host, "*") {
+					continue
+				}
+				hosts[rule.Host] = hosts[rule.Host] || tlsHosts[rule.Host]
+			}
+		}
+	}
+
+	for name, tls := range hosts {
+		inv.Hosts = append(inv.Hosts, Host{Name: name, TLS: tls})
+	}
+	sort.Slice(inv.Hosts, func(i, j int) bool { return inv.Hosts[i].Name < inv.Hosts[j].Name })
+	for _, list := range [][]Resource{inv.Deployments, inv.PVCs, inv.CronJobs} {
+		sort.Slice(list, func(i, j int) bool { return list[i].String() < list[j].String() })
+	}
+	return inv, nil
+}
+
+// LoadInventory lists deployments, ingresses, PVCs, and cronjobs with
+// kubectl, in one namespace or across all namespaces if namespace is empty.
+func LoadInventory(ctx context.Context, kubeContext, namespace string) (*Inventory, error) {
+	args := []string{"get", "deployments,ingresses,persistentvolumeclaims,cronjobs", "-o", "json"}
+	if kubeContext != "" {
+		args = append(args, "--context", kubeContext)
+	}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := osexec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("kubectl get failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ParseInventory(stdout.Bytes())
+}
+
+// Checks returns starter checks for an inventory: PVCs bound, deployments
+// rolled out, cronjobs succeeding, and ingress hosts responding with valid
+// certificates.
+func Checks(inv *Inventory) []config.Check {
+	var checks []config.Check
+	nonGating := false
+
+	for _, pvc := range inv.PVCs {
+		checks = append(checks, config.Check{
+			Name:     fmt.Sprintf("PVC %s Bound", pvc),
+			Layer:    layerStorage,
+			Command:  fmt.Sprintf("%s -n %s get pvc %s -o jsonpath='{.status.phase}'", kubectl, pvc.Namespace, pvc.Name),
+			Validate: &validate.Validation{Contains: "Bound"},
+		})
+	}
+
+	for _, d := range inv.Deployments {
+		checks = append(checks, config.Check{
+			Name:    fmt.Sprintf("Deployment %s Rolled Out", d),
+			Layer:   layerWorkloads,
+			Command: fmt.Sprintf("%s -n %s rollout status deployment/%s --timeout=20s", kubectl, d.Namespace, d.Name),
+		})
+	}
+
+	for _, cj := range inv.CronJobs {
+		checks = append(checks, config.Check{
+			Name:     fmt.Sprintf("CronJob %s Has Succeeded", cj),
+			Layer:    layerWorkloads,
+			Command:  fmt.Sprintf("%s -n %s get cronjob %s -o jsonpath='{.status.lastSuccessfulTime}'", kubectl, cj.Namespace, cj.Name),
+			Validate: &validate.Validation{Regex: `^\d{4}-\d{2}-\d{2}T`},
+			Expect:   &config.ExpectConfig{Gating: &nonGating},
+		})
+	}
+
+	for _, h := range inv.Hosts {
+		scheme := "http"
+		if h.TLS {
+			scheme = "https"
+		}
+		// Auth-protected apps answer 401/403, which still proves they're up
+		checks = append(checks, config.Check{
+			Name:     fmt.Sprintf("Ingress %s Responds", h.Name),
+			Layer:    layerEndpoints,
+			Command:  fmt.Sprintf("curl -sS -o /dev/null -w '%%{http_code}' --max-time 10 %s://%s/", scheme, h.Name),
+			Validate: &validate.Validation{Regex: `^([23]\d\d|401|403)$`},
+		})
+		if h.TLS {
+			checks = append(checks, config.Check{
+				Name:    fmt.Sprintf("Certificate %s Valid", h.Name),
+				Layer:   layerEndpoints,
+				Command: fmt.Sprintf("echo | openssl s_client -servername %s -connect %s:443 2>/dev/null | openssl x509 -noout -checkend %d", h.Name, h.Name, certExpiryWindow),
+				Expect:  &config.ExpectConfig{Gating: &nonGating},
+			})
+		}
+	}
+
+	return checks
+}
+
+// Write renders checks as a checks.yaml document with a header comment.
+func Write(w io.Writer, header string, checks []config.Check) error {
+	var buf bytes.Buffer
+	for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+		_, _ = fmt.Fprintf(&buf, "%s\n", strings.TrimRight("# "+line, " "))
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&config.Config{APIVersion: config.APIVersion, Checks: checks}); err != nil {
+		return fmt.Errorf("failed to encode checks: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode checks: %w", err)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,106 @@
+package generate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+const kubectlOutput = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"kind": "Deployment", "metadata": {"name": "sonarr", "namespace": "media"}},
+    {"kind": "Deployment", "metadata": {"name": "jellyfin", "namespace": "media"}},
+    {"kind": "PersistentVolumeClaim", "metadata": {"name": "jellyfin-config", "namespace": "media"}},
+    {"kind": "CronJob", "metadata": {"name": "restic", "namespace": "backup"}},
+    {"kind": "Ingress", "metadata": {"name": "jellyfin", "namespace": "media"},
+     "spec": {"rules": [{"host": "jellyfin.lab"}, {"host": "*.lab"}, {}], "tls": [{"hosts": ["jellyfin.lab"]}]}},
+    {"kind": "Ingress", "metadata": {"name": "jellyfin-alt", "namespace": "media"},
+     "spec": {"rules": [{"host": "jellyfin.lab"}, {"host": "old.lab"}]}},
+    {"kind": "Service", "metadata": {"name": "jellyfin", "namespace": "media"}}
+  ]
+}`
+
+func TestParseInventory(t *testing.T) {
+	inv, err := ParseInventory([]byte(kubectlOutput))
+	if err != nil {
+		t.Fatalf("ParseInventory() error = %v", err)
+	}
+
+	if len(inv.Deployments) != 2 || inv.Deployments[0].String() != "media/jellyfin" {
+		t.Errorf("Deployments = %v, want sorted [media/jellyfin media/sonarr]", inv.Deployments)
+	}
+	if len(inv.PVCs) != 1 || len(inv.CronJobs) != 1 {
+		t.Errorf("PVCs = %v, CronJobs = %v, want one each", inv.PVCs, inv.CronJobs)
+	}
+
+	wantHosts := []Host{{Name: "jellyfin.lab", TLS: true}, {Name: "old.lab", TLS: false}}
+	if len(inv.Hosts) != len(wantHosts) {
+		t.Fatalf("Hosts = %v, want %v", inv.Hosts, wantHosts)
+	}
+	for i, h := range wantHosts {
+		if inv.Hosts[i] != h {
+			t.Errorf("Hosts[%d] = %v, want %v", i, inv.Hosts[i], h)
+		}
+	}
+
+	if _, err := ParseInventory([]byte("not json")); err == nil {
+		t.Error("ParseInventory() expected error for invalid JSON")
+	}
+}
+
+func TestChecksRoundTrip(t *testing.T) {
+	inv, err := ParseInventory([]byte(kubectlOutput))
+	if err != nil {
+		t.Fatalf("ParseInventory() error = %v", err)
+	}
+	checks := Checks(inv)
+
+	// pvc + 2 deployments + cronjob + 2 ingress hosts + 1 certificate
+	if len(checks) != 7 {
+		t.Fatalf("Checks() returned %d checks, want 7", len(checks))
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "Generated for tests.", checks); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "# Generated for tests.\n") {
+		t.Errorf("Write() missing header comment:\n%s", buf.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "checks.yaml")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadConfigStrict(path)
+	if err != nil {
+		t.Fatalf("LoadConfigStrict() error = %v\n%s", err, buf.String())
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(cfg.Checks) != len(checks) {
+		t.Errorf("round trip has %d checks, want %d", len(cfg.Checks), len(checks))
+	}
+
+	// Commands are templates that must render with and without a context
+	for _, vars := range []config.TemplateVars{{}, {Context: "home-admin"}} {
+		templated, err := config.ApplyTemplateToCheck(&cfg.Checks[1], vars)
+		if err != nil {
+			t.Fatalf("ApplyTemplateToCheck() error = %v", err)
+		}
+		want := "kubectl -n media rollout status deployment/jellyfin --timeout=20s"
+		if vars.Context != "" {
+			want = "kubectl --context home-admin -n media rollout status deployment/jellyfin --timeout=20s"
+		}
+		if templated.Command != want {
+			t.Errorf("Command = %q, want %q", templated.Command, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// starterChecks is the checks.yaml written by Scaffold.
+const starterChecks = `# Smoke checks: "is the system fundamentally working?"
+#
+# Checks run in layer order and stop at the first gating failure. Commands
+# and scripts report through their exit code:
+#   0 PASS, 1 FAIL, 2 ERROR, 3 SKIP, 4 WARN
+#
+# Try it:  smoke validate -checks checks.yaml && smoke -checks checks.yaml -v
+
+apiVersion: smoke/v1
+
+defaults:
+  timeout: 30s
+
+checks:
+  # Layer 1: infrastructure foundation - if this fails, nothing else matters
+  - name: "API Server Ready"
+    layer: 1
+    command: "kubectl{{if .Context}} --context {{quote .Context}}{{end}} get --raw /readyz"
+    validate:
+      contains: "ok"
+
+  # Layer 3: core services
+  - name: "CoreDNS Rolled Out"
+    layer: 3
+    command: "kubectl{{if .Context}} --context {{quote .Context}}{{end}} -n kube-system rollout status deployment/coredns --timeout=20s"
+    retry: true
+
+  # Layer 5: applications, via a script in checks/
+  - name: "Example App Responds"
+    layer: 5
+    script:
+      path: checks/http-ok.sh
+      args: ["https://example.com/"]
+    validate:
+      regex: "^HTTP [23][0-9][0-9]$"
+    expect:
+      gating: false   # report, but don't block rollouts
+`
+
+// starterScript is the example check script written by Scaffold.
+const starterScript = `#!/bin/sh
+# Example smoke check: does a URL answer with a non-error status?
+#
+# Exit code contract:
+#   0 PASS   the thing works
+#   1 FAIL   the thing is broken (retried if retry: true)
+#   2 ERROR  the check itself couldn't run (missing tool, bad args)
+#   3 SKIP   not applicable here
+#   4 WARN   works, but degraded
+#
+# Usage: http-ok.sh URL
+set -u
+
+url="${1:-}"
+if [ -z "$url" ]; then
+  echo "usage: $0 URL"
+  exit 2
+fi
+if ! command -v curl >/dev/null 2>&1; then
+  echo "curl not installed"
+  exit 2
+fi
+
+code=$(curl -sS -o /dev/null -w '%{http_code}' --max-time 10 "$url") || {
+  echo "request to $url failed"
+  exit 1
+}
+echo "HTTP $code"
+
+case "$code" in
+  2??|3??) exit 0 ;;
+  *) exit 1 ;;
+esac
+`
+
+// Scaffold writes a starter checks.yaml and checks/http-ok.sh into dir,
+// refusing to overwrite existing files. Returns the paths written.
+func Scaffold(dir string) ([]string, error) {
+	files := []struct {
+		path    string
+		content string
+		mode    os.FileMode
+	}{
+		{filepath.Join(dir, "checks.yaml"), starterChecks, 0o644},
+		{filepath.Join(dir, "checks", "http-ok.sh"), starterScript, 0o755},
+	}
+
+	for _, f := range files {
+		if _, err := os.Stat(f.path); err == nil {
+			return nil, fmt.Errorf("%s already exists", f.path)
+		}
+	}
+
+	var written []string
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(f.path, []byte(f.content), f.mode); err != nil { //nolint:gosec // Script must be executable
+			return written, err
+		}
+		written = append(written, f.path)
+	}
+	return written, nil
+}
@@ -0,0 +1,48 @@
+package generate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+func TestScaffold(t *testing.T) {
+	dir := t.TempDir()
+
+	written, err := Scaffold(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("expected 2 files, got %v", written)
+	}
+
+	// The starter config is clean, including its script reference
+	problems, err := config.Lint(filepath.Join(dir, "checks.yaml"), config.TemplateVars{Context: "home-admin"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected starter config to lint clean, got %v", problems)
+	}
+
+	// The starter script honors the contract for bad usage
+	script := filepath.Join(dir, "checks", "http-ok.sh")
+	if res := exec.RunCommand(context.Background(), script, 5*time.Second); res.ExitCode != 2 || !strings.Contains(res.Output, "usage") {
+		t.Errorf("expected usage error (exit 2), got exit %d: %s", res.ExitCode, res.Output)
+	}
+
+	// Existing files are never overwritten
+	if err := os.WriteFile(filepath.Join(dir, "checks.yaml"), []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Scaffold(dir); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/validate"
+)
+
+// kumaBackup is the subset of an Uptime Kuma backup file that is imported.
+type kumaBackup struct {
+	MonitorList []kumaMonitor `json:"monitorList"`
+}
+
+// kumaMonitor is a single Uptime Kuma monitor.
+type kumaMonitor struct {
+	Name                string    `json:"name"`
+	Type                string    `json:"type"`
+	Active              *kumaBool `json:"active"`
+	URL                 string    `json:"url"`
+	Method              string    `json:"method"`
+	Hostname            string    `json:"hostname"`
+	Port                int       `json:"port"`
+	Keyword             string    `json:"keyword"`
+	InvertKeyword       kumaBool  `json:"invertKeyword"`
+	IgnoreTLS           kumaBool  `json:"ignoreTls"`
+	UpsideDown          kumaBool  `json:"upsideDown"`
+	MaxRetries          int       `json:"maxretries"`
+	AcceptedStatusCodes []string  `json:"accepted_statuscodes"`
+	DNSResolveType      string    `json:"dns_resolve_type"`
+	DNSResolveServer    string    `json:"dns_resolve_server"`
+	Tags                []kumaTag `json:"tags"`
+}
+
+// kumaTag is a tag attached to a monitor.
+type kumaTag struct {
+	Name string `json:"name"`
+}
+
+// kumaBool is a boolean that older Uptime Kuma versions export as 0/1.
+type kumaBool bool
+
+// UnmarshalJSON implements json.Unmarshaler for kumaBool.
+func (b *kumaBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// kumaStatusRange matches an accepted status code range like "200-299".
+var kumaStatusRange = regexp.MustCompile(`^([1-5])00-([1-5])99$`)
+
+// ImportUptimeKuma translates the monitors of an Uptime Kuma backup (JSON
+// export) into checks: http and keyword monitors use curl, port uses nc,
+// ping uses ping, and dns uses dig. Other monitor types and settings that
+// can't be expressed are dropped and described in the returned warnings.
+func ImportUptimeKuma(data []byte) ([]config.Check, []string, error) {
+	var backup kumaBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse uptime kuma backup: %w", err)
+	}
+	if len(backup.MonitorList) == 0 {
+		return nil, nil, fmt.Errorf("uptime kuma backup has no monitors")
+	}
+
+	var checks []config.Check
+	var warnings []string
+	for _, m := range backup.MonitorList {
+		warn := func(format string, args ...any) {
+			warnings = append(warnings, m.Name+": "+fmt.Sprintf(format, args...))
+		}
+
+		check, err := kumaCheck(m, warn)
+		if err != nil {
+			warn("skipped: %v", err)
+			continue
+		}
+		check.Name = m.Name
+		for _, tag := range m.Tags {
+			check.Tags = append(check.Tags, tag.Name)
+		}
+		if m.Active != nil && !*m.Active {
+			check.Disabled = &config.Disabled{Reason: "paused in uptime kuma"}
+		}
+		if m.UpsideDown {
+			check.Expect = &config.ExpectConfig{Outcome: config.ExpectOutcomeFail}
+		}
+		if m.MaxRetries > 0 {
+			retries := m.MaxRetries
+			check.Retry = &config.RetryConfig{Max: &retries}
+		}
+		checks = append(checks, check)
+	}
+	return checks, warnings, nil
+}
+
+// kumaCheck builds the check for one monitor (without name, tags, or state).
+func kumaCheck(m kumaMonitor, warn func(string, ...any)) (config.Check, error) {
+	switch m.Type {
+	case "http", "keyword":
+		if m.URL == "" {
+			return config.Check{}, fmt.Errorf("%s monitor missing url", m.Type)
+		}
+		return kumaHTTPCheck(m, warn), nil
+	case "port":
+		if m.Hostname == "" || m.Port == 0 {
+			return config.Check{}, fmt.Errorf("port monitor missing hostname or port")
+		}
+		return config.Check{Command: tcpCommand(m.Hostname, strconv.Itoa(m.Port))}, nil
+	case "ping":
+		if m.Hostname == "" {
+			return config.Check{}, fmt.Errorf("ping monitor missing hostname")
+		}
+		return config.Check{Command: pingCommand(m.Hostname)}, nil
+	case "dns":
+		if m.Hostname == "" || m.DNSResolveServer == "" {
+			return config.Check{}, fmt.Errorf("dns monitor missing hostname or resolver")
+		}
+		queryType := m.DNSResolveType
+		if queryType == "" {
+			queryType = "A"
+		}
+		return config.Check{
+			Command:  digCommand(m.DNSResolveServer, m.Hostname, queryType),
+			Validate: &validate.Validation{Contains: "status: NOERROR"},
+		}, nil
+	default:
+		return config.Check{}, fmt.Errorf("unsupported monitor type %q", m.Type)
+	}
+}
+
+// kumaHTTPCheck builds a curl check validating the accepted status codes
+// and, for keyword monitors, the keyword.
+func kumaHTTPCheck(m kumaMonitor, warn func(string, ...any)) config.Check {
+	v := &validate.Validation{}
+
+	codes := m.AcceptedStatusCodes
+	if len(codes) == 0 {
+		codes = []string{"200-299"}
+	}
+	var patterns []string
+	for _, code := range codes {
+		if match := kumaStatusRange.FindStringSubmatch(code); match != nil && match[1] <= match[2] {
+			if match[1] == match[2] {
+				patterns = append(patterns, match[1]+`\d\d`)
+			} else {
+				patterns = append(patterns, "["+match[1]+"-"+match[2]+`]\d\d`)
+			}
+		} else if _, err := strconv.Atoi(code); err == nil && len(code) == 3 {
+			patterns = append(patterns, code)
+		} else {
+			warn("dropped accepted status code %q (only NNN and N00-N99 are supported)", code)
+		}
+	}
+	if len(patterns) == 1 {
+		v.Regex = httpStatusMarker + patterns[0] + "$"
+	} else if len(patterns) > 1 {
+		v.Regex = httpStatusMarker + "(" + strings.Join(patterns, "|") + ")$"
+	}
+
+	if m.Type == "keyword" && m.Keyword != "" {
+		if m.InvertKeyword {
+			v.NotContains = m.Keyword
+		} else {
+			v.Contains = m.Keyword
+		}
+	}
+
+	check := config.Check{Command: curlCommand(m.URL, m.Method, bool(m.IgnoreTLS))}
+	if !v.IsEmpty() {
+		check.Validate = v
+	}
+	return check
+}
@@ -0,0 +1,119 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+)
+
+const uptimeKumaBackupJSON = `{
+  "version": "1.23.0",
+  "notificationList": [],
+  "monitorList": [
+    {"name": "jellyfin", "type": "keyword", "active": 1, "url": "https://jellyfin.lab/health",
+     "method": "GET", "keyword": "Healthy", "invertKeyword": false, "ignoreTls": 1,
+     "maxretries": 2, "accepted_statuscodes": ["200-299"], "tags": [{"name": "media"}]},
+    {"name": "api", "type": "http", "active": true, "url": "https://api.lab/",
+     "method": "HEAD", "accepted_statuscodes": ["200-399", "401", "2xx"]},
+    {"name": "postgres", "type": "port", "active": true, "hostname": "db.lab", "port": 5432},
+    {"name": "router", "type": "ping", "active": false, "hostname": "10.0.0.1"},
+    {"name": "resolver", "type": "dns", "hostname": "nas.lab",
+     "dns_resolve_server": "10.0.0.53", "dns_resolve_type": "AAAA"},
+    {"name": "old-host", "type": "ping", "hostname": "old.lab", "upsideDown": true},
+    {"name": "backup-job", "type": "push"}
+  ]
+}`
+
+func TestImportUptimeKuma(t *testing.T) {
+	checks, warnings, err := ImportUptimeKuma([]byte(uptimeKumaBackupJSON))
+	if err != nil {
+		t.Fatalf("ImportUptimeKuma() error = %v", err)
+	}
+
+	if len(checks) != 6 {
+		t.Fatalf("ImportUptimeKuma() returned %d checks, want 6", len(checks))
+	}
+	byName := make(map[string]int)
+	for i, c := range checks {
+		byName[c.Name] = i
+	}
+
+	jellyfin := checks[byName["jellyfin"]]
+	if jellyfin.Command != `curl -sS --max-time 10 -k -w '\nHTTP_STATUS=%{http_code}' https://jellyfin.lab/health || exit 1` {
+		t.Errorf("jellyfin command = %q", jellyfin.Command)
+	}
+	if jellyfin.Validate == nil || jellyfin.Validate.Regex != `HTTP_STATUS=2\d\d$` || jellyfin.Validate.Contains != "Healthy" {
+		t.Errorf("jellyfin validate = %+v", jellyfin.Validate)
+	}
+	if len(jellyfin.Tags) != 1 || jellyfin.Tags[0] != "media" {
+		t.Errorf("jellyfin tags = %v, want [media]", jellyfin.Tags)
+	}
+	if jellyfin.Retry == nil || jellyfin.Retry.Max == nil || *jellyfin.Retry.Max != 2 {
+		t.Errorf("jellyfin retry = %+v, want max 2", jellyfin.Retry)
+	}
+
+	api := checks[byName["api"]]
+	if !strings.Contains(api.Command, " -I ") {
+		t.Errorf("api command = %q, want -I for HEAD", api.Command)
+	}
+	if api.Validate == nil || api.Validate.Regex != `HTTP_STATUS=([2-3]\d\d|401)$` {
+		t.Errorf("api validate = %+v", api.Validate)
+	}
+
+	if pg := checks[byName["postgres"]]; pg.Command != "nc -z -w 5 db.lab 5432 || exit 1" {
+		t.Errorf("postgres command = %q", pg.Command)
+	}
+	if router := checks[byName["router"]]; router.Disabled == nil || router.Command != "ping -c 1 -W 2 10.0.0.1 || exit 1" {
+		t.Errorf("router = %+v, want disabled ping check", router)
+	}
+	resolver := checks[byName["resolver"]]
+	if resolver.Command != "dig @10.0.0.53 nas.lab AAAA +noall +comments +answer || exit 1" {
+		t.Errorf("resolver command = %q", resolver.Command)
+	}
+	if resolver.Validate == nil || resolver.Validate.Contains != "status: NOERROR" {
+		t.Errorf("resolver validate = %+v", resolver.Validate)
+	}
+	if old := checks[byName["old-host"]]; !old.IsNegative() {
+		t.Errorf("old-host expect = %+v, want negative check", old.Expect)
+	}
+
+	wantWarnings := []string{`api: dropped accepted status code "2xx"`, "backup-job: skipped"}
+	if len(warnings) != len(wantWarnings) {
+		t.Fatalf("warnings = %v, want %d", warnings, len(wantWarnings))
+	}
+	for i, want := range wantWarnings {
+		if !strings.Contains(warnings[i], want) {
+			t.Errorf("warnings[%d] = %q, want substring %q", i, warnings[i], want)
+		}
+	}
+}
+
+func TestImportUptimeKumaQuotesValues(t *testing.T) {
+	data := `{"monitorList": [
+	  {"name": "port", "type": "port", "hostname": "db.lab;reboot", "port": 22},
+	  {"name": "dns", "type": "dns", "hostname": "$(id)", "dns_resolve_server": "1.1.1.1", "dns_resolve_type": "A"}
+	]}`
+	checks, _, err := ImportUptimeKuma([]byte(data))
+	if err != nil {
+		t.Fatalf("ImportUptimeKuma() error = %v", err)
+	}
+	want := []string{
+		"nc -z -w 5 'db.lab;reboot' 22 || exit 1",
+		"dig @1.1.1.1 '$(id)' A +noall +comments +answer || exit 1",
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("ImportUptimeKuma() returned %d checks, want %d", len(checks), len(want))
+	}
+	for i, c := range checks {
+		if c.Command != want[i] {
+			t.Errorf("%s command = %q, want %q", c.Name, c.Command, want[i])
+		}
+	}
+}
+
+func TestImportUptimeKumaInvalid(t *testing.T) {
+	for _, data := range []string{`{"monitorList": [`, `{"monitorList": []}`, `{"monitorList": [{"active": "yes"}]}`} {
+		if _, _, err := ImportUptimeKuma([]byte(data)); err == nil {
+			t.Errorf("ImportUptimeKuma(%q) expected error", data)
+		}
+	}
+}
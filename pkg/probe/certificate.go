@@ -0,0 +1,127 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// certificateList is the subset of a cert-manager Certificate list used.
+type certificateList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Status struct {
+			NotAfter   *time.Time `json:"notAfter"`
+			Conditions []struct {
+				Type    string `json:"type"`
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// Certificates lists cert-manager Certificates with kubectl and checks that
+// each is Ready and valid for at least min_valid_days. Any failing
+// certificate, or none matching, is FAIL; kubectl errors are ERROR.
+func Certificates(ctx context.Context, spec *config.CertificateConfig, kubeContext string, timeout time.Duration, opts exec.Options) exec.CommandResult {
+	res := exec.RunCommandOpts(ctx, certificatesCommand(spec, kubeContext), timeout, opts)
+	if res.Error != nil {
+		return res
+	}
+	if res.ExitCode != 0 {
+		return errorResult(engine.ExitError, "kubectl get certificates failed (exit %d): %s", res.ExitCode, strings.TrimSpace(res.Output))
+	}
+
+	// Output is combined, so skip any kubectl warnings before the JSON
+	data := res.Output
+	if i := strings.Index(data, "{"); i > 0 {
+		data = data[i:]
+	}
+	return evaluateCertificates([]byte(data), spec.GetMinValidDays(), time.Now())
+}
+
+// certificatesCommand builds the kubectl command listing certificates.
+func certificatesCommand(spec *config.CertificateConfig, kubeContext string) string {
+	args := []string{"kubectl"}
+	if kubeContext != "" {
+		args = append(args, "--context", exec.ShellQuote(kubeContext))
+	}
+	args = append(args, "get", "certificates.cert-manager.io", "-o", "json")
+	if spec.Namespace != "" {
+		args = append(args, "-n", exec.ShellQuote(spec.Namespace))
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	if spec.Selector != "" {
+		args = append(args, "-l", exec.ShellQuote(spec.Selector))
+	}
+	return strings.Join(args, " ")
+}
+
+// evaluateCertificates checks a certificate list at the given time.
+func evaluateCertificates(data []byte, minValidDays int, now time.Time) exec.CommandResult {
+	var list certificateList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errorResult(engine.ExitError, "failed to parse certificates: %v", err)
+	}
+	if len(list.Items) == 0 {
+		return errorResult(engine.ExitFail, "no certificates matched")
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, b := list.Items[i].Metadata, list.Items[j].Metadata
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	var out strings.Builder
+	failed := 0
+	for _, cert := range list.Items {
+		name := cert.Metadata.Namespace + "/" + cert.Metadata.Name
+
+		ready, message := false, "no Ready condition"
+		for _, c := range cert.Status.Conditions {
+			if c.Type == "Ready" {
+				ready, message = c.Status == "True", c.Message
+			}
+		}
+
+		var problems []string
+		if !ready {
+			problems = append(problems, "not Ready: "+message)
+		}
+		expiry := "expiry unknown"
+		if notAfter := cert.Status.NotAfter; notAfter != nil {
+			days := int(notAfter.Sub(now).Hours() / 24)
+			expiry = fmt.Sprintf("expires %s (%d days)", notAfter.Format("2006-01-02"), days)
+			if days < minValidDays {
+				problems = append(problems, fmt.Sprintf("valid for fewer than %d days", minValidDays))
+			}
+		} else if ready {
+			problems = append(problems, "no notAfter in status")
+		}
+
+		if len(problems) > 0 {
+			failed++
+			_, _ = fmt.Fprintf(&out, "FAIL %s: %s, %s\n", name, strings.Join(problems, "; "), expiry)
+		} else {
+			_, _ = fmt.Fprintf(&out, "ok   %s: Ready, %s\n", name, expiry)
+		}
+	}
+
+	_, _ = fmt.Fprintf(&out, "%d/%d certificates healthy\n", len(list.Items)-failed, len(list.Items))
+	if failed > 0 {
+		return exec.CommandResult{Output: out.String(), ExitCode: engine.ExitFail}
+	}
+	return exec.CommandResult{Output: out.String()}
+}
@@ -0,0 +1,108 @@
+package probe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+const certificatesJSON = `{
+  "items": [
+    {"metadata": {"name": "wildcard", "namespace": "gateway"},
+     "status": {"notAfter": "2026-12-15T00:00:00Z", "conditions": [{"type": "Ready", "status": "True"}]}},
+    {"metadata": {"name": "grafana", "namespace": "monitoring"},
+     "status": {"notAfter": "2026-10-20T00:00:00Z", "conditions": [{"type": "Ready", "status": "True"}]}},
+    {"metadata": {"name": "argocd", "namespace": "argocd"},
+     "status": {"conditions": [{"type": "Ready", "status": "False", "message": "Issuing certificate as Secret does not exist"}]}}
+  ]
+}`
+
+func TestEvaluateCertificates(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		data         string
+		minValidDays int
+		wantExit     int
+		wantOutput   []string
+	}{
+		{
+			name:         "mixed health",
+			data:         certificatesJSON,
+			minValidDays: 14,
+			wantExit:     1,
+			wantOutput: []string{
+				"FAIL argocd/argocd: not Ready: Issuing certificate as Secret does not exist, expiry unknown",
+				"ok   gateway/wildcard: Ready, expires 2026-12-15 (60 days)",
+				"FAIL monitoring/grafana: valid for fewer than 14 days, expires 2026-10-20 (4 days)",
+				"1/3 certificates healthy",
+			},
+		},
+		{
+			name:         "all healthy",
+			data:         `{"items": [{"metadata": {"name": "wildcard", "namespace": "gateway"}, "status": {"notAfter": "2026-12-15T00:00:00Z", "conditions": [{"type": "Ready", "status": "True"}]}}]}`,
+			minValidDays: 14,
+			wantExit:     0,
+			wantOutput:   []string{"1/1 certificates healthy"},
+		},
+		{
+			name:         "none matched",
+			data:         `{"items": []}`,
+			minValidDays: 14,
+			wantExit:     1,
+			wantOutput:   []string{"no certificates matched"},
+		},
+		{
+			name:         "invalid json",
+			data:         `error: the server doesn't have a resource type`,
+			minValidDays: 14,
+			wantExit:     2,
+			wantOutput:   []string{"failed to parse certificates"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateCertificates([]byte(tt.data), tt.minValidDays, now)
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("exit = %d, want %d (output: %s)", result.ExitCode, tt.wantExit, result.Output)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(result.Output, want) {
+					t.Errorf("output = %q, want substring %q", result.Output, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCertificatesCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        config.CertificateConfig
+		kubeContext string
+		want        string
+	}{
+		{
+			name: "all namespaces",
+			want: "kubectl get certificates.cert-manager.io -o json --all-namespaces",
+		},
+		{
+			name:        "namespace, selector, and context",
+			spec:        config.CertificateConfig{Namespace: "gateway", Selector: "tier=edge"},
+			kubeContext: "home-admin",
+			want:        "kubectl --context home-admin get certificates.cert-manager.io -o json -n gateway -l tier=edge",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certificatesCommand(&tt.spec, tt.kubeContext); got != tt.want {
+				t.Errorf("certificatesCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
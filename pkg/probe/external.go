@@ -0,0 +1,209 @@
+package probe
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// maxCompareBody bounds how much of a response is hashed.
+const maxCompareBody = 10 << 20
+
+// External resolves an externally published host against a public
+// resolver, checks its CNAME and addresses against the expectations, and
+// compares the content served at the public address with the internal
+// URL's. Any mismatch or an unresolvable host is FAIL, an unreachable
+// internal URL is ERROR. Each step is reported on its own line.
+func External(ctx context.Context, spec *config.ExternalConfig, timeout time.Duration) exec.CommandResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, spec.GetResolver())
+		},
+	}
+
+	var out strings.Builder
+	failed := false
+	report := func(ok bool, format string, args ...any) {
+		status := "ok  "
+		if !ok {
+			status = "FAIL"
+			failed = true
+		}
+		_, _ = fmt.Fprintf(&out, "%s "+format+"\n", append([]any{status}, args...)...)
+	}
+
+	// DNS: canonical name
+	if spec.ExpectCNAME != "" {
+		cname, err := resolver.LookupCNAME(ctx, spec.Host)
+		switch {
+		case err != nil:
+			report(false, "cname: %v", err)
+		case !hasDomainSuffix(cname, spec.ExpectCNAME):
+			report(false, "cname: %s is %s, expected *%s", spec.Host, strings.TrimSuffix(cname, "."), spec.ExpectCNAME)
+		default:
+			report(true, "cname: %s is %s", spec.Host, strings.TrimSuffix(cname, "."))
+		}
+	}
+
+	// DNS: addresses
+	addrs, err := resolver.LookupIPAddr(ctx, spec.Host)
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("no addresses")
+	}
+	if err != nil {
+		report(false, "resolve %s via %s: %v", spec.Host, spec.GetResolver(), err)
+		return exec.CommandResult{Output: out.String(), ExitCode: engine.ExitFail}
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, a := range addrs {
+		ips[i] = a.IP
+	}
+	if len(spec.ExpectAddresses) > 0 {
+		if unexpected := unexpectedAddresses(ips, spec.ExpectAddresses); len(unexpected) > 0 {
+			report(false, "addresses: %s not in %s", joinIPs(unexpected), strings.Join(spec.ExpectAddresses, ", "))
+		} else {
+			report(true, "addresses: %s", joinIPs(ips))
+		}
+	}
+
+	// Content: external (via the public address) vs internal
+	if spec.Internal != "" {
+		line, exitCode := compareContent(ctx, spec, net.JoinHostPort(ips[0].String(), "443"))
+		report(exitCode == engine.ExitPass, "%s", line)
+		if exitCode == engine.ExitError {
+			return exec.CommandResult{Output: out.String(), ExitCode: exitCode}
+		}
+	}
+
+	if failed {
+		return exec.CommandResult{Output: out.String(), ExitCode: engine.ExitFail}
+	}
+	return exec.CommandResult{Output: out.String()}
+}
+
+// compareContent fetches the path from the internal URL and, through
+// dialAddr, from https://<host>, and compares their content hashes.
+// Returns the result line and its exit code: ERROR if the internal URL
+// fails, as there's nothing to compare against.
+func compareContent(ctx context.Context, spec *config.ExternalConfig, dialAddr string) (string, int) {
+	path := spec.GetPath()
+	internalURL := strings.TrimSuffix(spec.Internal, "/") + path
+	internal, err := fetchHash(ctx, newCompareClient(spec, ""), internalURL)
+	if err != nil {
+		return fmt.Sprintf("internal %s: %v", internalURL, err), engine.ExitError
+	}
+
+	externalURL := "https://" + spec.Host + path
+	external, err := fetchHash(ctx, newCompareClient(spec, dialAddr), externalURL)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("external %s via %s: %v", externalURL, dialAddr, err), engine.ExitFail
+	case external != internal:
+		return fmt.Sprintf("content: external sha256 %s differs from internal %s", shortHash(external), shortHash(internal)), engine.ExitFail
+	default:
+		return fmt.Sprintf("content: sha256 %s matches", shortHash(external)), engine.ExitPass
+	}
+}
+
+// newCompareClient returns an HTTP client for the content comparison.
+// If dialAddr is set, every connection goes to it (keeping the URL's host
+// for SNI and the Host header), bypassing local DNS.
+func newCompareClient(spec *config.ExternalConfig, dialAddr string) *http.Client {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: spec.InsecureSkipVerify, //nolint:gosec // Opt-in for self-signed homelab endpoints
+		},
+		DisableKeepAlives: true,
+	}
+	if dialAddr != "" {
+		transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, dialAddr)
+		}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// fetchHash GETs url and returns the sha256 of the body; non-2xx responses
+// are errors.
+func fetchHash(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, io.LimitReader(resp.Body, maxCompareBody)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// unexpectedAddresses returns the ips not matching any expected IP or CIDR.
+func unexpectedAddresses(ips []net.IP, expected []string) []net.IP {
+	var unexpected []net.IP
+	for _, ip := range ips {
+		matched := false
+		for _, e := range expected {
+			if want := net.ParseIP(e); want != nil {
+				matched = want.Equal(ip)
+			} else if _, cidr, err := net.ParseCIDR(e); err == nil {
+				matched = cidr.Contains(ip)
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			unexpected = append(unexpected, ip)
+		}
+	}
+	return unexpected
+}
+
+// hasDomainSuffix reports whether name is suffix or a subdomain of it,
+// ignoring case and trailing dots.
+func hasDomainSuffix(name, suffix string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	suffix = strings.ToLower(strings.Trim(suffix, "."))
+	return name == suffix || strings.HasSuffix(name, "."+suffix)
+}
+
+// joinIPs formats ips as a comma-separated list.
+func joinIPs(ips []net.IP) string {
+	parts := make([]string, len(ips))
+	for i, ip := range ips {
+		parts[i] = ip.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// shortHash abbreviates a hex digest for output.
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
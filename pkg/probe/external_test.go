@@ -0,0 +1,88 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+)
+
+func TestUnexpectedAddresses(t *testing.T) {
+	ips := []net.IP{net.ParseIP("104.16.1.1"), net.ParseIP("203.0.113.7"), net.ParseIP("2606:4700::1")}
+
+	got := unexpectedAddresses(ips, []string{"104.16.0.0/13", "2606:4700::/32"})
+	if len(got) != 1 || !got[0].Equal(net.ParseIP("203.0.113.7")) {
+		t.Errorf("unexpected = %v, want [203.0.113.7]", got)
+	}
+	if got := unexpectedAddresses(ips[1:2], []string{"203.0.113.7"}); len(got) != 0 {
+		t.Errorf("exact IP should match, got %v", got)
+	}
+}
+
+func TestHasDomainSuffix(t *testing.T) {
+	tests := []struct {
+		name, suffix string
+		want         bool
+	}{
+		{"abc123.cfargotunnel.com.", "cfargotunnel.com", true},
+		{"ABC123.CFArgoTunnel.com", "abc123.cfargotunnel.com", true},
+		{"evilcfargotunnel.com.", "cfargotunnel.com", false},
+		{"app.example.com.", "cfargotunnel.com", false},
+	}
+	for _, tt := range tests {
+		if got := hasDomainSuffix(tt.name, tt.suffix); got != tt.want {
+			t.Errorf("hasDomainSuffix(%q, %q) = %v, want %v", tt.name, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func TestCompareContent(t *testing.T) {
+	var host string
+	external := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		_, _ = w.Write([]byte("welcome"))
+	}))
+	defer external.Close()
+	dialAddr := external.Listener.Addr().String()
+
+	same := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("welcome"))
+	}))
+	defer same.Close()
+	stale := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("welcome v2"))
+	}))
+	defer stale.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	tests := []struct {
+		name     string
+		internal string
+		wantExit int
+		wantLine string
+	}{
+		{name: "match", internal: same.URL, wantExit: engine.ExitPass, wantLine: "content: sha256"},
+		{name: "mismatch", internal: stale.URL, wantExit: engine.ExitFail, wantLine: "differs from internal"},
+		{name: "internal down", internal: down.URL, wantExit: engine.ExitError, wantLine: "HTTP 502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &config.ExternalConfig{Host: "app.example.com", Internal: tt.internal + "/", InsecureSkipVerify: true}
+			line, exitCode := compareContent(context.Background(), spec, dialAddr)
+			if exitCode != tt.wantExit || !strings.Contains(line, tt.wantLine) {
+				t.Errorf("got (%q, %d), want line containing %q and exit %d", line, exitCode, tt.wantLine, tt.wantExit)
+			}
+		})
+	}
+	if host != "app.example.com" {
+		t.Errorf("external request Host = %q, want app.example.com", host)
+	}
+}
@@ -0,0 +1,158 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// nodeList is the subset of a Node list used.
+type nodeList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			Allocatable map[string]string `json:"allocatable"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// GPUs lists nodes with kubectl and checks each advertises at least
+// min_per_node of the extended resource; with a job configured, it then
+// runs a one-off Job requesting the resource and waits for it to succeed.
+// Missing resources, no matching nodes, or a failed Job are FAIL; kubectl
+// errors are ERROR.
+func GPUs(ctx context.Context, spec *config.GPUConfig, kubeContext string, timeout time.Duration, opts exec.Options) exec.CommandResult {
+	res := exec.RunCommandOpts(ctx, nodesCommand(spec, kubeContext), timeout, opts)
+	if res.Error != nil {
+		return res
+	}
+	if res.ExitCode != 0 {
+		return errorResult(engine.ExitError, "kubectl get nodes failed (exit %d): %s", res.ExitCode, strings.TrimSpace(res.Output))
+	}
+
+	// Output is combined, so skip any kubectl warnings before the JSON
+	data := res.Output
+	if i := strings.Index(data, "{"); i > 0 {
+		data = data[i:]
+	}
+	result := evaluateGPUNodes([]byte(data), spec.GetResource(), spec.GetMinPerNode())
+	if result.ExitCode != 0 || spec.Job == nil {
+		return result
+	}
+
+	job := exec.RunCommandOpts(ctx, gpuJobCommand(spec, kubeContext, timeout), timeout, opts)
+	job.Output = result.Output + job.Output
+	return job
+}
+
+// nodesCommand builds the kubectl command listing nodes.
+func nodesCommand(spec *config.GPUConfig, kubeContext string) string {
+	args := []string{"kubectl"}
+	if kubeContext != "" {
+		args = append(args, "--context", exec.ShellQuote(kubeContext))
+	}
+	args = append(args, "get", "nodes", "-o", "json")
+	if spec.Selector != "" {
+		args = append(args, "-l", exec.ShellQuote(spec.Selector))
+	}
+	return strings.Join(args, " ")
+}
+
+// evaluateGPUNodes checks every node advertises at least min of resource.
+func evaluateGPUNodes(data []byte, resource string, min int) exec.CommandResult {
+	var list nodeList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errorResult(engine.ExitError, "failed to parse nodes: %v", err)
+	}
+	if len(list.Items) == 0 {
+		return errorResult(engine.ExitFail, "no nodes matched")
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Metadata.Name < list.Items[j].Metadata.Name
+	})
+
+	var out strings.Builder
+	failed := 0
+	for _, node := range list.Items {
+		name := node.Metadata.Name
+		quantity, ok := node.Status.Allocatable[resource]
+		count, err := strconv.Atoi(quantity)
+		switch {
+		case !ok:
+			failed++
+			_, _ = fmt.Fprintf(&out, "FAIL %s: no %s advertised\n", name, resource)
+		case err != nil || count < min:
+			failed++
+			_, _ = fmt.Fprintf(&out, "FAIL %s: %s %s allocatable, want at least %d\n", name, quantity, resource, min)
+		default:
+			_, _ = fmt.Fprintf(&out, "ok   %s: %d %s allocatable\n", name, count, resource)
+		}
+	}
+
+	_, _ = fmt.Fprintf(&out, "%d/%d nodes advertise %s\n", len(list.Items)-failed, len(list.Items), resource)
+	if failed > 0 {
+		return exec.CommandResult{Output: out.String(), ExitCode: engine.ExitFail}
+	}
+	return exec.CommandResult{Output: out.String()}
+}
+
+// gpuJobCommand builds a shell script that creates a Job requesting one
+// unit of the resource, polls until it succeeds (exit 0) or fails (exit
+// 1), prints its logs, and deletes it. The Job's deadline and TTL clean it
+// up even if the script is killed by the check timeout.
+func gpuJobCommand(spec *config.GPUConfig, kubeContext string, timeout time.Duration) string {
+	job := spec.Job
+	kubectl := "kubectl"
+	if kubeContext != "" {
+		kubectl += " --context " + exec.ShellQuote(kubeContext)
+	}
+	kubectl += " -n " + exec.ShellQuote(job.GetNamespace())
+
+	command, _ := json.Marshal(job.GetCommand())
+	deadline := int(timeout.Seconds())
+	if deadline < 1 {
+		deadline = 1
+	}
+
+	manifest := fmt.Sprintf(`apiVersion: batch/v1
+kind: Job
+metadata:
+  generateName: smoke-gpu-
+  labels: {app.kubernetes.io/managed-by: smoke}
+spec:
+  backoffLimit: 0
+  activeDeadlineSeconds: %d
+  ttlSecondsAfterFinished: 300
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+      - name: gpu
+        image: %s
+        command: %s
+        resources: {limits: {%s: 1}}`, deadline, job.Image, command, spec.GetResource())
+
+	return strings.Join([]string{
+		fmt.Sprintf("job=$(%s create -o name -f - <<'SMOKE_EOF'\n%s\nSMOKE_EOF\n) || exit 2", kubectl, manifest),
+		fmt.Sprintf(`trap '%s delete "$job" --wait=false >/dev/null 2>&1' EXIT`, kubectl),
+		"while :; do",
+		fmt.Sprintf(`  state=$(%s get "$job" -o jsonpath='{.status.succeeded}/{.status.failed}') || exit 2`, kubectl),
+		`  case "$state" in`,
+		fmt.Sprintf(`    1/*) %s logs "$job"; echo "ok   $job succeeded"; exit 0 ;;`, kubectl),
+		fmt.Sprintf(`    */?*) %s logs "$job" 2>&1 | tail -n 20; echo "FAIL $job failed"; exit 1 ;;`, kubectl),
+		"  esac",
+		"  sleep 2",
+		"done",
+	}, "\n")
+}
@@ -0,0 +1,127 @@
+package probe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+const nodesJSON = `{
+  "items": [
+    {"metadata": {"name": "gpu-2"}, "status": {"allocatable": {"cpu": "8", "nvidia.com/gpu": "0"}}},
+    {"metadata": {"name": "gpu-1"}, "status": {"allocatable": {"cpu": "8", "nvidia.com/gpu": "2"}}},
+    {"metadata": {"name": "gpu-3"}, "status": {"allocatable": {"cpu": "8"}}}
+  ]
+}`
+
+func TestEvaluateGPUNodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		min        int
+		wantExit   int
+		wantOutput []string
+	}{
+		{
+			name:     "mixed nodes",
+			data:     nodesJSON,
+			min:      1,
+			wantExit: 1,
+			wantOutput: []string{
+				"ok   gpu-1: 2 nvidia.com/gpu allocatable",
+				"FAIL gpu-2: 0 nvidia.com/gpu allocatable, want at least 1",
+				"FAIL gpu-3: no nvidia.com/gpu advertised",
+				"1/3 nodes advertise nvidia.com/gpu",
+			},
+		},
+		{
+			name:       "minimum per node",
+			data:       `{"items": [{"metadata": {"name": "gpu-1"}, "status": {"allocatable": {"nvidia.com/gpu": "2"}}}]}`,
+			min:        4,
+			wantExit:   1,
+			wantOutput: []string{"FAIL gpu-1: 2 nvidia.com/gpu allocatable, want at least 4"},
+		},
+		{
+			name:       "none matched",
+			data:       `{"items": []}`,
+			min:        1,
+			wantExit:   1,
+			wantOutput: []string{"no nodes matched"},
+		},
+		{
+			name:       "invalid json",
+			data:       `error: You must be logged in to the server`,
+			min:        1,
+			wantExit:   2,
+			wantOutput: []string{"failed to parse nodes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateGPUNodes([]byte(tt.data), "nvidia.com/gpu", tt.min)
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("exit = %d, want %d (output: %s)", result.ExitCode, tt.wantExit, result.Output)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(result.Output, want) {
+					t.Errorf("output = %q, want substring %q", result.Output, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNodesCommand(t *testing.T) {
+	spec := &config.GPUConfig{Selector: "gpu=true"}
+	want := "kubectl --context home-admin get nodes -o json -l gpu=true"
+	if got := nodesCommand(spec, "home-admin"); got != want {
+		t.Errorf("nodesCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestGPUJobCommand(t *testing.T) {
+	// Fake kubectl: records the manifest and reports the Job as succeeded
+	bin := t.TempDir()
+	manifest := filepath.Join(bin, "manifest.yaml")
+	fake := `#!/bin/sh
+while [ "${1#-}" != "$1" ]; do shift 2; done
+case "$1" in
+  create) cat > "` + manifest + `"; echo job.batch/smoke-gpu-abc12 ;;
+  get) echo "1/" ;;
+  logs) echo "GPU 0: NVIDIA GeForce RTX 3060" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(fake), 0o755); err != nil { //nolint:gosec // Test helper must be executable
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	spec := &config.GPUConfig{Job: &config.GPUJobConfig{Image: "nvidia/cuda:12.4.1-base-ubuntu22.04", Namespace: "gpu"}}
+	result := exec.RunCommand(context.Background(), gpuJobCommand(spec, "home-admin", 30*time.Second), 5*time.Second)
+
+	if result.ExitCode != 0 {
+		t.Fatalf("exit = %d, want 0 (output: %s)", result.ExitCode, result.Output)
+	}
+	for _, want := range []string{"GPU 0: NVIDIA GeForce RTX 3060", "ok   job.batch/smoke-gpu-abc12 succeeded"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output = %q, want substring %q", result.Output, want)
+		}
+	}
+
+	data, err := os.ReadFile(manifest) //nolint:gosec // Path is a test temp file
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"image: nvidia/cuda:12.4.1-base-ubuntu22.04", `command: ["nvidia-smi"]`, "resources: {limits: {nvidia.com/gpu: 1}}", "activeDeadlineSeconds: 30"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("manifest = %q, want substring %q", data, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package probe provides native check kinds that run in-process instead
+// of shelling out, reporting results in the exit code contract.
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// grpc.health.v1 serving statuses.
+const (
+	healthUnknown        = 0
+	healthServing        = 1
+	healthNotServing     = 2
+	healthServiceUnknown = 3
+)
+
+// gRPC status codes with special handling.
+const (
+	grpcNotFound      = "5"
+	grpcUnimplemented = "12"
+)
+
+// GRPCHealth calls grpc.health.v1.Health/Check over HTTP/2 (h2c, or TLS
+// when configured). SERVING is PASS; NOT_SERVING, SERVICE_UNKNOWN, and
+// connection failures are FAIL; a server without the health service or a
+// malformed response is ERROR.
+func GRPCHealth(ctx context.Context, spec *config.GRPCConfig, timeout time.Duration) exec.CommandResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var protocols http.Protocols
+	transport := &http.Transport{}
+	scheme := "http"
+	if spec.TLS {
+		scheme = "https"
+		protocols.SetHTTP2(true)
+		transport.TLSClientConfig = &tls.Config{
+			ServerName:         spec.ServerName,
+			InsecureSkipVerify: spec.InsecureSkipVerify, //nolint:gosec // Opt-in for self-signed homelab endpoints
+		}
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	transport.Protocols = &protocols
+	defer transport.CloseIdleConnections()
+
+	target := fmt.Sprintf("%s (service %q)", spec.Address, spec.Service)
+	url := scheme + "://" + spec.Address + "/grpc.health.v1.Health/Check"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(grpcFrame(encodeHealthCheckRequest(spec.Service))))
+	if err != nil {
+		return errorResult(engine.ExitError, "grpc %s: %v", target, err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return exec.CommandResult{ExitCode: -1, Error: fmt.Errorf("grpc health check timed out after %v", timeout)}
+		}
+		return errorResult(engine.ExitFail, "grpc %s: connection failed: %v", target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return errorResult(engine.ExitError, "grpc %s: unexpected HTTP status %s", target, resp.Status)
+	}
+
+	// Trailers are only populated once the body has been read
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return errorResult(engine.ExitFail, "grpc %s: reading response: %v", target, err)
+	}
+
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		// Trailers-only response
+		status = resp.Header.Get("Grpc-Status")
+		message = resp.Header.Get("Grpc-Message")
+	}
+	switch status {
+	case "0", "":
+	case grpcUnimplemented:
+		return errorResult(engine.ExitError, "grpc %s: health service not implemented", target)
+	case grpcNotFound:
+		return errorResult(engine.ExitFail, "grpc %s: SERVICE_UNKNOWN (%s)", target, message)
+	default:
+		return errorResult(engine.ExitFail, "grpc %s: grpc-status %s: %s", target, status, message)
+	}
+
+	serving, err := decodeHealthCheckResponse(body)
+	if err != nil {
+		return errorResult(engine.ExitError, "grpc %s: %v", target, err)
+	}
+
+	switch serving {
+	case healthServing:
+		return exec.CommandResult{Output: fmt.Sprintf("grpc %s: SERVING\n", target)}
+	case healthNotServing:
+		return errorResult(engine.ExitFail, "grpc %s: NOT_SERVING", target)
+	case healthServiceUnknown:
+		return errorResult(engine.ExitFail, "grpc %s: SERVICE_UNKNOWN", target)
+	default:
+		return errorResult(engine.ExitFail, "grpc %s: UNKNOWN (status %d)", target, serving)
+	}
+}
+
+// errorResult returns a result with the given exit code and message output.
+func errorResult(exitCode int, format string, args ...any) exec.CommandResult {
+	return exec.CommandResult{Output: fmt.Sprintf(format, args...) + "\n", ExitCode: exitCode}
+}
+
+// grpcFrame wraps a message in the gRPC length-prefixed framing.
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(msg)))
+	copy(frame[5:], msg)
+	return frame
+}
+
+// encodeHealthCheckRequest encodes HealthCheckRequest{service} as protobuf.
+func encodeHealthCheckRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	msg := []byte{0x0a} // field 1, wire type 2 (length-delimited)
+	msg = binary.AppendUvarint(msg, uint64(len(service)))
+	return append(msg, service...)
+}
+
+// decodeHealthCheckResponse extracts the status from a framed
+// HealthCheckResponse. A missing status field is UNKNOWN (the proto default).
+func decodeHealthCheckResponse(frame []byte) (uint64, error) {
+	if len(frame) < 5 {
+		return 0, fmt.Errorf("malformed response: short frame (%d bytes)", len(frame))
+	}
+	if frame[0] != 0 {
+		return 0, fmt.Errorf("malformed response: compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(frame[1:5])
+	if uint32(len(frame)-5) < size {
+		return 0, fmt.Errorf("malformed response: truncated message")
+	}
+	msg := frame[5 : 5+size]
+
+	status := uint64(healthUnknown)
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return 0, fmt.Errorf("malformed response: bad field key")
+		}
+		msg = msg[n:]
+
+		switch key & 0x7 {
+		case 0: // varint
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return 0, fmt.Errorf("malformed response: bad varint")
+			}
+			msg = msg[n:]
+			if key>>3 == 1 {
+				status = v
+			}
+		case 2: // length-delimited (unknown field)
+			l, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < l {
+				return 0, fmt.Errorf("malformed response: bad length")
+			}
+			msg = msg[n+int(l):]
+		default:
+			return 0, fmt.Errorf("malformed response: unsupported wire type %d", key&0x7)
+		}
+	}
+	return status, nil
+}
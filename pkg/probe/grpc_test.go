@@ -0,0 +1,173 @@
+package probe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// healthServer returns a handler implementing grpc.health.v1 Check with
+// the given serving status per service name.
+func healthServer(t *testing.T, statuses map[string]uint64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/grpc.health.v1.Health/Check" {
+			w.Header().Set("Content-Type", "application/grpc")
+			w.Header().Set("Grpc-Status", grpcUnimplemented)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if ct := req.Header.Get("Content-Type"); ct != "application/grpc" {
+			t.Errorf("Content-Type = %q, want application/grpc", ct)
+		}
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil || len(body) < 5 {
+			t.Fatalf("reading request frame: %v (%d bytes)", err, len(body))
+		}
+		service := ""
+		if len(body) > 7 {
+			service = string(body[7:])
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		status, ok := statuses[service]
+		if !ok {
+			w.WriteHeader(http.StatusOK)
+			w.Header().Set("Grpc-Status", grpcNotFound)
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(grpcFrame([]byte{0x08, byte(status)}))
+		w.Header().Set("Grpc-Status", "0")
+	})
+}
+
+func TestGRPCHealth(t *testing.T) {
+	statuses := map[string]uint64{
+		"":        healthServing,
+		"api":     healthServing,
+		"worker":  healthNotServing,
+		"warming": healthUnknown,
+	}
+
+	h2c := httptest.NewUnstartedServer(healthServer(t, statuses))
+	h2c.Config.Protocols = new(http.Protocols)
+	h2c.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2c.Start()
+	defer h2c.Close()
+
+	tlsServer := httptest.NewUnstartedServer(healthServer(t, statuses))
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	h2cAddr := strings.TrimPrefix(h2c.URL, "http://")
+	tlsAddr := strings.TrimPrefix(tlsServer.URL, "https://")
+
+	tests := []struct {
+		name       string
+		spec       config.GRPCConfig
+		wantExit   int
+		wantOutput string
+	}{
+		{
+			name:       "server serving",
+			spec:       config.GRPCConfig{Address: h2cAddr},
+			wantExit:   0,
+			wantOutput: "SERVING",
+		},
+		{
+			name:       "service serving",
+			spec:       config.GRPCConfig{Address: h2cAddr, Service: "api"},
+			wantExit:   0,
+			wantOutput: "SERVING",
+		},
+		{
+			name:       "service not serving",
+			spec:       config.GRPCConfig{Address: h2cAddr, Service: "worker"},
+			wantExit:   1,
+			wantOutput: "NOT_SERVING",
+		},
+		{
+			name:       "status unknown",
+			spec:       config.GRPCConfig{Address: h2cAddr, Service: "warming"},
+			wantExit:   1,
+			wantOutput: "UNKNOWN",
+		},
+		{
+			name:       "service unknown",
+			spec:       config.GRPCConfig{Address: h2cAddr, Service: "missing"},
+			wantExit:   1,
+			wantOutput: "SERVICE_UNKNOWN",
+		},
+		{
+			name:       "tls serving",
+			spec:       config.GRPCConfig{Address: tlsAddr, TLS: true, InsecureSkipVerify: true},
+			wantExit:   0,
+			wantOutput: "SERVING",
+		},
+		{
+			name:       "tls untrusted certificate",
+			spec:       config.GRPCConfig{Address: tlsAddr, TLS: true},
+			wantExit:   1,
+			wantOutput: "connection failed",
+		},
+		{
+			name:       "connection refused",
+			spec:       config.GRPCConfig{Address: "127.0.0.1:1"},
+			wantExit:   1,
+			wantOutput: "connection failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GRPCHealth(context.Background(), &tt.spec, 5*time.Second)
+			if result.Error != nil {
+				t.Fatalf("GRPCHealth() error = %v", result.Error)
+			}
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("GRPCHealth() exit = %d, want %d (output: %s)", result.ExitCode, tt.wantExit, result.Output)
+			}
+			if !strings.Contains(result.Output, tt.wantOutput) {
+				t.Errorf("GRPCHealth() output = %q, want substring %q", result.Output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestDecodeHealthCheckResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   []byte
+		want    uint64
+		wantErr bool
+	}{
+		{"serving", grpcFrame([]byte{0x08, 0x01}), healthServing, false},
+		{"empty message is unknown", grpcFrame(nil), healthUnknown, false},
+		{"skips unknown fields", grpcFrame([]byte{0x12, 0x02, 'h', 'i', 0x08, 0x02}), healthNotServing, false},
+		{"short frame", []byte{0, 0}, 0, true},
+		{"compressed", []byte{1, 0, 0, 0, 0}, 0, true},
+		{"truncated", []byte{0, 0, 0, 0, 9, 0x08}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeHealthCheckResponse(tt.frame)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeHealthCheckResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("decodeHealthCheckResponse() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
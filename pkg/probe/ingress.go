@@ -0,0 +1,181 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// routeList is the subset of an Ingress/HTTPRoute list used.
+type routeList struct {
+	Items []struct {
+		Spec struct {
+			// Ingress
+			Rules []struct {
+				Host string `json:"host"`
+			} `json:"rules"`
+
+			// HTTPRoute
+			Hostnames []string `json:"hostnames"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// Ingresses discovers hosts from Ingresses (and optionally HTTPRoutes) with
+// kubectl and probes each over HTTPS. A connection/TLS error or 5xx on any
+// host, or no hosts at all, is FAIL; kubectl errors are ERROR.
+func Ingresses(ctx context.Context, spec *config.IngressConfig, kubeContext string, timeout time.Duration, opts exec.Options) exec.CommandResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := exec.RunCommandOpts(ctx, ingressesCommand(spec, kubeContext), timeout, opts)
+	if res.Error != nil {
+		return res
+	}
+	if res.ExitCode != 0 {
+		return errorResult(engine.ExitError, "kubectl get ingresses failed (exit %d): %s", res.ExitCode, strings.TrimSpace(res.Output))
+	}
+
+	// Output is combined, so skip any kubectl warnings before the JSON
+	data := res.Output
+	if i := strings.Index(data, "{"); i > 0 {
+		data = data[i:]
+	}
+	hosts, err := parseRouteHosts([]byte(data))
+	if err != nil {
+		return errorResult(engine.ExitError, "failed to parse ingresses: %v", err)
+	}
+	if len(hosts) == 0 {
+		return errorResult(engine.ExitFail, "no ingress hosts matched")
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: spec.InsecureSkipVerify, //nolint:gosec // Opt-in for self-signed homelab endpoints
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{
+		Transport: transport,
+		// A redirect (e.g., to a login page) means the app answered
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return probeHosts(ctx, client, hosts, spec.GetPath())
+}
+
+// ingressesCommand builds the kubectl command listing routes.
+func ingressesCommand(spec *config.IngressConfig, kubeContext string) string {
+	args := []string{"kubectl"}
+	if kubeContext != "" {
+		args = append(args, "--context", exec.ShellQuote(kubeContext))
+	}
+	kinds := "ingresses.networking.k8s.io"
+	if spec.HTTPRoutes {
+		kinds += ",httproutes.gateway.networking.k8s.io"
+	}
+	args = append(args, "get", kinds, "-o", "json")
+	if spec.Namespace != "" {
+		args = append(args, "-n", exec.ShellQuote(spec.Namespace))
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	if spec.Selector != "" {
+		args = append(args, "-l", exec.ShellQuote(spec.Selector))
+	}
+	return strings.Join(args, " ")
+}
+
+// parseRouteHosts returns the sorted, deduplicated hosts of a route list.
+// Wildcard hosts are skipped, as they have no single URL to probe.
+func parseRouteHosts(data []byte) ([]string, error) {
+	var list routeList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var hosts []string
+	add := func(host string) {
+		if host == "" || strings.HasPrefix(host, "*") || seen[host] {
+			return
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+	}
+	for _, item := range list.Items {
+		for _, rule := range item.Spec.Rules {
+			add(rule.Host)
+		}
+		for _, host := range item.Spec.Hostnames {
+			add(host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+// probeHosts requests https://<host><path> for every host concurrently and
+// reports one line per host, in host order.
+func probeHosts(ctx context.Context, client *http.Client, hosts []string, path string) exec.CommandResult {
+	lines := make([]string, len(hosts))
+	failed := make([]bool, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := probeHost(ctx, client, "https://"+host+path)
+			switch {
+			case err != nil:
+				failed[i] = true
+				lines[i] = fmt.Sprintf("FAIL %s: %v", host, err)
+			case status >= 500:
+				failed[i] = true
+				lines[i] = fmt.Sprintf("FAIL %s: HTTP %d", host, status)
+			default:
+				lines[i] = fmt.Sprintf("ok   %s: HTTP %d", host, status)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var out strings.Builder
+	count := 0
+	for i, line := range lines {
+		_, _ = fmt.Fprintln(&out, line)
+		if failed[i] {
+			count++
+		}
+	}
+	_, _ = fmt.Fprintf(&out, "%d/%d hosts healthy\n", len(hosts)-count, len(hosts))
+	if count > 0 {
+		return exec.CommandResult{Output: out.String(), ExitCode: engine.ExitFail}
+	}
+	return exec.CommandResult{Output: out.String()}
+}
+
+// probeHost sends a GET and returns the response status code.
+func probeHost(ctx context.Context, client *http.Client, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,95 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+func TestParseRouteHosts(t *testing.T) {
+	data := `{
+  "items": [
+    {"kind": "Ingress", "spec": {"rules": [{"host": "grafana.lab"}, {"host": "*.apps.lab"}, {}]}},
+    {"kind": "Ingress", "spec": {"rules": [{"host": "argocd.lab"}, {"host": "grafana.lab"}]}},
+    {"kind": "HTTPRoute", "spec": {"hostnames": ["jellyfin.lab"]}}
+  ]
+}`
+
+	hosts, err := parseRouteHosts([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"argocd.lab", "grafana.lab", "jellyfin.lab"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+}
+
+func TestProbeHosts(t *testing.T) {
+	healthy := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			t.Errorf("path = %q, want /healthz", r.URL.Path)
+		}
+		http.Redirect(w, r, "https://sso.lab/login", http.StatusFound)
+	}))
+	defer healthy.Close()
+
+	broken := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	client := healthy.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	healthyHost := strings.TrimPrefix(healthy.URL, "https://")
+	brokenHost := strings.TrimPrefix(broken.URL, "https://")
+
+	result := probeHosts(context.Background(), client, []string{healthyHost}, "/healthz")
+	if result.ExitCode != 0 || !strings.Contains(result.Output, "ok   "+healthyHost+": HTTP 302") {
+		t.Errorf("expected redirect to pass, got exit %d: %s", result.ExitCode, result.Output)
+	}
+
+	result = probeHosts(context.Background(), client, []string{healthyHost, brokenHost}, "/healthz")
+	if result.ExitCode != 1 {
+		t.Errorf("exit = %d, want 1 (output: %s)", result.ExitCode, result.Output)
+	}
+	for _, want := range []string{"FAIL " + brokenHost + ": HTTP 502", "1/2 hosts healthy"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output = %q, want substring %q", result.Output, want)
+		}
+	}
+}
+
+func TestIngressesCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        config.IngressConfig
+		kubeContext string
+		want        string
+	}{
+		{
+			name: "all namespaces",
+			want: "kubectl get ingresses.networking.k8s.io -o json --all-namespaces",
+		},
+		{
+			name:        "httproutes, namespace, selector, and context",
+			spec:        config.IngressConfig{Namespace: "media", Selector: "smoke=true", HTTPRoutes: true},
+			kubeContext: "home-admin",
+			want:        "kubectl --context home-admin get ingresses.networking.k8s.io,httproutes.gateway.networking.k8s.io -o json -n media -l smoke=true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ingressesCommand(&tt.spec, tt.kubeContext); got != tt.want {
+				t.Errorf("ingressesCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900)
+// and the Unix epoch (1970).
+const ntpEpochOffset = 2208988800
+
+// NTP queries an NTP server (SNTP, RFC 4330) and compares its clock to the
+// local one. Offsets beyond max_offset are FAIL, beyond warn_offset WARN.
+// An unreachable, unresponsive, or unsynchronized server is FAIL; a reply
+// that isn't a server answer to this request is ERROR.
+func NTP(ctx context.Context, spec *config.NTPConfig, timeout time.Duration) exec.CommandResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	address := spec.Server
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "123")
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", address)
+	if err != nil {
+		return errorResult(engine.ExitFail, "ntp %s: %v", address, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// LI=0, VN=4, Mode=3 (client). The server echoes the transmit
+	// timestamp as its originate timestamp, tying the reply to this request.
+	req := make([]byte, 48)
+	req[0] = 0x23
+	sent := time.Now()
+	putNTPTime(req[40:48], sent)
+	if _, err := conn.Write(req); err != nil {
+		return errorResult(engine.ExitFail, "ntp %s: %v", address, err)
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	received := time.Now()
+	if err != nil {
+		// The read deadline can fire just before the context's own timer
+		var netErr net.Error
+		if ctx.Err() == context.DeadlineExceeded || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return errorResult(engine.ExitFail, "ntp %s: no response within %v", address, timeout)
+		}
+		return errorResult(engine.ExitFail, "ntp %s: %v", address, err)
+	}
+	if n < 48 {
+		return errorResult(engine.ExitError, "ntp %s: short response (%d bytes)", address, n)
+	}
+	if mode := resp[0] & 0x7; mode != 4 {
+		return errorResult(engine.ExitError, "ntp %s: reply has mode %d, want 4 (server)", address, mode)
+	}
+	if !bytes.Equal(resp[24:32], req[40:48]) {
+		return errorResult(engine.ExitError, "ntp %s: reply does not answer this request (originate timestamp mismatch)", address)
+	}
+
+	// Stratum 0 is a kiss-o'-death; leap indicator 3 means unsynchronized
+	if stratum := resp[1]; stratum == 0 || resp[0]>>6 == 3 {
+		return errorResult(engine.ExitFail, "ntp %s: server unsynchronized (stratum %d, kiss code %q)", address, stratum, string(resp[12:16]))
+	}
+
+	serverReceive := ntpTime(resp[32:40])
+	serverTransmit := ntpTime(resp[40:48])
+	offset := (serverReceive.Sub(sent) + serverTransmit.Sub(received)) / 2
+	delay := received.Sub(sent) - serverTransmit.Sub(serverReceive)
+
+	abs := offset
+	if abs < 0 {
+		abs = -abs
+	}
+	summary := fmt.Sprintf("ntp %s: offset %v (delay %v, stratum %d)", address, offset.Round(time.Microsecond), delay.Round(time.Microsecond), resp[1])
+
+	maxOffset := spec.GetMaxOffset()
+	switch {
+	case abs > maxOffset:
+		return errorResult(engine.ExitFail, "%s exceeds max_offset %v", summary, maxOffset)
+	case spec.WarnOffset.Duration > 0 && abs > spec.WarnOffset.Duration:
+		return errorResult(engine.ExitWarn, "%s exceeds warn_offset %v", summary, spec.WarnOffset.Duration)
+	default:
+		return exec.CommandResult{Output: summary + "\n"}
+	}
+}
+
+// ntpTime converts a 64-bit NTP timestamp to a time.Time.
+func ntpTime(b []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	frac := int64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(secs, (frac*1e9)>>32)
+}
+
+// putNTPTime encodes t as a 64-bit NTP timestamp.
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32((int64(t.Nanosecond())<<32)/1e9))
+}
@@ -0,0 +1,143 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// fakeNTP answers SNTP queries with a clock skewed by skew from local time.
+// A stratum of 0 makes it send a kiss-o'-death; tamper, if set, edits each
+// reply before it is sent.
+func fakeNTP(t *testing.T, skew time.Duration, stratum byte, tamper func(resp []byte)) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			resp := make([]byte, 48)
+			resp[0] = 0x24 // LI=0, VN=4, Mode=4 (server)
+			resp[1] = stratum
+			copy(resp[12:16], "RATE")
+			copy(resp[24:32], buf[40:48])
+			now := time.Now().Add(skew)
+			putNTPTime(resp[32:40], now)
+			putNTPTime(resp[40:48], now)
+			if tamper != nil {
+				tamper(resp)
+			}
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestNTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		skew       time.Duration
+		stratum    byte
+		spec       config.NTPConfig
+		tamper     func(resp []byte)
+		wantExit   int
+		wantOutput string
+	}{
+		{
+			name:       "in sync",
+			stratum:    2,
+			wantExit:   0,
+			wantOutput: "stratum 2",
+		},
+		{
+			name:       "drift beyond max",
+			skew:       5 * time.Second,
+			stratum:    2,
+			wantExit:   1,
+			wantOutput: "exceeds max_offset 1s",
+		},
+		{
+			name:       "negative drift beyond max",
+			skew:       -5 * time.Second,
+			stratum:    2,
+			wantExit:   1,
+			wantOutput: "exceeds max_offset",
+		},
+		{
+			name:       "drift beyond warn",
+			skew:       300 * time.Millisecond,
+			stratum:    2,
+			spec:       config.NTPConfig{WarnOffset: config.Duration{Duration: 100 * time.Millisecond}},
+			wantExit:   4,
+			wantOutput: "exceeds warn_offset",
+		},
+		{
+			name:       "kiss of death",
+			stratum:    0,
+			wantExit:   1,
+			wantOutput: `kiss code "RATE"`,
+		},
+		{
+			name:       "not a server reply",
+			stratum:    2,
+			tamper:     func(resp []byte) { resp[0] = 0x23 },
+			wantExit:   2,
+			wantOutput: "mode 3, want 4",
+		},
+		{
+			name:       "originate mismatch",
+			stratum:    2,
+			tamper:     func(resp []byte) { resp[31]++ },
+			wantExit:   2,
+			wantOutput: "originate timestamp mismatch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			spec.Server = fakeNTP(t, tt.skew, tt.stratum, tt.tamper)
+			result := NTP(context.Background(), &spec, 5*time.Second)
+			if result.Error != nil {
+				t.Fatalf("NTP() error = %v", result.Error)
+			}
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("NTP() exit = %d, want %d (output: %s)", result.ExitCode, tt.wantExit, result.Output)
+			}
+			if !strings.Contains(result.Output, tt.wantOutput) {
+				t.Errorf("NTP() output = %q, want substring %q", result.Output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestNTPTimeout(t *testing.T) {
+	// A listener that never answers
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	spec := config.NTPConfig{Server: conn.LocalAddr().String()}
+	result := NTP(context.Background(), &spec, 200*time.Millisecond)
+	if result.Error != nil {
+		t.Fatalf("NTP() error = %v", result.Error)
+	}
+	if result.ExitCode != 1 || !strings.Contains(result.Output, "no response within 200ms") {
+		t.Errorf("NTP() = exit %d, output %q, want FAIL on timeout", result.ExitCode, result.Output)
+	}
+}
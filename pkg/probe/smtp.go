@@ -0,0 +1,119 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// SMTP verifies a mail relay: it connects, sends EHLO, negotiates STARTTLS
+// when required, authenticates if credentials are set, and optionally
+// checks that the sender and recipient are accepted. No message is sent:
+// the transaction is reset before DATA. Every failed step is FAIL.
+func SMTP(ctx context.Context, spec *config.SMTPConfig, timeout time.Duration) exec.CommandResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out strings.Builder
+	step := func(format string, args ...any) {
+		fmt.Fprintf(&out, format+"\n", args...)
+	}
+	failed := func(format string, args ...any) exec.CommandResult {
+		step(format, args...)
+		if ctx.Err() == context.DeadlineExceeded {
+			return exec.CommandResult{Output: out.String(), ExitCode: -1, Error: fmt.Errorf("smtp check timed out after %v", timeout)}
+		}
+		return exec.CommandResult{Output: out.String(), ExitCode: engine.ExitFail}
+	}
+
+	host, _, err := net.SplitHostPort(spec.Address)
+	if err != nil {
+		return errorResult(engine.ExitError, "smtp %s: %v", spec.Address, err)
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: spec.InsecureSkipVerify, //nolint:gosec // Opt-in for self-signed homelab relays
+	}
+	if spec.ServerName != "" {
+		tlsConfig.ServerName = spec.ServerName
+	}
+
+	// Connect (implicit TLS for submissions ports like 465)
+	var conn net.Conn
+	dialer := &net.Dialer{}
+	if spec.TLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", spec.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", spec.Address)
+	}
+	if err != nil {
+		return failed("smtp %s: connect failed: %v", spec.Address, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	step("smtp %s: connected", spec.Address)
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return failed("smtp %s: greeting failed: %v", spec.Address, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	helo := spec.Hello
+	if helo == "" {
+		helo = "localhost"
+	}
+	if err := client.Hello(helo); err != nil {
+		return failed("EHLO %s failed: %v", helo, err)
+	}
+	step("EHLO %s: ok", helo)
+
+	if spec.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return failed("STARTTLS not advertised")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return failed("STARTTLS failed: %v", err)
+		}
+		step("STARTTLS: ok")
+	}
+
+	if spec.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return failed("AUTH not advertised")
+		}
+		if err := client.Auth(smtp.PlainAuth("", spec.Username, spec.Password, host)); err != nil {
+			return failed("AUTH as %s failed: %v", spec.Username, err)
+		}
+		step("AUTH as %s: ok", spec.Username)
+	}
+
+	if spec.MailFrom != "" {
+		if err := client.Mail(spec.MailFrom); err != nil {
+			return failed("MAIL FROM:<%s> rejected: %v", spec.MailFrom, err)
+		}
+		step("MAIL FROM:<%s>: ok", spec.MailFrom)
+		if spec.RcptTo != "" {
+			if err := client.Rcpt(spec.RcptTo); err != nil {
+				return failed("RCPT TO:<%s> rejected: %v", spec.RcptTo, err)
+			}
+			step("RCPT TO:<%s>: ok", spec.RcptTo)
+		}
+		if err := client.Reset(); err != nil {
+			return failed("RSET failed: %v", err)
+		}
+	}
+
+	_ = client.Quit()
+	return exec.CommandResult{Output: out.String()}
+}
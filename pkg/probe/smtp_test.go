@@ -0,0 +1,119 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// fakeSMTP serves a minimal SMTP dialogue on a local listener. Recipients
+// in reject get a 550; the extensions are advertised in the EHLO reply.
+func fakeSMTP(t *testing.T, extensions []string, reject string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() { _ = conn.Close() }()
+				r := bufio.NewReader(conn)
+				reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+				reply("220 fake ESMTP")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					cmd := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(cmd, "EHLO"):
+						reply("250-fake")
+						for _, ext := range extensions {
+							reply("250-" + ext)
+						}
+						reply("250 HELP")
+					case strings.HasPrefix(cmd, "RCPT TO:") && reject != "" && strings.Contains(cmd, strings.ToUpper(reject)):
+						reply("550 no such user")
+					case strings.HasPrefix(cmd, "QUIT"):
+						reply("221 bye")
+						return
+					default:
+						reply("250 ok")
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestSMTP(t *testing.T) {
+	addr := fakeSMTP(t, []string{"PIPELINING"}, "nobody@lab")
+
+	tests := []struct {
+		name       string
+		spec       config.SMTPConfig
+		wantExit   int
+		wantOutput string
+	}{
+		{
+			name:       "ehlo only",
+			spec:       config.SMTPConfig{Address: addr},
+			wantExit:   0,
+			wantOutput: "EHLO localhost: ok",
+		},
+		{
+			name:       "sender and recipient accepted",
+			spec:       config.SMTPConfig{Address: addr, Hello: "smoke.lab", MailFrom: "smoke@lab", RcptTo: "alerts@lab"},
+			wantExit:   0,
+			wantOutput: "RCPT TO:<alerts@lab>: ok",
+		},
+		{
+			name:       "recipient rejected",
+			spec:       config.SMTPConfig{Address: addr, MailFrom: "smoke@lab", RcptTo: "nobody@lab"},
+			wantExit:   1,
+			wantOutput: "RCPT TO:<nobody@lab> rejected",
+		},
+		{
+			name:       "starttls not offered",
+			spec:       config.SMTPConfig{Address: addr, StartTLS: true},
+			wantExit:   1,
+			wantOutput: "STARTTLS not advertised",
+		},
+		{
+			name:       "connection refused",
+			spec:       config.SMTPConfig{Address: "127.0.0.1:1"},
+			wantExit:   1,
+			wantOutput: "connect failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := SMTP(context.Background(), &tt.spec, 5*time.Second)
+			if result.Error != nil {
+				t.Fatalf("SMTP() error = %v", result.Error)
+			}
+			if result.ExitCode != tt.wantExit {
+				t.Errorf("SMTP() exit = %d, want %d (output: %s)", result.ExitCode, tt.wantExit, result.Output)
+			}
+			if !strings.Contains(result.Output, tt.wantOutput) {
+				t.Errorf("SMTP() output = %q, want substring %q", result.Output, tt.wantOutput)
+			}
+		})
+	}
+}
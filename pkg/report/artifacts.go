@@ -0,0 +1,79 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+)
+
+// ArtifactMetadata is written next to a check's log as <check-id>.json.
+type ArtifactMetadata struct {
+	CheckReport
+
+	// Log is the name of the file holding the check's full output.
+	Log string `json:"log"`
+
+	// OutputBytes is the size of the full output.
+	OutputBytes int `json:"output_bytes"`
+
+	// FinishedAt is when the result was recorded.
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// WriteArtifacts writes a check's full, untruncated output to
+// <dir>/<check-id>.log, each of its diagnostics' output to
+// <dir>/<check-id>.diag-<n>.log, and its result to <dir>/<check-id>.json,
+// creating dir if needed. Call it before the runner truncates retained
+// output, e.g. from Runner.OnResult.
+func WriteArtifacts(dir string, r runner.CheckExecutionResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil { //nolint:gosec // Artifacts are meant to be readable
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	name := artifactName(r.Check)
+	if err := writeLog(dir, name+".log", r.Result.Output); err != nil {
+		return err
+	}
+
+	meta := &ArtifactMetadata{
+		CheckReport: newCheckReport(r),
+		Log:         name + ".log",
+		OutputBytes: len(r.Result.Output),
+		FinishedAt:  time.Now().UTC(),
+	}
+	for i := range meta.Diagnostics {
+		d := &meta.Diagnostics[i]
+		d.Log = fmt.Sprintf("%s.diag-%d.log", name, i+1)
+		if err := writeLog(dir, d.Log, fmt.Sprintf("$ %s\n%s", d.Command, d.Output)); err != nil {
+			return err
+		}
+	}
+	return WriteFile(filepath.Join(dir, name+".json"), meta)
+}
+
+// writeLog writes output to the artifact file dir/name.
+func writeLog(dir, name, output string) error {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(output), 0644); err != nil { //nolint:gosec // Artifacts are meant to be readable
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// artifactName returns the file name stem for a check's artifacts: its ID,
+// or a slug of it if the ID isn't a safe file name.
+func artifactName(check *config.Check) string {
+	id := check.GetID()
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		if slug := config.Slugify(id); slug != "" {
+			return slug
+		}
+		return "check"
+	}
+	return id
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+)
+
+func TestWriteArtifacts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "artifacts")
+	output := strings.Repeat("x", 100000) + "\nboom\n"
+	result := engine.ClassifyResult(1, nil, nil, true)
+	result.Output = output
+	result.Diagnostics = []engine.Diagnostic{{Command: "kubectl get pods", Output: "pod crashlooping", ExitCode: 0}}
+
+	if err := WriteArtifacts(dir, runner.CheckExecutionResult{
+		Check:  &config.Check{Name: "Gateway Has IP", Layer: 2},
+		Result: result,
+	}); err != nil {
+		t.Fatalf("WriteArtifacts failed: %v", err)
+	}
+
+	log, err := os.ReadFile(filepath.Join(dir, "gateway-has-ip.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(log) != output {
+		t.Errorf("log should hold the full output, got %d bytes", len(log))
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "gateway-has-ip.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta ArtifactMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("metadata is not valid JSON: %v", err)
+	}
+	if meta.ID != "gateway-has-ip" || meta.Outcome != "FAIL" || meta.Layer != 2 || !meta.Blocking {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if meta.Log != "gateway-has-ip.log" || meta.OutputBytes != len(output) {
+		t.Errorf("unexpected log fields: %q, %d", meta.Log, meta.OutputBytes)
+	}
+	if len(meta.Diagnostics) != 1 || meta.Diagnostics[0].Output != "pod crashlooping" || meta.Diagnostics[0].Log != "gateway-has-ip.diag-1.log" {
+		t.Errorf("unexpected diagnostics: %+v", meta.Diagnostics)
+	}
+
+	diag, err := os.ReadFile(filepath.Join(dir, "gateway-has-ip.diag-1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(diag) != "$ kubectl get pods\npod crashlooping" {
+		t.Errorf("unexpected diagnostics log: %q", diag)
+	}
+}
+
+func TestArtifactName(t *testing.T) {
+	tests := []struct {
+		check config.Check
+		want  string
+	}{
+		{config.Check{Name: "Gateway Has IP"}, "gateway-has-ip"},
+		{config.Check{Name: "x", ID: "dns_internal"}, "dns_internal"},
+		{config.Check{Name: "x", ID: "../etc/passwd"}, "etc-passwd"},
+		{config.Check{Name: "x", ID: ".."}, "check"},
+	}
+	for _, tt := range tests {
+		if got := artifactName(&tt.check); got != tt.want {
+			t.Errorf("artifactName(%q) = %q, want %q", tt.check.GetID(), got, tt.want)
+		}
+	}
+}
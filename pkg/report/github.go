@@ -0,0 +1,111 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// WriteGitHubAnnotations writes a GitHub Actions workflow command per
+// notable check: ::error for blocking failures and errors, ::warning for
+// non-blocking failures, warnings, and unexpected passes.
+func WriteGitHubAnnotations(w io.Writer, rep *Report) error {
+	for _, c := range rep.Checks {
+		level := ""
+		switch {
+		case c.Blocking:
+			level = "error"
+		case c.Outcome == "FAIL" || c.Outcome == "WARN" || c.Outcome == "XPASS":
+			level = "warning"
+		default:
+			continue
+		}
+
+		title := fmt.Sprintf("smoke %s: %s", c.Outcome, c.Name)
+		message := c.Reason
+		if message == "" {
+			message = c.Outcome
+		}
+		if _, err := fmt.Fprintf(w, "::%s title=%s::%s\n", level, escapeGitHubProperty(title), escapeGitHubData(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeGitHubData escapes a workflow command message.
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes a workflow command property value.
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+// GitHubStepSummary renders the report as Markdown for a job summary: the
+// overall result, outcome counts, and a table of checks that didn't pass.
+func GitHubStepSummary(rep *Report) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Smoke tests %s on %s\n\n", runResult(rep), rep.Cluster)
+
+	c := rep.Counts
+	fmt.Fprintf(&b, "%d passed, %d failed, %d warnings, %d skipped, %d errors (out of %d) in %.1fs",
+		c.Pass, c.Fail, c.Warn, c.Skip, c.Error, c.Total, rep.DurationSeconds)
+	if c.GatingFails > 0 {
+		fmt.Fprintf(&b, " · **%d gating failure(s)**", c.GatingFails)
+	}
+	b.WriteString("\n")
+
+	if rc := rep.RootCause; rc != nil {
+		fmt.Fprintf(&b, "\n**Probable root cause** (layer %d): %s — %d downstream check(s) failed, skipped, or did not run\n",
+			rc.Layer, markdownCell(strings.Join(rc.Checks, ", ")), rc.Downstream)
+	}
+
+	header := false
+	for _, check := range rep.Checks {
+		if check.Outcome == "PASS" || check.Outcome == "SKIP" || check.Outcome == "XFAIL" {
+			continue
+		}
+		if !header {
+			b.WriteString("\n| Check | Outcome | Blocking | Reason |\n|---|---|---|---|\n")
+			header = true
+		}
+		blocking := ""
+		if check.Blocking {
+			blocking = "yes"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			markdownCell(check.Name), check.Outcome, blocking, markdownCell(check.Reason))
+	}
+	return b.String()
+}
+
+// markdownCell makes s safe for a single Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// AppendFile appends content to path, creating it if needed, as GitHub
+// expects for GITHUB_STEP_SUMMARY.
+func AppendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644) //nolint:gosec // Summary files are meant to be readable
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
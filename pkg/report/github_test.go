@@ -0,0 +1,55 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	rep := New(testRunResult(), "home", time.Now(), time.Second)
+	rep.Checks = append(rep.Checks, CheckReport{Name: "Disk: 91%, almost full", Outcome: "WARN", Reason: "usage 91%\nsee df"})
+
+	var buf bytes.Buffer
+	if err := WriteGitHubAnnotations(&buf, rep); err != nil {
+		t.Fatalf("WriteGitHubAnnotations failed: %v", err)
+	}
+
+	want := "::error title=smoke FAIL%3A Fail Check::check failed (exit code 1)\n" +
+		"::warning title=smoke WARN%3A Disk%3A 91%25%2C almost full::usage 91%25%0Asee df\n"
+	if buf.String() != want {
+		t.Errorf("unexpected annotations:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestGitHubStepSummary(t *testing.T) {
+	rep := New(testRunResult(), "home", time.Now(), 2*time.Second)
+	rep.Checks[1].Reason = "a | b"
+
+	summary := GitHubStepSummary(rep)
+	for _, want := range []string{
+		"## Smoke tests failed on home\n",
+		"1 passed, 1 failed, 0 warnings, 0 skipped, 0 errors (out of 2) in 2.0s · **1 gating failure(s)**",
+		"| Fail Check | FAIL | yes | a \\| b |\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+	if strings.Contains(summary, "Pass Check") {
+		t.Errorf("passing checks should not be listed:\n%s", summary)
+	}
+
+	path := filepath.Join(t.TempDir(), "step_summary.md")
+	for i := 0; i < 2; i++ {
+		if err := AppendFile(path, "x\n"); err != nil {
+			t.Fatalf("AppendFile failed: %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "x\nx\n" {
+		t.Errorf("expected appended content, got %q", data)
+	}
+}
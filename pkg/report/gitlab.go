@@ -0,0 +1,155 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// junitSuites is the JUnit XML document GitLab reads as a test report.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Time      string      `xml:"time,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML for GitLab's
+// artifacts:reports:junit, with one test case per check classed by layer.
+// FAIL and XPASS are failures, ERROR is an error, and SKIP is skipped;
+// WARN and XFAIL pass with the outcome noted in the case's output.
+func WriteJUnit(w io.Writer, rep *Report) error {
+	suite := junitSuite{
+		Name:      "smoke " + rep.Cluster,
+		Timestamp: rep.StartedAt.Format("2006-01-02T15:04:05Z"),
+		Time:      junitSeconds(rep.DurationSeconds),
+		Cases:     make([]junitCase, 0, len(rep.Checks)),
+	}
+
+	for _, c := range rep.Checks {
+		tc := junitCase{
+			Name:      c.Name,
+			ClassName: fmt.Sprintf("%s.layer%d", rep.Cluster, c.Layer),
+			Time:      junitSeconds(c.DurationSeconds),
+		}
+		problem := &junitProblem{Message: c.Reason, Type: c.Outcome, Text: c.Reason}
+		switch c.Outcome {
+		case "FAIL", "XPASS":
+			tc.Failure = problem
+			suite.Failures++
+		case "ERROR":
+			tc.Error = problem
+			suite.Errors++
+		case "SKIP":
+			tc.Skipped = problem
+			suite.Skipped++
+		case "WARN", "XFAIL":
+			tc.SystemOut = c.Outcome + ": " + c.Reason + "\n"
+		}
+		for _, d := range c.Diagnostics {
+			tc.SystemOut += fmt.Sprintf("$ %s (exit %d)\n%s\n", d.Command, d.ExitCode, d.Output)
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+
+	doc := junitSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitSeconds formats a duration in seconds as JUnit expects.
+func junitSeconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+// GitLabDotenv renders the run's outcome as a dotenv file for GitLab's
+// artifacts:reports:dotenv, so downstream jobs can branch on the result
+// (e.g., SMOKE_PASSED=true). Values are single-line and unquoted, as
+// GitLab requires.
+func GitLabDotenv(rep *Report) string {
+	c := rep.Counts
+	vars := []struct {
+		name  string
+		value any
+	}{
+		{"SMOKE_RESULT", runResult(rep)},
+		{"SMOKE_PASSED", rep.ExitCode == 0},
+		{"SMOKE_EXIT_CODE", rep.ExitCode},
+		{"SMOKE_CLUSTER", rep.Cluster},
+		{"SMOKE_TOTAL", c.Total},
+		{"SMOKE_PASS", c.Pass},
+		{"SMOKE_FAIL", c.Fail},
+		{"SMOKE_WARN", c.Warn},
+		{"SMOKE_SKIP", c.Skip},
+		{"SMOKE_ERROR", c.Error},
+		{"SMOKE_GATING_FAILS", c.GatingFails},
+	}
+
+	var b strings.Builder
+	for _, v := range vars {
+		value := strings.Join(strings.Fields(fmt.Sprint(v.value)), " ")
+		fmt.Fprintf(&b, "%s=%s\n", v.name, value)
+	}
+	return b.String()
+}
+
+// runResult describes the run's exit code as passed, failed, or errored.
+func runResult(rep *Report) string {
+	switch {
+	case rep.ExitCode == 0:
+		return "passed"
+	case rep.ExitCode == 1:
+		return "failed"
+	default:
+		return "errored"
+	}
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	rep := New(testRunResult(), "home", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), 2*time.Second)
+	rep.Checks = append(rep.Checks,
+		CheckReport{Name: "Disk <90%", Layer: 3, Outcome: "WARN", Reason: "usage 91%"},
+		CheckReport{Name: "Unreachable", Layer: 3, Outcome: "ERROR", Reason: "timeout"},
+	)
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, rep); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<testsuites name="smoke home" tests="4" failures="1" errors="1" skipped="0" time="2.000">`,
+		`<testsuite name="smoke home" tests="4" failures="1" errors="1" skipped="0" timestamp="2026-01-02T03:04:05Z" time="2.000">`,
+		`<testcase name="Pass Check" classname="home.layer1"`,
+		`<failure message="check failed (exit code 1)" type="FAIL">check failed (exit code 1)</failure>`,
+		`<testcase name="Disk &lt;90%" classname="home.layer3" time="0.000">`,
+		`<system-out>WARN: usage 91%`,
+		`<error message="timeout" type="ERROR">timeout</error>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JUnit output missing %q:\n%s", want, out)
+		}
+	}
+
+	var doc junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+}
+
+func TestGitLabDotenv(t *testing.T) {
+	rep := New(testRunResult(), "home lab", time.Now(), time.Second)
+
+	want := "SMOKE_RESULT=failed\n" +
+		"SMOKE_PASSED=false\n" +
+		"SMOKE_EXIT_CODE=1\n" +
+		"SMOKE_CLUSTER=home lab\n" +
+		"SMOKE_TOTAL=2\n" +
+		"SMOKE_PASS=1\n" +
+		"SMOKE_FAIL=1\n" +
+		"SMOKE_WARN=0\n" +
+		"SMOKE_SKIP=0\n" +
+		"SMOKE_ERROR=0\n" +
+		"SMOKE_GATING_FAILS=1\n"
+	if got := GitLabDotenv(rep); got != want {
+		t.Errorf("unexpected dotenv:\n%s\nwant:\n%s", got, want)
+	}
+}
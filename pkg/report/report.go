@@ -0,0 +1,332 @@
+// Package report provides machine-readable run reports and publishing.
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+)
+
+// Report is the machine-readable form of a run.
+type Report struct {
+	// Cluster is the cluster the run targeted.
+	Cluster string `json:"cluster"`
+
+	// Labels are user-supplied key=value tags for the run (e.g., the deploy
+	// that triggered it), for filtering runs downstream.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// StartedAt is when the run began.
+	StartedAt time.Time `json:"started_at"`
+
+	// DurationSeconds is the total run time.
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// ExitCode is the CLI exit code for the run.
+	ExitCode int `json:"exit_code"`
+
+	// Counts holds per-outcome totals.
+	Counts Counts `json:"counts"`
+
+	// Buckets holds configured summary bucket totals.
+	Buckets map[string]int `json:"buckets,omitempty"`
+
+	// Checks holds one entry per executed check.
+	Checks []CheckReport `json:"checks"`
+
+	// Snapshot is the pre-run cluster snapshot, if configured.
+	Snapshot *Snapshot `json:"snapshot,omitempty"`
+
+	// RootCause is the probable root cause when failures cascade across
+	// layers.
+	RootCause *RootCauseReport `json:"root_cause,omitempty"`
+
+	// TimedOutLayers lists the layers that ran out of their time budget.
+	TimedOutLayers []int `json:"timed_out_layers,omitempty"`
+
+	// SetupError and TeardownError describe failed setup and teardown
+	// commands.
+	SetupError    string `json:"setup_error,omitempty"`
+	TeardownError string `json:"teardown_error,omitempty"`
+}
+
+// RootCauseReport names the failed checks of the lowest failing layer and
+// how many later-layer checks were affected.
+type RootCauseReport struct {
+	Layer      int      `json:"layer"`
+	Checks     []string `json:"checks"`
+	Downstream int      `json:"downstream"`
+}
+
+// Counts holds per-outcome totals for a run.
+type Counts struct {
+	Pass        int `json:"pass"`
+	Fail        int `json:"fail"`
+	Warn        int `json:"warn"`
+	Skip        int `json:"skip"`
+	Error       int `json:"error"`
+	XFail       int `json:"xfail"`
+	XPass       int `json:"xpass"`
+	Total       int `json:"total"`
+	GatingFails int `json:"gating_fails"`
+	Flaky       int `json:"flaky"`
+}
+
+// CheckReport is the machine-readable form of a single check result.
+type CheckReport struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Owner       string `json:"owner,omitempty"`
+	Team        string `json:"team,omitempty"`
+	Layer       int    `json:"layer,omitempty"`
+	Outcome     string `json:"outcome"`
+	Label       string `json:"label,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Gating      bool   `json:"gating"`
+	Blocking    bool   `json:"blocking"`
+	ExitCode    int    `json:"exit_code"`
+	Retries     int    `json:"retries,omitempty"`
+	Fallback    bool   `json:"fallback,omitempty"`
+	Flaky       bool   `json:"flaky,omitempty"`
+	Quarantined bool   `json:"quarantined,omitempty"`
+
+	DurationSeconds float64 `json:"duration_seconds"`
+	CPUSeconds      float64 `json:"cpu_seconds"`
+	MaxRSSBytes     int64   `json:"max_rss_bytes,omitempty"`
+
+	Diagnostics []DiagnosticReport `json:"diagnostics,omitempty"`
+}
+
+// DiagnosticReport is the machine-readable form of a diagnostics command
+// run after a check failed.
+type DiagnosticReport struct {
+	Command  string `json:"command"`
+	Output   string `json:"output"`
+	ExitCode int    `json:"exit_code"`
+
+	// Log is the name of the artifact file holding the output; it is only
+	// set in artifact metadata.
+	Log string `json:"log,omitempty"`
+}
+
+// New builds a Report from a run result.
+func New(result *runner.RunResult, cluster string, startedAt time.Time, duration time.Duration) *Report {
+	rep := &Report{
+		Cluster:         cluster,
+		StartedAt:       startedAt.UTC(),
+		DurationSeconds: duration.Seconds(),
+		ExitCode:        result.ExitCode(),
+		Counts: Counts{
+			Pass:        result.PassCount,
+			Fail:        result.FailCount,
+			Warn:        result.WarnCount,
+			Skip:        result.SkipCount,
+			Error:       result.ErrorCount,
+			XFail:       result.XFailCount,
+			XPass:       result.XPassCount,
+			Total:       result.TotalCount,
+			GatingFails: result.GatingFails,
+			Flaky:       result.FlakyCount,
+		},
+		Checks:         make([]CheckReport, 0, len(result.Results)),
+		TimedOutLayers: result.TimedOutLayers,
+	}
+
+	for _, r := range result.Results {
+		rep.Checks = append(rep.Checks, newCheckReport(r))
+	}
+
+	if result.SetupError != nil {
+		rep.SetupError = result.SetupError.Error()
+	}
+	if result.TeardownError != nil {
+		rep.TeardownError = result.TeardownError.Error()
+	}
+
+	if rc := result.RootCause(); rc != nil {
+		rep.RootCause = &RootCauseReport{Layer: rc.Layer, Downstream: rc.Downstream}
+		for _, c := range rc.Causes {
+			rep.RootCause.Checks = append(rep.RootCause.Checks, c.Check.GetID())
+		}
+	}
+
+	return rep
+}
+
+// newCheckReport builds the report entry for a single check result.
+func newCheckReport(r runner.CheckExecutionResult) CheckReport {
+	var diagnostics []DiagnosticReport
+	for _, d := range r.Result.Diagnostics {
+		diagnostics = append(diagnostics, DiagnosticReport{Command: d.Command, Output: d.Output, ExitCode: d.ExitCode})
+	}
+	return CheckReport{
+		ID:          r.Check.GetID(),
+		Name:        r.Check.Name,
+		Owner:       r.Check.Owner,
+		Team:        r.Check.Team,
+		Layer:       r.Check.Layer,
+		Outcome:     string(r.Result.Outcome),
+		Reason:      r.Result.OutcomeReason,
+		Gating:      r.Result.Gating,
+		Blocking:    r.Result.IsGatingFailure(),
+		ExitCode:    r.Result.ExitCode,
+		Retries:     r.Result.RetryCount,
+		Fallback:    r.Result.Fallback,
+		Flaky:       r.Result.Flaky,
+		Quarantined: r.Check.Quarantine.IsActive(time.Now()),
+
+		DurationSeconds: r.Result.Duration.Seconds(),
+		CPUSeconds:      r.Result.CPUTime.Seconds(),
+		MaxRSSBytes:     r.Result.MaxRSS,
+
+		Diagnostics: diagnostics,
+	}
+}
+
+// ApplyStyle adds configured outcome labels and bucket totals.
+func (rep *Report) ApplyStyle(style *config.SummaryStyle) {
+	if style == nil {
+		return
+	}
+
+	counts := make(map[string]int)
+	for i := range rep.Checks {
+		c := &rep.Checks[i]
+		counts[c.Outcome]++
+		if label := style.Label(c.Outcome); label != c.Outcome {
+			c.Label = label
+		}
+	}
+
+	if len(style.Buckets) > 0 {
+		rep.Buckets = make(map[string]int, len(style.Buckets))
+		for _, b := range style.Buckets {
+			rep.Buckets[b.Name] = b.BucketTotal(counts)
+		}
+	}
+}
+
+// Summary is a compact run summary for shell-based CI gates.
+type Summary struct {
+	// Outcome is the overall result: "pass", "fail" (gating failures), or "error".
+	Outcome string `json:"outcome"`
+
+	// ExitCode is the CLI exit code for the run.
+	ExitCode int `json:"exit_code"`
+
+	// Labels are the run's labels.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Counts holds per-outcome totals.
+	Counts Counts `json:"counts"`
+
+	// GatingFailures lists the IDs of checks that blocked the run.
+	GatingFailures []string `json:"gating_failures"`
+}
+
+// Summary returns the compact summary for this report.
+func (rep *Report) Summary() *Summary {
+	sum := &Summary{
+		ExitCode:       rep.ExitCode,
+		Labels:         rep.Labels,
+		Counts:         rep.Counts,
+		GatingFailures: []string{},
+	}
+
+	switch rep.ExitCode {
+	case 0:
+		sum.Outcome = "pass"
+	case 1:
+		sum.Outcome = "fail"
+	default:
+		sum.Outcome = "error"
+	}
+
+	for _, c := range rep.Checks {
+		if c.Blocking {
+			sum.GatingFailures = append(sum.GatingFailures, c.ID)
+		}
+	}
+
+	return sum
+}
+
+// WriteFile writes v as indented JSON to path.
+func WriteFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil { //nolint:gosec // Report files are meant to be readable
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Publish POSTs the report as JSON to an event endpoint, such as an
+// Argo Events webhook EventSource or any HTTP-fronted event bus.
+func Publish(ctx context.Context, url string, rep *Report) error {
+	body, err := json.Marshal(rep)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return post(ctx, url, "application/json", body)
+}
+
+// PublishHook POSTs the report to a hook's webhook, rendering the hook's
+// payload template over the report if one is set.
+func PublishHook(ctx context.Context, hook *config.HookConfig, rep *Report) error {
+	if hook.Payload == "" {
+		return Publish(ctx, hook.Webhook, rep)
+	}
+	body, err := RenderPayload(hook.Payload, rep)
+	if err != nil {
+		return err
+	}
+	return post(ctx, hook.Webhook, hook.GetContentType(), body)
+}
+
+// RenderPayload renders a webhook payload template over the report, with
+// the same helper functions as check templates (e.g., json, upper).
+func RenderPayload(payload string, rep *Report) ([]byte, error) {
+	tmpl, err := template.New("payload").Funcs(config.TemplateFuncs()).Parse(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse payload template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, rep); err != nil {
+		return nil, fmt.Errorf("failed to render payload template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// post sends body to url and requires a 2xx response.
+func post(ctx context.Context, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create publish request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish report: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("publish endpoint returned %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+)
+
+func testRunResult() *runner.RunResult {
+	return &runner.RunResult{
+		Results: []runner.CheckExecutionResult{
+			{
+				Check:  &config.Check{Name: "Pass Check", Layer: 1},
+				Result: engine.ClassifyResult(0, nil, nil, true),
+			},
+			{
+				Check:  &config.Check{Name: "Fail Check", Layer: 2},
+				Result: engine.ClassifyResult(1, nil, nil, true),
+			},
+		},
+		PassCount:   1,
+		FailCount:   1,
+		TotalCount:  2,
+		GatingFails: 1,
+	}
+}
+
+func TestNew(t *testing.T) {
+	rep := New(testRunResult(), "home", time.Now(), 3*time.Second)
+
+	if rep.ExitCode != 1 {
+		t.Errorf("ExitCode expected 1, got %d", rep.ExitCode)
+	}
+	if rep.Counts.GatingFails != 1 {
+		t.Errorf("GatingFails expected 1, got %d", rep.Counts.GatingFails)
+	}
+	if len(rep.Checks) != 2 {
+		t.Fatalf("expected 2 checks, got %d", len(rep.Checks))
+	}
+	if rep.Checks[1].Outcome != "FAIL" || !rep.Checks[1].Blocking {
+		t.Errorf("expected blocking FAIL, got %+v", rep.Checks[1])
+	}
+	if rep.DurationSeconds != 3 {
+		t.Errorf("DurationSeconds expected 3, got %v", rep.DurationSeconds)
+	}
+}
+
+func TestNewRootCause(t *testing.T) {
+	result := testRunResult()
+	result.Results[0].Result = engine.ClassifyResult(2, nil, nil, true)
+
+	rep := New(result, "home", time.Now(), time.Second)
+	rc := rep.RootCause
+	if rc == nil {
+		t.Fatal("expected a root cause")
+	}
+	if rc.Layer != 1 || len(rc.Checks) != 1 || rc.Checks[0] != "pass-check" || rc.Downstream != 1 {
+		t.Errorf("unexpected root cause: %+v", rc)
+	}
+	if New(testRunResult(), "home", time.Now(), time.Second).RootCause != nil {
+		t.Error("expected no root cause for a single failing layer")
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var received Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected JSON content type, got %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	rep := New(testRunResult(), "home", time.Now(), time.Second)
+	if err := Publish(context.Background(), server.URL, rep); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if received.Cluster != "home" || len(received.Checks) != 2 {
+		t.Errorf("unexpected payload: %+v", received)
+	}
+}
+
+func TestPublishErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	rep := New(testRunResult(), "home", time.Now(), time.Second)
+	if err := Publish(context.Background(), server.URL, rep); err == nil {
+		t.Error("expected error for non-2xx status")
+	}
+}
+
+func TestPublishHookPayload(t *testing.T) {
+	var body, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	hook := &config.HookConfig{
+		Webhook:     server.URL,
+		Payload:     `{"text": {{ printf "smoke on %s: %d checks" .Cluster (len .Checks) | json }}}`,
+		ContentType: "application/vnd.test+json",
+	}
+	rep := New(testRunResult(), "home", time.Now(), time.Second)
+	if err := PublishHook(context.Background(), hook, rep); err != nil {
+		t.Fatalf("PublishHook failed: %v", err)
+	}
+	if body != `{"text": "smoke on home: 2 checks"}` {
+		t.Errorf("unexpected body: %s", body)
+	}
+	if contentType != "application/vnd.test+json" {
+		t.Errorf("unexpected content type: %q", contentType)
+	}
+}
+
+func TestRenderPayloadError(t *testing.T) {
+	rep := New(testRunResult(), "home", time.Now(), time.Second)
+	if _, err := RenderPayload("{{ .NoSuchField }}", rep); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestApplyStyle(t *testing.T) {
+	rep := New(testRunResult(), "home", time.Now(), time.Second)
+	rep.ApplyStyle(&config.SummaryStyle{
+		Labels: map[string]string{"FAIL": "broken"},
+		Buckets: []config.SummaryBucket{
+			{Name: "attention", Outcomes: []string{"FAIL", "WARN"}},
+			{Name: "healthy", Outcomes: []string{"PASS"}},
+		},
+	})
+
+	if rep.Checks[0].Label != "" {
+		t.Errorf("unlabeled outcome should have no label, got %q", rep.Checks[0].Label)
+	}
+	if rep.Checks[1].Label != "broken" {
+		t.Errorf("expected label broken, got %q", rep.Checks[1].Label)
+	}
+	if rep.Buckets["attention"] != 1 || rep.Buckets["healthy"] != 1 {
+		t.Errorf("unexpected buckets: %v", rep.Buckets)
+	}
+}
+
+func TestSummaryWriteFile(t *testing.T) {
+	rep := New(testRunResult(), "home", time.Now(), time.Second)
+	rep.Labels = map[string]string{"deploy": "media-stack"}
+	path := filepath.Join(t.TempDir(), "summary.json")
+
+	if err := WriteFile(path, rep.Summary()); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read summary: %v", err)
+	}
+
+	var sum Summary
+	if err := json.Unmarshal(data, &sum); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if sum.Outcome != "fail" || sum.ExitCode != 1 {
+		t.Errorf("expected fail/1, got %s/%d", sum.Outcome, sum.ExitCode)
+	}
+	if len(sum.GatingFailures) != 1 || sum.GatingFailures[0] != "fail-check" {
+		t.Errorf("expected gating failure fail-check, got %v", sum.GatingFailures)
+	}
+	if sum.Labels["deploy"] != "media-stack" {
+		t.Errorf("expected label deploy=media-stack, got %v", sum.Labels)
+	}
+}
@@ -0,0 +1,229 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	osexec "os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// Snapshot is cluster state captured before a run, giving baseline
+// context when later checks fail.
+type Snapshot struct {
+	// TakenAt is when the snapshot was captured.
+	TakenAt time.Time `json:"taken_at"`
+
+	// Nodes lists every node and whether it is Ready.
+	Nodes []SnapshotNode `json:"nodes"`
+
+	// FailingPods lists pods that are not running cleanly.
+	FailingPods []SnapshotPod `json:"failing_pods"`
+
+	// WarningEvents lists the most recent warning events, newest first.
+	WarningEvents []SnapshotEvent `json:"warning_events"`
+
+	// Error is set when the snapshot couldn't be captured.
+	Error string `json:"error,omitempty"`
+}
+
+// SnapshotNode is a node's readiness.
+type SnapshotNode struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+}
+
+// SnapshotPod is a pod that is pending, failed, or has a waiting container.
+type SnapshotPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Phase     string `json:"phase"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// SnapshotEvent is a warning event.
+type SnapshotEvent struct {
+	Namespace string    `json:"namespace"`
+	Object    string    `json:"object"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Count     int       `json:"count,omitempty"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// snapshotList is the subset of a `kubectl get -o json` list of nodes,
+// pods, and events that is used.
+type snapshotList struct {
+	Items []struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name              string    `json:"name"`
+			Namespace         string    `json:"namespace"`
+			CreationTimestamp time.Time `json:"creationTimestamp"`
+		} `json:"metadata"`
+		Status struct {
+			Phase      string `json:"phase"`
+			Reason     string `json:"reason"`
+			Conditions []struct {
+				Type   string `json:"type"`
+				Status string `json:"status"`
+			} `json:"conditions"`
+			ContainerStatuses []struct {
+				State struct {
+					Waiting *struct {
+						Reason string `json:"reason"`
+					} `json:"waiting"`
+				} `json:"state"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+
+		// Event fields
+		Type           string `json:"type"`
+		Reason         string `json:"reason"`
+		Message        string `json:"message"`
+		Count          int    `json:"count"`
+		InvolvedObject struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"involvedObject"`
+		LastTimestamp *time.Time `json:"lastTimestamp"`
+		EventTime     *time.Time `json:"eventTime"`
+	} `json:"items"`
+}
+
+// ParseSnapshot builds a snapshot from `kubectl get -o json` lists of
+// nodes, pods, and events (in any combination), keeping at most
+// maxEvents of the most recent warning events.
+func ParseSnapshot(maxEvents int, lists ...[]byte) (*Snapshot, error) {
+	snap := &Snapshot{
+		Nodes:         []SnapshotNode{},
+		FailingPods:   []SnapshotPod{},
+		WarningEvents: []SnapshotEvent{},
+	}
+
+	for _, data := range lists {
+		var list snapshotList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse kubectl output: %w", err)
+		}
+
+		for _, item := range list.Items {
+			switch item.Kind {
+			case "Node":
+				node := SnapshotNode{Name: item.Metadata.Name}
+				for _, c := range item.Status.Conditions {
+					if c.Type == "Ready" {
+						node.Ready = c.Status == "True"
+					}
+				}
+				snap.Nodes = append(snap.Nodes, node)
+
+			case "Pod":
+				pod := SnapshotPod{
+					Namespace: item.Metadata.Namespace,
+					Name:      item.Metadata.Name,
+					Phase:     item.Status.Phase,
+					Reason:    item.Status.Reason,
+				}
+				for _, cs := range item.Status.ContainerStatuses {
+					if w := cs.State.Waiting; w != nil && w.Reason != "" {
+						pod.Reason = w.Reason
+						break
+					}
+				}
+				if (pod.Phase != "Running" && pod.Phase != "Succeeded") || pod.Reason != "" {
+					snap.FailingPods = append(snap.FailingPods, pod)
+				}
+
+			case "Event":
+				if item.Type != "Warning" {
+					continue
+				}
+				event := SnapshotEvent{
+					Namespace: item.Metadata.Namespace,
+					Object:    strings.ToLower(item.InvolvedObject.Kind) + "/" + item.InvolvedObject.Name,
+					Reason:    item.Reason,
+					Message:   strings.TrimSpace(item.Message),
+					Count:     item.Count,
+					LastSeen:  item.Metadata.CreationTimestamp,
+				}
+				switch {
+				case item.LastTimestamp != nil:
+					event.LastSeen = *item.LastTimestamp
+				case item.EventTime != nil:
+					event.LastSeen = *item.EventTime
+				}
+				snap.WarningEvents = append(snap.WarningEvents, event)
+			}
+		}
+	}
+
+	sort.Slice(snap.Nodes, func(i, j int) bool { return snap.Nodes[i].Name < snap.Nodes[j].Name })
+	sort.Slice(snap.FailingPods, func(i, j int) bool {
+		a, b := snap.FailingPods[i], snap.FailingPods[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	sort.SliceStable(snap.WarningEvents, func(i, j int) bool {
+		return snap.WarningEvents[i].LastSeen.After(snap.WarningEvents[j].LastSeen)
+	})
+	if len(snap.WarningEvents) > maxEvents {
+		snap.WarningEvents = snap.WarningEvents[:maxEvents]
+	}
+	return snap, nil
+}
+
+// TakeSnapshot captures a snapshot with kubectl, run with the given
+// environment (nil to inherit). Failures are recorded in the snapshot's
+// Error rather than returned, as a snapshot never affects the run.
+func TakeSnapshot(ctx context.Context, spec *config.SnapshotConfig, kubeContext string, environ []string) *Snapshot {
+	scope := []string{"--all-namespaces"}
+	if spec.Namespace != "" {
+		scope = []string{"--namespace", spec.Namespace}
+	}
+	if kubeContext != "" {
+		scope = append(scope, "--context", kubeContext)
+	}
+
+	var lists [][]byte
+	for _, args := range [][]string{
+		{"get", "nodes,pods", "-o", "json"},
+		{"get", "events", "--field-selector", "type=Warning", "-o", "json"},
+	} {
+		var stdout, stderr bytes.Buffer
+		cmd := osexec.CommandContext(ctx, "kubectl", append(args, scope...)...)
+		cmd.Env = environ
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return &Snapshot{TakenAt: time.Now().UTC(), Error: fmt.Sprintf("kubectl %s failed: %v: %s", args[1], err, strings.TrimSpace(stderr.String()))}
+		}
+		lists = append(lists, stdout.Bytes())
+	}
+
+	snap, err := ParseSnapshot(spec.GetEvents(), lists...)
+	if err != nil {
+		return &Snapshot{TakenAt: time.Now().UTC(), Error: err.Error()}
+	}
+	snap.TakenAt = time.Now().UTC()
+	return snap
+}
+
+// Describe returns a one-line summary of the snapshot for the console.
+func (s *Snapshot) Describe() string {
+	if s.Error != "" {
+		return "unavailable (" + s.Error + ")"
+	}
+	ready := 0
+	for _, n := range s.Nodes {
+		if n.Ready {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d/%d nodes ready, %d failing pod(s), %d recent warning event(s)",
+		ready, len(s.Nodes), len(s.FailingPods), len(s.WarningEvents))
+}
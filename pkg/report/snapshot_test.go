@@ -0,0 +1,63 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSnapshot(t *testing.T) {
+	nodesAndPods := `{"items": [
+		{"kind": "Node", "metadata": {"name": "worker-2"}, "status": {"conditions": [{"type": "Ready", "status": "False"}]}},
+		{"kind": "Node", "metadata": {"name": "worker-1"}, "status": {"conditions": [{"type": "MemoryPressure", "status": "False"}, {"type": "Ready", "status": "True"}]}},
+		{"kind": "Pod", "metadata": {"name": "web", "namespace": "media"}, "status": {"phase": "Running", "containerStatuses": [{"state": {"running": {}}}]}},
+		{"kind": "Pod", "metadata": {"name": "db", "namespace": "media"}, "status": {"phase": "Running", "containerStatuses": [{"state": {"waiting": {"reason": "CrashLoopBackOff"}}}]}},
+		{"kind": "Pod", "metadata": {"name": "job-x", "namespace": "batch"}, "status": {"phase": "Succeeded"}},
+		{"kind": "Pod", "metadata": {"name": "stuck", "namespace": "apps"}, "status": {"phase": "Pending"}}
+	]}`
+	events := `{"items": [
+		{"kind": "Event", "type": "Warning", "reason": "BackOff", "message": "Back-off restarting", "count": 12,
+		 "metadata": {"namespace": "media", "creationTimestamp": "2026-01-01T00:00:00Z"},
+		 "involvedObject": {"kind": "Pod", "name": "db"}, "lastTimestamp": "2026-01-01T10:00:00Z"},
+		{"kind": "Event", "type": "Warning", "reason": "FailedScheduling", "message": "0/2 nodes available",
+		 "metadata": {"namespace": "apps", "creationTimestamp": "2026-01-01T00:00:00Z"},
+		 "involvedObject": {"kind": "Pod", "name": "stuck"}, "lastTimestamp": null, "eventTime": "2026-01-01T11:00:00.000000Z"},
+		{"kind": "Event", "type": "Warning", "reason": "Old", "message": "old",
+		 "metadata": {"namespace": "apps", "creationTimestamp": "2025-12-01T00:00:00Z"},
+		 "involvedObject": {"kind": "Pod", "name": "gone"}},
+		{"kind": "Event", "type": "Normal", "reason": "Pulled", "message": "pulled",
+		 "metadata": {"namespace": "media", "creationTimestamp": "2026-01-01T12:00:00Z"},
+		 "involvedObject": {"kind": "Pod", "name": "web"}}
+	]}`
+
+	snap, err := ParseSnapshot(2, []byte(nodesAndPods), []byte(events))
+	if err != nil {
+		t.Fatalf("ParseSnapshot failed: %v", err)
+	}
+
+	if len(snap.Nodes) != 2 || snap.Nodes[0].Name != "worker-1" || !snap.Nodes[0].Ready || snap.Nodes[1].Ready {
+		t.Errorf("unexpected nodes: %+v", snap.Nodes)
+	}
+
+	var pods []string
+	for _, p := range snap.FailingPods {
+		pods = append(pods, p.Namespace+"/"+p.Name+":"+p.Reason)
+	}
+	if got := strings.Join(pods, ","); got != "apps/stuck:,media/db:CrashLoopBackOff" {
+		t.Errorf("unexpected failing pods: %s", got)
+	}
+
+	if len(snap.WarningEvents) != 2 || snap.WarningEvents[0].Reason != "FailedScheduling" || snap.WarningEvents[1].Object != "pod/db" {
+		t.Errorf("expected the two newest warnings, newest first, got %+v", snap.WarningEvents)
+	}
+
+	expected := "1/2 nodes ready, 2 failing pod(s), 2 recent warning event(s)"
+	if got := snap.Describe(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestParseSnapshotInvalid(t *testing.T) {
+	if _, err := ParseSnapshot(20, []byte("error: not json")); err == nil {
+		t.Error("expected error for non-JSON output")
+	}
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"io"
+	"os"
+)
+
+// UseColor decides whether output written to w should carry ANSI colors.
+// -force-color (force) and -no-color (disable) win; otherwise colors are
+// off when NO_COLOR is set (https://no-color.org), when TERM is "dumb", or
+// when w isn't a terminal, so logs captured by CI stay free of escape codes.
+func UseColor(w io.Writer, force, disable bool) bool {
+	switch {
+	case force:
+		return true
+	case disable:
+		return false
+	case os.Getenv("NO_COLOR") != "":
+		return false
+	case os.Getenv("TERM") == "dumb":
+		return false
+	}
+	return IsTerminal(w)
+}
+
+// IsTerminal reports whether w is a character device, such as a TTY.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// hostResult is one host's attempt within a fan-out.
+type hostResult struct {
+	host   string
+	result exec.CommandResult
+}
+
+// fanOut runs attempt for every host concurrently, returning the results
+// in host order.
+func fanOut(ctx context.Context, hosts []string, attempt func(ctx context.Context, host string) exec.CommandResult) []hostResult {
+	results := make([]hostResult, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			results[i] = hostResult{host: host, result: attempt(ctx, host)}
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
+
+// aggregateHosts folds per-host results into a single attempt for the
+// check. Hosts that exit with a pass code (or WARN) count as passing and
+// SKIP hosts are left out of the ratio. When at least minRatio of the
+// rest pass, the attempt exits passExit (the check's first pass code), or
+// WARN if any host warned; otherwise it fails if any host failed and
+// errors if the shortfall is only hosts that errored. The output lists
+// each host's outcome and indented output, then the tally.
+func aggregateHosts(results []hostResult, minRatio float64, passCodes []int) exec.CommandResult {
+	passExit := engine.ExitPass
+	if len(passCodes) > 0 {
+		passExit = passCodes[0]
+	}
+
+	var agg exec.CommandResult
+	var out strings.Builder
+	passed, counted, failed, warned := 0, 0, 0, 0
+	for _, hr := range results {
+		res := hr.result
+		outcome := engine.OutcomeError
+		if res.Error == nil {
+			outcome = engine.OutcomeFromExitCode(engine.NormalizeExitCode(res.ExitCode, passCodes))
+		}
+
+		switch outcome {
+		case engine.OutcomePass:
+			passed++
+		case engine.OutcomeWarn:
+			passed++
+			warned++
+		case engine.OutcomeFail:
+			failed++
+		}
+		if outcome != engine.OutcomeSkip {
+			counted++
+		}
+
+		fmt.Fprintf(&out, "%s: %s (exit %d)", hr.host, outcome, res.ExitCode)
+		if res.Error != nil {
+			fmt.Fprintf(&out, ": %v", res.Error)
+		}
+		out.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimRight(res.Output, "\n"), "\n") {
+			if line != "" {
+				out.WriteString("  " + line + "\n")
+			}
+		}
+
+		agg.CPUTime += res.CPUTime
+		if res.MaxRSS > agg.MaxRSS {
+			agg.MaxRSS = res.MaxRSS
+		}
+	}
+
+	tally := fmt.Sprintf("%d/%d hosts passed (min_pass_ratio %g)", passed, counted, minRatio)
+	fmt.Fprintf(&out, "%s\n", tally)
+	agg.Output = out.String()
+
+	switch {
+	case counted == 0:
+		agg.ExitCode = engine.ExitSkip
+	case float64(passed) >= minRatio*float64(counted):
+		agg.ExitCode = passExit
+		if warned > 0 {
+			agg.ExitCode = engine.ExitWarn
+		}
+	case failed > 0:
+		agg.ExitCode = engine.ExitFail
+	default:
+		agg.ExitCode = engine.ExitError
+		agg.Error = fmt.Errorf("%s, the rest errored", tally)
+	}
+	return agg
+}
+
+// runOnGroup runs an ssh check's command on every host of its targets
+// group and aggregates the results.
+func (r *Runner) runOnGroup(ctx context.Context, check *config.Check, spec *config.SSHConfig, timeout time.Duration) exec.CommandResult {
+	opts := r.execOptions()
+	results := fanOut(ctx, r.Config.HostsFor(spec), func(ctx context.Context, host string) exec.CommandResult {
+		hostSpec := *spec
+		hostSpec.Host, hostSpec.Group = host, ""
+		return exec.RunCommandOpts(ctx, buildSSHCommand(&hostSpec, timeout), timeout, opts)
+	})
+	return aggregateHosts(results, spec.GetMinPassRatio(), check.PassExitCodes())
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+)
+
+// fixture tracks a shared fixture's lifecycle within a run.
+type fixture struct {
+	name   string
+	config config.FixtureConfig
+
+	mu          sync.Mutex
+	started     bool
+	setupErr    error
+	teardownErr error
+
+	// remaining is the number of checks using the fixture that haven't
+	// finished yet; the fixture is torn down when it reaches zero.
+	remaining int
+}
+
+// newFixtures returns the run's fixtures, each counting the checks that
+// use it.
+func (r *Runner) newFixtures() map[string]*fixture {
+	fixtures := make(map[string]*fixture, len(r.Config.Fixtures))
+	for name, cfg := range r.Config.Fixtures {
+		fixtures[name] = &fixture{name: name, config: cfg}
+	}
+	for _, check := range r.Config.Checks {
+		for _, name := range check.Uses {
+			if f := fixtures[name]; f != nil {
+				f.remaining++
+			}
+		}
+	}
+	return fixtures
+}
+
+// acquireFixtures sets up the fixtures a check uses that aren't up yet,
+// in order. A fixture whose setup failed fails every check that uses it
+// with the same error, without being retried.
+func (r *Runner) acquireFixtures(ctx context.Context, check *config.Check) error {
+	for _, name := range check.Uses {
+		f := r.fixtures[name]
+		if f == nil {
+			continue
+		}
+		f.mu.Lock()
+		if !f.started {
+			f.started = true
+			f.setupErr = r.runPhase(ctx, "fixture "+name+" setup", f.config.Setup, r.DefaultTimeout, r.execOptions())
+		}
+		err := f.setupErr
+		f.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseFixtures marks a check as done with its fixtures, tearing down
+// each one whose last user it was.
+func (r *Runner) releaseFixtures(check *config.Check) {
+	for _, name := range check.Uses {
+		f := r.fixtures[name]
+		if f == nil {
+			continue
+		}
+		f.mu.Lock()
+		f.remaining--
+		if f.remaining <= 0 {
+			r.teardownFixture(f)
+		}
+		f.mu.Unlock()
+	}
+}
+
+// teardownFixtures tears down fixtures still up at the end of a run,
+// e.g. after a gating failure stopped it before their last user, and
+// returns the run's fixture teardown errors joined.
+func (r *Runner) teardownFixtures() error {
+	names := make([]string, 0, len(r.fixtures))
+	for name := range r.fixtures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		f := r.fixtures[name]
+		f.mu.Lock()
+		r.teardownFixture(f)
+		if f.teardownErr != nil {
+			errs = append(errs, f.teardownErr)
+		}
+		f.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// teardownFixture runs a started fixture's teardown, even if its setup
+// failed part way, and records any error. The caller holds f.mu.
+func (r *Runner) teardownFixture(f *fixture) {
+	if !f.started {
+		return
+	}
+	f.started = false
+
+	// Tear down even if the run was interrupted
+	f.teardownErr = r.runPhase(context.Background(), "fixture "+f.name+" teardown", f.config.Teardown, r.DefaultTimeout, r.execOptions())
+}
@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+)
+
+// buildExecInPodCommand builds a kubectl exec command for an exec_in_pod
+// check. With a selector, the first matching pod is resolved first; if
+// none matches, the command exits 2 (ERROR).
+func buildExecInPodCommand(spec *config.ExecInPodConfig, kubeContext string) string {
+	kubectl := []string{"kubectl"}
+	if kubeContext != "" {
+		kubectl = append(kubectl, "--context="+exec.ShellQuote(kubeContext))
+	}
+	if spec.Namespace != "" {
+		kubectl = append(kubectl, "-n", exec.ShellQuote(spec.Namespace))
+	}
+	base := strings.Join(kubectl, " ")
+
+	target := exec.ShellQuote(spec.Pod)
+	var resolve string
+	if spec.Selector != "" {
+		resolve = "pod=$(" + base + " get pods -l " + exec.ShellQuote(spec.Selector) +
+			" -o jsonpath='{.items[0].metadata.name}' 2>/dev/null) && [ -n \"$pod\" ] || " +
+			"{ echo no pod matches selector " + exec.ShellQuote(spec.Selector) + "; exit 2; }; "
+		target = `"$pod"`
+	}
+
+	command := base + " exec " + target
+	if spec.Container != "" {
+		command += " -c " + exec.ShellQuote(spec.Container)
+	}
+	command += " -- sh -c " + exec.ShellQuote(spec.Command)
+
+	return resolve + command
+}
+
+// buildSSHCommand builds a non-interactive ssh command for an ssh check.
+// The remote command's exit code is the check's exit code; ssh's own
+// connection failures (255) classify as ERROR. A pinned host key is
+// written to a temporary known_hosts file that is the only one consulted
+// for the target (jump hosts still use the normal known_hosts).
+func buildSSHCommand(spec *config.SSHConfig, timeout time.Duration) string {
+	connectTimeout := int(timeout.Seconds())
+	if connectTimeout < 1 {
+		connectTimeout = 1
+	}
+
+	args := []string{"ssh", "-o", "BatchMode=yes", "-o", fmt.Sprintf("ConnectTimeout=%d", connectTimeout)}
+	if spec.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(spec.Port))
+	}
+	if spec.Key != "" {
+		args = append(args, "-i", exec.ShellQuote(spec.Key), "-o", "IdentitiesOnly=yes")
+	}
+	if spec.Jump != "" {
+		args = append(args, "-J", exec.ShellQuote(spec.Jump))
+	}
+	if spec.HostKey != "" {
+		args = append(args, "-o", `UserKnownHostsFile="$known_hosts"`, "-o", "GlobalKnownHostsFile=/dev/null", "-o", "StrictHostKeyChecking=yes")
+	}
+
+	target := spec.Host
+	if spec.User != "" {
+		target = spec.User + "@" + spec.Host
+	}
+	args = append(args, exec.ShellQuote(target), "--", exec.ShellQuote(spec.Command))
+	command := strings.Join(args, " ")
+
+	if spec.HostKey == "" {
+		return command
+	}
+	entry := spec.Host
+	if spec.Port > 0 && spec.Port != 22 {
+		entry = fmt.Sprintf("[%s]:%d", spec.Host, spec.Port)
+	}
+	return fmt.Sprintf(`known_hosts=$(mktemp) || exit 2; printf '%%s\n' %s >"$known_hosts"; %s; rc=$?; rm -f "$known_hosts"; exit $rc`,
+		exec.ShellQuote(entry+" "+spec.HostKey), command)
+}
+
+// buildFileCommand builds a POSIX shell script for a file check, run
+// locally or wrapped in ssh. Each assertion prints an ok/FAIL line; any
+// failure exits 1 (FAIL), and a missing path fails immediately.
+func buildFileCommand(spec *config.FileConfig, timeout time.Duration) string {
+	p := path.Clean(spec.Path)
+	lines := []string{
+		"p=" + exec.ShellQuote(p) + "; fail=0",
+		assertFunc,
+		`[ -e "$p" ] || { echo "FAIL $p does not exist"; exit 1; }`,
+		`echo "ok   $p exists"`,
+	}
+
+	if spec.Mounted {
+		lines = append(lines, `check "awk -v p=\"\$p\" '\$2 == p { found = 1 } END { exit !found }' /proc/mounts" "mounted"`)
+	}
+	if max := spec.MaxAge.Duration; max > 0 {
+		lines = append(lines,
+			`age=$(( $(date +%s) - $(stat -c %Y "$p") ))`,
+			fmt.Sprintf(`check '[ "$age" -le %d ]' "modified ${age}s ago (max_age %s)"`, int64(max.Seconds()), max))
+	}
+	if spec.MinSize > 0 || spec.MaxSize > 0 {
+		lines = append(lines, `size=$(stat -c %s "$p")`)
+		if spec.MinSize > 0 {
+			lines = append(lines, fmt.Sprintf(`check '[ "$size" -ge %d ]' "size $size bytes (min_size %d)"`, spec.MinSize, spec.MinSize))
+		}
+		if spec.MaxSize > 0 {
+			lines = append(lines, fmt.Sprintf(`check '[ "$size" -le %d ]' "size $size bytes (max_size %d)"`, spec.MaxSize, spec.MaxSize))
+		}
+	}
+	if spec.SHA256 != "" {
+		want := strings.ToLower(spec.SHA256)
+		lines = append(lines,
+			`sum=$(sha256sum "$p" | cut -d' ' -f1)`,
+			fmt.Sprintf(`check '[ "$sum" = %s ]' "sha256 $sum (want %s)"`, want, want))
+	}
+	if spec.MinFree > 0 || spec.MinFreePercent > 0 {
+		lines = append(lines,
+			`set -- $(df -Pk "$p" | awk 'NR == 2 { print $4, 100 - $5 }')`,
+			`free=$(( $1 * 1024 )); pct=$2`)
+		if spec.MinFree > 0 {
+			lines = append(lines, fmt.Sprintf(`check '[ "$free" -ge %d ]' "$free bytes free (min_free %d)"`, spec.MinFree, spec.MinFree))
+		}
+		if spec.MinFreePercent > 0 {
+			lines = append(lines, fmt.Sprintf(`check '[ "$pct" -ge %d ]' "${pct}%% free (min_free_percent %d)"`, spec.MinFreePercent, spec.MinFreePercent))
+		}
+	}
+	lines = append(lines, `exit $fail`)
+
+	return onHost(strings.Join(lines, "\n"), spec.SSH, timeout)
+}
+
+// buildSystemdCommand builds a shell script asserting systemd units are
+// active (and enabled, if required), run locally or wrapped in ssh. Any
+// unit in the wrong state exits 1 (FAIL).
+func buildSystemdCommand(spec *config.SystemdConfig, timeout time.Duration) string {
+	lines := []string{"fail=0", assertFunc}
+	for _, unit := range spec.Units {
+		u := exec.ShellQuote(unit)
+		lines = append(lines, fmt.Sprintf(`check 'systemctl is-active --quiet %s' "%s active ($(systemctl is-active %s))"`, exec.ShellQuote(u), unit, u))
+		if spec.Enabled {
+			lines = append(lines, fmt.Sprintf(`check 'systemctl is-enabled --quiet %s' "%s enabled ($(systemctl is-enabled %s))"`, exec.ShellQuote(u), unit, u))
+		}
+	}
+	lines = append(lines, `exit $fail`)
+
+	return onHost(strings.Join(lines, "\n"), spec.SSH, timeout)
+}
+
+// assertFunc defines check CONDITION DESCRIPTION for generated scripts: it
+// prints an ok/FAIL line and records failures in $fail.
+const assertFunc = `check() { if eval "$1"; then echo "ok   $2"; else echo "FAIL $2"; fail=1; fi; }`
+
+// onHost wraps a generated script in ssh when a remote host is set.
+func onHost(script string, remote *config.SSHConfig, timeout time.Duration) string {
+	if remote == nil {
+		return script
+	}
+	spec := *remote
+	spec.Command = script
+	return buildSSHCommand(&spec, timeout)
+}
@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+)
+
+// FailureGroup is the results needing attention for one owner or team.
+type FailureGroup struct {
+	// Key is the owner or team, or "" for checks without one.
+	Key string
+
+	Results []CheckExecutionResult
+}
+
+// FailuresBy groups the results that need attention (FAIL, ERROR, and
+// XPASS) by their check's owner or team (by is "owner" or "team"). Groups
+// are sorted by key, with checks that have none last, and keep run order
+// within a group.
+func (result *RunResult) FailuresBy(by string) []FailureGroup {
+	var groups []FailureGroup
+	index := make(map[string]int)
+	for _, res := range result.Results {
+		switch res.Result.Outcome {
+		case engine.OutcomeFail, engine.OutcomeError, engine.OutcomeXPass:
+		default:
+			continue
+		}
+
+		key := groupKey(res.Check, by)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, FailureGroup{Key: key})
+		}
+		groups[i].Results = append(groups[i].Results, res)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Key == "") != (groups[j].Key == "") {
+			return groups[j].Key == ""
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// groupKey returns a check's owner or team.
+func groupKey(check *config.Check, by string) string {
+	if by == "team" {
+		return check.Team
+	}
+	return check.Owner
+}
+
+// printFailuresBy prints the run's failures grouped by r.GroupBy, one
+// line per owner or team, so it is clear who picks up what.
+func (r *Runner) printFailuresBy(result *RunResult) {
+	groups := result.FailuresBy(r.GroupBy)
+	if len(groups) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(r.Output, "\nFailures by %s:\n", r.GroupBy)
+	for _, g := range groups {
+		key := g.Key
+		if key == "" {
+			key = "(no " + r.GroupBy + ")"
+		}
+		checks := make([]string, len(g.Results))
+		for i, res := range g.Results {
+			checks[i] = fmt.Sprintf("%s (%s%s%s)", res.Check.Name,
+				r.color(res.Result.Outcome), r.consoleStyle().Label(string(res.Result.Outcome)), r.colorReset())
+		}
+		_, _ = fmt.Fprintf(r.Output, "  %s: %s\n", key, strings.Join(checks, ", "))
+	}
+}
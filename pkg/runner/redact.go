@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/erauner/homelab-smoke/pkg/engine"
+)
+
+// redacted replaces secret values in output.
+const redacted = "***"
+
+// newRedactor returns a replacer that masks secret values, or nil if there
+// are none. Longer values are matched first, so a secret containing
+// another is masked whole.
+func newRedactor(secrets map[string]string) *strings.Replacer {
+	values := make([]string, 0, len(secrets))
+	for _, v := range secrets {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	pairs := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		pairs = append(pairs, v, redacted)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// redact masks secret values in s.
+func (r *Runner) redact(s string) string {
+	if r.redactor == nil {
+		return s
+	}
+	return r.redactor.Replace(s)
+}
+
+// redactResult masks secret values in a result's output, reason, and
+// diagnostics before it is printed or reported.
+func (r *Runner) redactResult(result *engine.CheckResult) {
+	if r.redactor == nil {
+		return
+	}
+	result.Output = r.redact(result.Output)
+	result.OutcomeReason = r.redact(result.OutcomeReason)
+	for i := range result.Diagnostics {
+		d := &result.Diagnostics[i]
+		d.Command = r.redact(d.Command)
+		d.Output = r.redact(d.Output)
+	}
+}
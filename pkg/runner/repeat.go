@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+)
+
+// PassRate is a check's results across repeated runs of a suite.
+type PassRate struct {
+	Check *config.Check
+
+	// Runs is the number of runs the check ran in; it is lower than the
+	// number of runs when a gating failure stopped a run before it.
+	Runs int
+
+	// Passes is the number of runs the check passed in.
+	Passes int
+
+	// Flakes is the number of runs the check passed in only after
+	// retries.
+	Flakes int
+
+	// Outcomes counts each outcome the check had.
+	Outcomes map[engine.Outcome]int
+}
+
+// Deterministic reports whether the check had the same outcome every
+// time it ran.
+func (p PassRate) Deterministic() bool {
+	return len(p.Outcomes) <= 1
+}
+
+// PassRates aggregates per-check results across repeated runs, in the
+// order checks first ran.
+func PassRates(runs []*RunResult) []PassRate {
+	var rates []PassRate
+	index := make(map[string]int)
+	for _, run := range runs {
+		for _, res := range run.Results {
+			id := res.Check.GetID()
+			i, ok := index[id]
+			if !ok {
+				i = len(rates)
+				index[id] = i
+				rates = append(rates, PassRate{Check: res.Check, Outcomes: make(map[engine.Outcome]int)})
+			}
+			rates[i].Runs++
+			rates[i].Outcomes[res.Result.Outcome]++
+			if res.Result.IsPass() {
+				rates[i].Passes++
+			}
+			if res.Result.Flaky {
+				rates[i].Flakes++
+			}
+		}
+	}
+	return rates
+}
+
+// PrintPassRates prints each check's pass rate (its availability) and
+// flake rate under a title, marking checks that changed outcome between
+// runs or were flaky as unstable.
+func (r *Runner) PrintPassRates(title string, rates []PassRate) {
+	_, _ = fmt.Fprintf(r.Output, "\n========================================\n")
+	_, _ = fmt.Fprintf(r.Output, "%s:\n", title)
+
+	unstable := 0
+	for _, p := range rates {
+		var outcomes []string
+		for _, o := range engine.AllOutcomes() {
+			if n := p.Outcomes[o]; n > 0 {
+				outcomes = append(outcomes, fmt.Sprintf("%d %s", n, r.consoleStyle().Label(string(o))))
+			}
+		}
+		if p.Flakes > 0 {
+			outcomes = append(outcomes, fmt.Sprintf("%d%% flaky", p.Flakes*100/p.Runs))
+		}
+
+		color, note := r.color(engine.OutcomePass), ""
+		switch {
+		case !p.Deterministic() || p.Flakes > 0:
+			unstable++
+			color, note = r.color(engine.OutcomeWarn), " - UNSTABLE"
+		case p.Passes == 0:
+			color = r.color(engine.OutcomeFail)
+		}
+		_, _ = fmt.Fprintf(r.Output, "  %s%3d%%  %d/%d  %s (%s)%s%s\n",
+			color, p.Passes*100/p.Runs, p.Passes, p.Runs, p.Check.Name, strings.Join(outcomes, ", "), note, r.colorReset())
+	}
+
+	if unstable > 0 {
+		_, _ = fmt.Fprintf(r.Output, "%s%d check(s) changed outcome between runs or were flaky%s\n", r.color(engine.OutcomeWarn), unstable, r.colorReset())
+	} else {
+		_, _ = fmt.Fprintf(r.Output, "Every check had the same outcome in every run\n")
+	}
+	_, _ = fmt.Fprintf(r.Output, "========================================\n")
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/engine"
+)
+
+// RootCause collapses cascading failures into their probable cause: the
+// failed checks of the lowest layer with failures, which later layers
+// build on.
+type RootCause struct {
+	// Layer is the lowest layer with a FAIL or ERROR.
+	Layer int
+
+	// Causes are that layer's failed and errored checks.
+	Causes []CheckExecutionResult
+
+	// Downstream counts checks in later layers that failed, errored, or
+	// were skipped (other than disabled checks), plus checks that didn't
+	// run because the run stopped early.
+	Downstream int
+}
+
+// RootCause returns the probable root cause of the run's failures, or nil
+// if no later-layer checks were affected (nothing to collapse).
+func (result *RunResult) RootCause() *RootCause {
+	failed := func(o engine.Outcome) bool { return o == engine.OutcomeFail || o == engine.OutcomeError }
+
+	rc := &RootCause{}
+	found := false
+	for _, r := range result.Results {
+		if !failed(r.Result.Outcome) {
+			continue
+		}
+		if !found || r.Check.Layer < rc.Layer {
+			rc.Layer = r.Check.Layer
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	now := time.Now()
+	for _, r := range result.Results {
+		switch {
+		case r.Check.Layer == rc.Layer && failed(r.Result.Outcome):
+			rc.Causes = append(rc.Causes, r)
+		case r.Check.Layer > rc.Layer && failed(r.Result.Outcome):
+			rc.Downstream++
+		case r.Check.Layer > rc.Layer && r.Result.Outcome == engine.OutcomeSkip && !r.Check.Disabled.IsActive(now):
+			rc.Downstream++
+		}
+	}
+	rc.Downstream += result.TotalCount - len(result.Results)
+
+	if rc.Downstream == 0 {
+		return nil
+	}
+	return rc
+}
@@ -2,18 +2,25 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/erauner/homelab-smoke/pkg/config"
 	"github.com/erauner/homelab-smoke/pkg/engine"
 	"github.com/erauner/homelab-smoke/pkg/exec"
+	"github.com/erauner/homelab-smoke/pkg/probe"
+	"github.com/erauner/homelab-smoke/pkg/transform"
 	"github.com/erauner/homelab-smoke/pkg/validate"
 )
 
@@ -40,8 +47,63 @@ type Runner struct {
 	// Verbose enables verbose output.
 	Verbose bool
 
+	// FlakyAsWarn reports checks that passed only after retries as WARN
+	// instead of PASS.
+	FlakyAsWarn bool
+
+	// GroupBy groups failures in the summary by check owner or team
+	// ("owner" or "team"; empty for no grouping).
+	GroupBy string
+
+	// Quiet prints only checks that need attention (FAIL, WARN, ERROR,
+	// XPASS) and the summary, leaving out progress lines, layer
+	// separators, and passing or skipped checks.
+	Quiet bool
+
+	// NoColor disables ANSI colors in output.
+	NoColor bool
+
+	// Parallel is the maximum number of checks run concurrently within a
+	// layer (<= 1 runs checks sequentially).
+	Parallel int
+
+	// OnStart, if set, is called as each check starts running.
+	OnStart func(*config.Check)
+
+	// OnOutput, if set, is called with each line a command check writes
+	// while it runs, with secrets masked. Calls are serialized.
+	OnOutput func(check *config.Check, line string)
+
+	// OnResult, if set, is called with each result as soon as its check
+	// completes, so reporters can consume results incrementally. Calls are
+	// serialized, even when checks run in parallel.
+	OnResult func(CheckExecutionResult)
+
+	// RetainOutputBytes caps how much of each check's output is kept in
+	// RunResult once the result has been passed to OnResult (0 keeps
+	// everything). The tail is kept, since that is usually where
+	// errors are.
+	RetainOutputBytes int
+
 	// Output is the writer for check output.
 	Output io.Writer
+
+	// captured holds run-scoped variables extracted by check captures.
+	captured   map[string]string
+	capturedMu sync.Mutex
+
+	// outputMu serializes writes to Output from concurrent checks.
+	outputMu sync.Mutex
+
+	// redactor masks secret values in output; nil when there are none.
+	redactor *strings.Replacer
+
+	// fixtures tracks the run's shared fixtures.
+	fixtures map[string]*fixture
+
+	// streamNewline starts streamed output on a fresh line (sequential
+	// layers, where the cursor sits after the progress prefix).
+	streamNewline bool
 }
 
 // CheckExecutionResult holds the result of a single check execution.
@@ -58,8 +120,46 @@ type RunResult struct {
 	WarnCount   int
 	SkipCount   int
 	ErrorCount  int
+	XFailCount  int
+	XPassCount  int
 	TotalCount  int
 	GatingFails int
+
+	// FlakyCount is the number of checks that passed only after retries.
+	FlakyCount int
+
+	// TimedOutLayers lists the layers that ran out of their time budget.
+	TimedOutLayers []int
+
+	// SetupError is set when a setup command failed, so no checks ran.
+	SetupError error
+
+	// TeardownError is set when a teardown or fixture teardown command
+	// failed. It doesn't change the exit code, since the checks themselves
+	// ran.
+	TeardownError error
+}
+
+// layerBudget is a layer's total time budget.
+type layerBudget struct {
+	layer    int
+	budget   time.Duration
+	deadline time.Time
+}
+
+// newLayerBudget starts the budget for a layer, or returns nil if the
+// layer has none.
+func (r *Runner) newLayerBudget(layer int) *layerBudget {
+	budget := r.Config.LayerTimeout(layer)
+	if budget <= 0 {
+		return nil
+	}
+	return &layerBudget{layer: layer, budget: budget, deadline: time.Now().Add(budget)}
+}
+
+// exhausted reports whether the budget has run out.
+func (b *layerBudget) exhausted() bool {
+	return b != nil && !time.Now().Before(b.deadline)
 }
 
 // NewRunner creates a new Runner with the given configuration.
@@ -82,109 +182,869 @@ func (r *Runner) Run(ctx context.Context) *RunResult {
 		TotalCount: len(r.Config.Checks),
 	}
 
+	r.captured = make(map[string]string)
+	r.fixtures = r.newFixtures()
+	r.redactor = newRedactor(r.Vars.Secret)
+
+	// Set up, run the checks, and always tear down
+	if err := r.runPhase(ctx, "setup", r.Config.Setup, r.DefaultTimeout, r.execOptions()); err != nil {
+		result.SetupError = err
+		_, _ = fmt.Fprintf(r.Output, "\n[!] Setup failed - no checks run: %v\n", err)
+	} else {
+		r.runLayers(ctx, result)
+	}
+	fixtureErr := r.teardownFixtures()
+	teardownErr := r.runPhase(context.WithoutCancel(ctx), "teardown", r.Config.Teardown, r.DefaultTimeout, r.execOptions())
+	if err := errors.Join(fixtureErr, teardownErr); err != nil {
+		result.TeardownError = err
+		_, _ = fmt.Fprintf(r.Output, "\n[!] Teardown failed: %v\n", err)
+	}
+
+	return result
+}
+
+// runPhase runs setup, teardown, before, or after commands in order,
+// templated like check commands and each bounded by timeout, stopping at
+// the first failure.
+func (r *Runner) runPhase(ctx context.Context, phase string, commands []string, timeout time.Duration, opts exec.Options) error {
+	for _, command := range commands {
+		templated, err := config.ApplyTemplate(command, r.templateVars())
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", phase, command, err)
+		}
+		if r.Verbose {
+			// before/after phases run inside concurrent checks
+			r.outputMu.Lock()
+			_, _ = fmt.Fprintf(r.Output, "[%s] %s\n", phase, r.redact(templated))
+			r.outputMu.Unlock()
+		}
+
+		res := exec.RunCommandOpts(ctx, templated, timeout, opts)
+		if res.Error != nil {
+			return fmt.Errorf("%s %q: %w", phase, command, res.Error)
+		}
+		if res.ExitCode != 0 {
+			return fmt.Errorf("%s %q exited with code %d: %s", phase, command, res.ExitCode, r.redact(strings.TrimSpace(res.Output)))
+		}
+	}
+	return nil
+}
+
+// runLayers runs the checks layer by layer, recording results, and stops
+// after a layer with a gating failure that fails fast.
+func (r *Runner) runLayers(ctx context.Context, result *RunResult) {
 	// Sort checks by layer for fail-fast behavior
 	checks := r.sortByLayer(r.Config.Checks)
 
-	currentLayer := -1
+	progress := &progress{total: result.TotalCount, parallel: r.Parallel}
 
-	for i, check := range checks {
-		// Print layer separator if layer changed
-		if check.Layer != currentLayer && check.Layer > 0 {
-			currentLayer = check.Layer
-			_, _ = fmt.Fprintf(r.Output, "\n--- Layer %d ---\n", currentLayer)
+	index := 0
+	for _, layer := range groupByLayer(checks) {
+		// Print layer separator
+		if layer[0].Layer > 0 && !r.Quiet {
+			_, _ = fmt.Fprintf(r.Output, "\n--- Layer %d ---\n", layer[0].Layer)
 		}
 
-		// Print check progress
-		_, _ = fmt.Fprintf(r.Output, "[%d/%d] %s... ", i+1, result.TotalCount, check.Name)
-
-		// Execute the check
-		execResult := r.executeCheck(ctx, &check)
+		budget := r.newLayerBudget(layer[0].Layer)
 
-		// Print result
-		r.printResult(execResult)
+		var layerResults []CheckExecutionResult
+		r.streamNewline = r.Parallel <= 1 || len(layer) == 1
+		if !r.streamNewline {
+			progress.advanceTo(index)
+			layerResults = r.runLayerParallel(ctx, layer, budget, progress)
+		} else {
+			layerResults = r.runLayerSequential(ctx, layer, budget, index, result.TotalCount)
+		}
+		index += len(layer)
 
-		// Record result
-		result.Results = append(result.Results, CheckExecutionResult{
-			Check:  &check,
-			Result: execResult,
-		})
+		if budget.exhausted() {
+			result.TimedOutLayers = append(result.TimedOutLayers, budget.layer)
+		}
 
-		// Update counts
-		switch execResult.Outcome {
-		case engine.OutcomePass:
-			result.PassCount++
-		case engine.OutcomeFail:
-			result.FailCount++
-			if execResult.Gating {
-				result.GatingFails++
+		// Record results
+		stop := false
+		for _, execResult := range layerResults {
+			result.record(execResult)
+			if execResult.Result.IsGatingFailure() && r.shouldFailFast(execResult.Check.Layer) {
+				stop = true
 			}
-		case engine.OutcomeWarn:
-			result.WarnCount++
-		case engine.OutcomeSkip:
-			result.SkipCount++
-		case engine.OutcomeError:
-			result.ErrorCount++
 		}
 
 		// Fail fast on gating failure if enabled
-		if execResult.IsGatingFailure() && r.shouldFailFast() {
+		if stop {
 			_, _ = fmt.Fprintf(r.Output, "\n[!] Gating check failed - stopping execution\n")
 			break
 		}
 	}
+}
 
-	return result
+// runLayerSequential runs a layer's checks one at a time, streaming
+// progress as it goes and stopping at the first gating failure.
+func (r *Runner) runLayerSequential(ctx context.Context, layer []config.Check, budget *layerBudget, offset, total int) []CheckExecutionResult {
+	var results []CheckExecutionResult
+
+	for i := range layer {
+		check := &layer[i]
+
+		// Print check progress (after the fact in quiet mode)
+		prefix := fmt.Sprintf("[%d/%d] %s... ", offset+i+1, total, check.Name)
+		if !r.Quiet {
+			_, _ = io.WriteString(r.Output, prefix)
+		}
+
+		// Execute the check
+		if r.OnStart != nil {
+			r.OnStart(check)
+		}
+		execResult := r.executeWithin(ctx, check, budget)
+
+		// Print result
+		if !r.Quiet {
+			r.printResult(r.Output, execResult)
+		} else if !r.silenced(execResult) {
+			_, _ = io.WriteString(r.Output, prefix)
+			r.printResult(r.Output, execResult)
+		}
+
+		results = append(results, r.complete(CheckExecutionResult{Check: check, Result: execResult}))
+
+		if execResult.IsGatingFailure() && r.shouldFailFast(check.Layer) {
+			break
+		}
+	}
+
+	return results
+}
+
+// runLayerParallel runs a layer's checks on a pool of up to r.Parallel
+// workers; the call returns only when the whole layer is done, acting as a
+// barrier before the next layer. Each check's output is buffered and
+// flushed atomically when it completes, so concurrent output never
+// interleaves. Results are returned in config order.
+func (r *Runner) runLayerParallel(ctx context.Context, layer []config.Check, budget *layerBudget, progress *progress) []CheckExecutionResult {
+	results := make([]CheckExecutionResult, len(layer))
+	jobs := make(chan int)
+
+	workers := r.Parallel
+	if workers > len(layer) {
+		workers = len(layer)
+	}
+
+	var wg sync.WaitGroup
+	var resultsMu sync.Mutex
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				check := &layer[i]
+
+				progress.start()
+				if r.OnStart != nil {
+					r.OnStart(check)
+				}
+				execResult := r.executeWithin(ctx, check, budget)
+				status := progress.finish(execResult.Duration)
+
+				if !r.silenced(execResult) {
+					var buf bytes.Buffer
+					_, _ = fmt.Fprintf(&buf, "[%s] %s... ", status, check.Name)
+					r.printResult(&buf, execResult)
+
+					r.outputMu.Lock()
+					_, _ = r.Output.Write(buf.Bytes())
+					r.outputMu.Unlock()
+				}
+
+				resultsMu.Lock()
+				results[i] = r.complete(CheckExecutionResult{Check: check, Result: execResult})
+				resultsMu.Unlock()
+			}
+		}()
+	}
+
+	for i := range layer {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+	return results
 }
 
-// executeCheck runs a single check and returns the classified result.
+// complete passes a finished check's result to OnResult, then caps its
+// retained output, so a large layer doesn't hold every check's full output
+// until the layer ends.
+func (r *Runner) complete(execResult CheckExecutionResult) CheckExecutionResult {
+	if r.OnResult != nil {
+		r.OnResult(execResult)
+	}
+	if r.RetainOutputBytes > 0 {
+		execResult.Result.Output = truncateOutput(execResult.Result.Output, r.RetainOutputBytes)
+		for i := range execResult.Result.Diagnostics {
+			d := &execResult.Result.Diagnostics[i]
+			d.Output = truncateOutput(d.Output, r.RetainOutputBytes)
+		}
+	}
+	return execResult
+}
+
+// silenced reports whether quiet mode leaves a result out of the output.
+func (r *Runner) silenced(result *engine.CheckResult) bool {
+	if !r.Quiet {
+		return false
+	}
+	switch result.Outcome {
+	case engine.OutcomePass, engine.OutcomeSkip, engine.OutcomeXFail:
+		return true
+	}
+	return false
+}
+
+// progress tracks live run counts for the parallel progress line.
+type progress struct {
+	mu       sync.Mutex
+	total    int
+	done     int
+	running  int
+	parallel int
+	elapsed  time.Duration
+}
+
+// start marks a check as running.
+func (p *progress) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running++
+}
+
+// finish marks a check as done and returns the progress status, e.g.
+// "3/10 | 2 running, 5 queued | ETA 12s".
+func (p *progress) finish(d time.Duration) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.running--
+	p.done++
+	p.elapsed += d
+
+	queued := p.total - p.done - p.running
+	status := fmt.Sprintf("%d/%d | %d running, %d queued", p.done, p.total, p.running, queued)
+	if eta := p.eta(); eta > 0 {
+		status += fmt.Sprintf(" | ETA %s", eta)
+	}
+	return status
+}
+
+// advanceTo records checks completed outside the pool (sequential layers).
+func (p *progress) advanceTo(done int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if done > p.done {
+		p.done = done
+	}
+}
+
+// eta estimates the remaining time from the mean duration of completed
+// checks, spread across the worker pool. Caller must hold p.mu.
+func (p *progress) eta() time.Duration {
+	remaining := p.total - p.done
+	if p.done == 0 || remaining <= 0 {
+		return 0
+	}
+	avg := p.elapsed / time.Duration(p.done)
+	workers := p.parallel
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > remaining {
+		workers = remaining
+	}
+	batches := (remaining + workers - 1) / workers
+	return (avg * time.Duration(batches)).Round(time.Second)
+}
+
+// record adds a check result to the run totals.
+func (result *RunResult) record(execResult CheckExecutionResult) {
+	result.Results = append(result.Results, execResult)
+	if execResult.Result.Flaky {
+		result.FlakyCount++
+	}
+
+	switch execResult.Result.Outcome {
+	case engine.OutcomePass:
+		result.PassCount++
+	case engine.OutcomeFail:
+		result.FailCount++
+		if execResult.Result.Gating {
+			result.GatingFails++
+		}
+	case engine.OutcomeWarn:
+		result.WarnCount++
+	case engine.OutcomeSkip:
+		result.SkipCount++
+	case engine.OutcomeError:
+		result.ErrorCount++
+	case engine.OutcomeXFail:
+		result.XFailCount++
+	case engine.OutcomeXPass:
+		result.XPassCount++
+	}
+}
+
+// truncateOutput keeps at most the last max bytes of output, noting how
+// much was dropped. The cut never splits a UTF-8 character.
+func truncateOutput(output string, max int) string {
+	if len(output) <= max {
+		return output
+	}
+	dropped := len(output) - max
+	for dropped < len(output) && !utf8.RuneStart(output[dropped]) {
+		dropped++
+	}
+	return fmt.Sprintf("[... %d bytes truncated]\n%s", dropped, output[dropped:])
+}
+
+// groupByLayer splits layer-sorted checks into consecutive same-layer groups.
+func groupByLayer(checks []config.Check) [][]config.Check {
+	var layers [][]config.Check
+	for i := 0; i < len(checks); {
+		j := i + 1
+		for j < len(checks) && checks[j].Layer == checks[i].Layer {
+			j++
+		}
+		layers = append(layers, checks[i:j])
+		i = j
+	}
+	return layers
+}
+
+// executeCheck runs a single check and returns the classified result,
+// including its wall-clock duration and, on failure, its diagnostics.
 func (r *Runner) executeCheck(ctx context.Context, check *config.Check) *engine.CheckResult {
+	start := time.Now()
+	result := r.evaluateCheck(ctx, check)
+	result.Duration = time.Since(start)
+
+	// Flag passing checks that were slow
+	if max := check.MaxDuration(); max > 0 {
+		result.ApplyMaxDuration(max)
+	}
+
+	// Surface checks that only passed after retries
+	if r.FlakyAsWarn {
+		result.ApplyFlaky()
+	}
+
+	// Collect diagnostics for failures, outside the check's own duration
+	if result.Outcome == engine.OutcomeFail || result.Outcome == engine.OutcomeError {
+		result.Diagnostics = r.runDiagnostics(ctx, check)
+	}
+
+	r.redactResult(result)
+	return result
+}
+
+// executeWithin runs a check unless its layer's budget has run out, in
+// which case the check is skipped without starting. Either way the check
+// is done with its fixtures afterwards.
+func (r *Runner) executeWithin(ctx context.Context, check *config.Check, budget *layerBudget) *engine.CheckResult {
+	defer r.releaseFixtures(check)
+	if budget.exhausted() {
+		return skipResult(check, fmt.Sprintf("layer %d timed out (budget %s)", budget.layer, budget.budget))
+	}
+	return r.executeCheck(ctx, check)
+}
+
+// runDiagnostics runs a failed check's diagnostics commands in order,
+// each with the check's timeout. Commands are templated like the check,
+// including captured values.
+func (r *Runner) runDiagnostics(ctx context.Context, check *config.Check) []engine.Diagnostic {
+	if r.Config == nil {
+		return nil
+	}
+	commands := r.Config.DiagnosticsFor(check)
+	if len(commands) == 0 {
+		return nil
+	}
+
+	timeout := check.GetTimeout(r.DefaultTimeout)
+	vars := r.templateVars()
+	diagnostics := make([]engine.Diagnostic, 0, len(commands))
+	for _, command := range commands {
+		if ctx.Err() != nil {
+			break
+		}
+		rendered, err := config.ApplyTemplate(command, vars)
+		if err != nil {
+			diagnostics = append(diagnostics, engine.Diagnostic{Command: command, Output: err.Error(), ExitCode: -1})
+			continue
+		}
+		res := exec.RunCommandOpts(ctx, rendered, timeout, r.checkOptions(check))
+		diag := engine.Diagnostic{Command: rendered, Output: res.Output, ExitCode: res.ExitCode}
+		if res.Error != nil {
+			diag.ExitCode = -1
+			diag.Output += res.Error.Error()
+		}
+		diagnostics = append(diagnostics, diag)
+	}
+	return diagnostics
+}
+
+// evaluateCheck templates, runs, and classifies a single check.
+func (r *Runner) evaluateCheck(ctx context.Context, check *config.Check) *engine.CheckResult {
+	// Disabled checks don't run at all
+	if now := time.Now(); check.Disabled.IsActive(now) {
+		return skipResult(check, check.Disabled.Describe(now))
+	}
+
 	// Apply template variables
-	templatedCheck, err := config.ApplyTemplateToCheck(check, r.Vars)
+	templatedCheck, err := config.ApplyTemplateToCheck(check, r.templateVars())
 	if err != nil {
 		return engine.ClassifyResult(-1, err, nil, check.IsGating())
 	}
 
 	timeout := check.GetTimeout(r.DefaultTimeout)
+	retryDelay := check.GetRetryDelay(r.RetryDelay)
 
-	// Determine command to run
-	var cmdResult exec.CommandResult
-	var attempts int
+	// Evaluate when/skip_if conditions
+	if check.When != "" {
+		ok, err := r.evalCondition(ctx, check, templatedCheck.When, timeout)
+		if err != nil {
+			return engine.ClassifyResult(-1, fmt.Errorf("when: %w", err), nil, check.IsGating())
+		}
+		if !ok {
+			return skipResult(check, fmt.Sprintf("condition not met (when: %s)", check.When))
+		}
+	}
+	if check.SkipIf != "" {
+		ok, err := r.evalCondition(ctx, check, templatedCheck.SkipIf, timeout)
+		if err != nil {
+			return engine.ClassifyResult(-1, fmt.Errorf("skip_if: %w", err), nil, check.IsGating())
+		}
+		if ok {
+			return skipResult(check, fmt.Sprintf("skip_if matched (%s)", check.SkipIf))
+		}
+	}
+
+	// Run the check's kind between its before and after commands, once
+	// its fixtures are up
+	var result *engine.CheckResult
+	if err := r.acquireFixtures(ctx, check); err != nil {
+		result = engine.ClassifyResult(-1, err, nil, check.IsGating())
+	} else if err := r.runPhase(ctx, "before", check.Before, timeout, r.checkOptions(check)); err != nil {
+		result = engine.ClassifyResult(-1, err, nil, check.IsGating())
+	} else {
+		result = r.runKind(ctx, check, templatedCheck, timeout, retryDelay)
+	}
+	afterErr := r.runPhase(context.WithoutCancel(ctx), "after", check.After, timeout, r.checkOptions(check))
+
+	// Extract captures for later checks
+	if result.IsPass() && len(check.Capture) > 0 {
+		r.applyCaptures(check, result)
+	}
+
+	// Apply central override rules (e.g., known benign errors)
+	if r.Config != nil {
+		if rule := r.Config.OverrideFor(check, string(result.Outcome), result.Output); rule != nil && rule.Outcome != string(result.Outcome) {
+			result.ApplyOverride(engine.Outcome(rule.Outcome), rule.Reason)
+		}
+	}
+
+	// Known-broken checks report XFAIL/XPASS instead of FAIL/PASS
+	if check.ExpectedFailure.IsActive(time.Now()) {
+		result.ApplyExpectedFailure(check.ExpectedFailure.Reason)
+	}
+
+	// Quarantined checks run but don't block
+	if check.Quarantine.IsActive(time.Now()) {
+		result.ApplyQuarantine(check.Quarantine.Reason)
+	}
 
-	if templatedCheck.Script != nil {
-		// Script-based check
-		command := r.buildScriptCommand(templatedCheck.Script)
-		if check.Retry {
-			cmdResult, attempts = exec.RunWithRetry(ctx, command, timeout, r.MaxRetries, r.RetryDelay)
+	// Failures during planned maintenance don't block
+	if r.Config != nil && (result.Outcome == engine.OutcomeFail || result.Outcome == engine.OutcomeError) {
+		if w := r.Config.MaintenanceFor(check, time.Now()); w != nil {
+			result.ApplyMaintenance(w.GetOutcome(), w.Describe())
+		}
+	}
+
+	// A failed cleanup turns a PASS into WARN, since it may leave state
+	// behind for later checks
+	if afterErr != nil {
+		if result.IsPass() {
+			result.Outcome = engine.OutcomeWarn
+			result.OutcomeReason = afterErr.Error()
 		} else {
-			cmdResult = exec.RunCommand(ctx, command, timeout)
-			attempts = 1
+			result.OutcomeReason = fmt.Sprintf("%s (%v)", result.OutcomeReason, afterErr)
 		}
-	} else if templatedCheck.Command != "" {
-		// Inline command
-		if check.Retry {
-			cmdResult, attempts = exec.RunWithRetry(ctx, templatedCheck.Command, timeout, r.MaxRetries, r.RetryDelay)
+	}
+
+	return result
+}
+
+// runKind runs the check's kind, degrading to its fallback command if the
+// primary one errored.
+func (r *Runner) runKind(ctx context.Context, check, templatedCheck *config.Check, timeout, retryDelay time.Duration) *engine.CheckResult {
+	var result *engine.CheckResult
+	switch {
+	case templatedCheck.GRPC != nil:
+		// Native gRPC health probe
+		spec := templatedCheck.GRPC
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return probe.GRPCHealth(ctx, spec, timeout)
+		})
+	case templatedCheck.SMTP != nil:
+		// Native mail relay probe
+		spec := templatedCheck.SMTP
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return probe.SMTP(ctx, spec, timeout)
+		})
+	case templatedCheck.NTP != nil:
+		// Native clock drift probe
+		spec := templatedCheck.NTP
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return probe.NTP(ctx, spec, timeout)
+		})
+	case templatedCheck.Certificate != nil:
+		// cert-manager Certificate readiness via kubectl
+		spec := templatedCheck.Certificate
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return probe.Certificates(ctx, spec, r.Vars.Context, timeout, r.execOptions())
+		})
+	case templatedCheck.GPU != nil:
+		// Device plugin resources (and optional Job) via kubectl
+		spec := templatedCheck.GPU
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return probe.GPUs(ctx, spec, r.Vars.Context, timeout, r.execOptions())
+		})
+	case templatedCheck.Ingress != nil:
+		// Discovered ingress hosts over HTTPS
+		spec := templatedCheck.Ingress
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return probe.Ingresses(ctx, spec, r.Vars.Context, timeout, r.execOptions())
+		})
+	case templatedCheck.External != nil:
+		// Public DNS and external vs internal content
+		spec := templatedCheck.External
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return probe.External(ctx, spec, timeout)
+		})
+	case templatedCheck.SSH != nil && templatedCheck.SSH.Group != "" && r.Config != nil:
+		// Command on every host of a targets group
+		spec := templatedCheck.SSH
+		result = r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+			return r.runOnGroup(ctx, check, spec, timeout)
+		})
+	default:
+		var command string
+		if templatedCheck.Script != nil {
+			// Script-based check
+			command = r.buildScriptCommand(templatedCheck.Script)
+		} else if templatedCheck.Command != "" {
+			// Inline command
+			command = templatedCheck.Command
+		} else if templatedCheck.ExecInPod != nil {
+			// Command inside a pod
+			command = buildExecInPodCommand(templatedCheck.ExecInPod, r.Vars.Context)
+		} else if templatedCheck.SSH != nil {
+			// Command on a remote host
+			command = buildSSHCommand(templatedCheck.SSH, timeout)
+		} else if templatedCheck.File != nil {
+			// Path assertions, locally or over SSH
+			command = buildFileCommand(templatedCheck.File, timeout)
+		} else if templatedCheck.Systemd != nil {
+			// Unit states, locally or over SSH
+			command = buildSystemdCommand(templatedCheck.Systemd, timeout)
 		} else {
-			cmdResult = exec.RunCommand(ctx, templatedCheck.Command, timeout)
-			attempts = 1
+			return engine.ClassifyResult(-1, fmt.Errorf("check has no command or script"), nil, check.IsGating())
+		}
+		result = r.runCommand(ctx, check, command, timeout, retryDelay)
+	}
+
+	// Degrade to the fallback command if the primary one errored
+	if result.Outcome == engine.OutcomeError && templatedCheck.FallbackCommand != "" && ctx.Err() == nil {
+		primaryReason := result.OutcomeReason
+		result = r.runCommand(ctx, check, templatedCheck.FallbackCommand, timeout, retryDelay)
+		result.Fallback = true
+		result.OutcomeReason = fmt.Sprintf("%s (via fallback_command; primary: %s)", result.OutcomeReason, primaryReason)
+	}
+
+	return result
+}
+
+// templateVars returns the runner's template variables with run-scoped
+// captured values merged into Custom.
+func (r *Runner) templateVars() config.TemplateVars {
+	r.capturedMu.Lock()
+	defer r.capturedMu.Unlock()
+
+	if len(r.captured) == 0 {
+		return r.Vars
+	}
+
+	vars := r.Vars
+	vars.Custom = make(map[string]string, len(r.Vars.Custom)+len(r.captured))
+	for k, v := range r.Vars.Custom {
+		vars.Custom[k] = v
+	}
+	for k, v := range r.captured {
+		vars.Custom[k] = v
+	}
+	return vars
+}
+
+// applyCaptures extracts capture values from a passing check's output.
+// A capture that doesn't match turns the result into FAIL, since later
+// checks depend on the value.
+func (r *Runner) applyCaptures(check *config.Check, result *engine.CheckResult) {
+	names := make([]string, 0, len(check.Capture))
+	for name := range check.Capture {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := config.ExtractCapture(check.Capture[name], result.Output)
+		if err != nil {
+			result.Outcome = engine.OutcomeFail
+			result.OutcomeReason = fmt.Sprintf("capture %s: %v", name, err)
+			return
+		}
+		r.capturedMu.Lock()
+		r.captured[name] = value
+		r.capturedMu.Unlock()
+	}
+}
+
+// evalCondition evaluates a rendered when/skip_if condition. Empty means
+// false, boolean literals are used directly, and anything else runs as a
+// shell command where exit 0 means true and exit 1 means false, confined
+// like the check's own command.
+func (r *Runner) evalCondition(ctx context.Context, check *config.Check, cond string, timeout time.Duration) (bool, error) {
+	if cond == "" {
+		return false, nil
+	}
+	if b, err := strconv.ParseBool(cond); err == nil {
+		return b, nil
+	}
+
+	res := exec.RunCommandOpts(ctx, cond, timeout, r.checkOptions(check))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	switch res.ExitCode {
+	case 0:
+		return true, nil
+	case 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("condition command exited with code %d", res.ExitCode)
+	}
+}
+
+// skipResult returns a SKIP result with the given reason.
+func skipResult(check *config.Check, reason string) *engine.CheckResult {
+	result := engine.ClassifyResult(engine.ExitSkip, nil, nil, check.IsGating())
+	result.OutcomeReason = reason
+	return result
+}
+
+// runCommand runs a shell command as a check attempt, streaming its
+// output live in verbose mode and to OnOutput.
+func (r *Runner) runCommand(ctx context.Context, check *config.Check, command string, timeout, retryDelay time.Duration) *engine.CheckResult {
+	opts := r.checkOptions(check)
+
+	// Stream output live in verbose mode
+	var streams []io.Writer
+	if r.Verbose {
+		stream := &lineWriter{
+			mu:             &r.outputMu,
+			w:              r.Output,
+			prefix:         fmt.Sprintf("  [%s] ", check.Name),
+			leadingNewline: r.streamNewline,
+			redact:         r.redact,
+		}
+		defer stream.Flush()
+		streams = append(streams, stream)
+	}
+	if r.OnOutput != nil {
+		lines := &lineWriter{
+			mu:     &r.outputMu,
+			redact: r.redact,
+			onLine: func(line string) { r.OnOutput(check, line) },
+		}
+		defer lines.Flush()
+		streams = append(streams, lines)
+	}
+	if len(streams) > 0 {
+		opts.Stream = io.MultiWriter(streams...)
+	}
+
+	result := r.runAndClassify(ctx, check, retryDelay, func(ctx context.Context) exec.CommandResult {
+		return exec.RunCommandOpts(ctx, command, timeout, opts)
+	})
+	result.Streamed = r.Verbose
+	return result
+}
+
+// runAndClassify runs an attempt (with retry or wait_for if enabled),
+// validates its output, and classifies the result. Retries are decided on
+// the classified result, so a check that fails as expected isn't retried.
+func (r *Runner) runAndClassify(ctx context.Context, check *config.Check, retryDelay time.Duration, attempt func(context.Context) exec.CommandResult) *engine.CheckResult {
+	if check.WaitFor != nil {
+		return r.waitFor(ctx, check, attempt)
+	}
+
+	var result *engine.CheckResult
+	var attempts int
+
+	start := time.Now()
+	if check.IsRetry() {
+		backoff := exec.Backoff{Delay: retryDelay}
+		if b := check.GetRetryBackoff(); b != nil {
+			backoff.Multiplier, backoff.MaxDelay, backoff.Jitter = b.Multiplier, b.MaxDelay.Duration, b.Jitter
+		}
+		// Retry on the classified result, so exit codes mapped to PASS by
+		// expect.exit_codes and expected failures aren't retried
+		var classified *engine.CheckResult
+		var cmdResult exec.CommandResult
+		cmdResult, attempts = exec.RetryBackoffIf(ctx, check.GetMaxRetries(r.MaxRetries), backoff, attempt, func(res exec.CommandResult) bool {
+			classified = r.classify(ctx, check, res, start)
+			return classified.ShouldRetry()
+		})
+		result = classified
+		if cmdResult.Error != nil && result.ExecutionError == nil {
+			// Canceled while waiting to retry
+			result = r.classify(ctx, check, cmdResult, start)
 		}
 	} else {
-		return engine.ClassifyResult(-1, fmt.Errorf("check has no command or script"), nil, check.IsGating())
+		result = r.classify(ctx, check, attempt(ctx), start)
+		attempts = 1
+	}
+
+	result.RetryCount = attempts - 1
+	result.Flaky = result.IsPass() && attempts > 1
+	return result
+}
+
+// waitFor re-runs an attempt until it classifies as PASS (after exit code
+// mapping and any negative expectation) or the check's wait_for timeout
+// passes, sleeping the interval between attempts. The last complete
+// attempt's result is returned, noting how long it was waited on; an
+// attempt the deadline cuts short only counts if it was the first.
+func (r *Runner) waitFor(ctx context.Context, check *config.Check, attempt func(context.Context) exec.CommandResult) *engine.CheckResult {
+	timeout, interval := check.WaitFor.Timeout.Duration, check.WaitFor.GetInterval()
+
+	start := time.Now()
+	parent := ctx
+	ctx, cancel := context.WithDeadline(ctx, start.Add(timeout))
+	defer cancel()
+
+	var last *engine.CheckResult
+	for attempts := 1; ; attempts++ {
+		result := r.classify(ctx, check, attempt(ctx), start)
+		result.RetryCount = attempts - 1
+		if result.IsPass() {
+			return result
+		}
+		if ctx.Err() != nil && parent.Err() == nil && last != nil {
+			result = last
+		}
+		last = result
+
+		// Stop once the next attempt would start past the deadline
+		deadline, _ := ctx.Deadline()
+		if time.Now().Add(interval).After(deadline) {
+			result.OutcomeReason = fmt.Sprintf("%s (still not passing after waiting %s, %d attempts)", result.OutcomeReason, timeout, attempts)
+			return result
+		}
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(interval):
+		}
+	}
+}
+
+// classify post-processes an attempt's output, validates it, and
+// classifies the result, including the check's negative expectation, so
+// retries see the final outcome. start is when the first attempt began.
+func (r *Runner) classify(ctx context.Context, check *config.Check, cmdResult exec.CommandResult, start time.Time) *engine.CheckResult {
+	// Apply per-check PASS exit codes
+	exitCode := cmdResult.ExitCode
+	if cmdResult.Error == nil {
+		exitCode = engine.NormalizeExitCode(exitCode, check.PassExitCodes())
 	}
 
-	// Validate output (only on exit 0)
+	// Post-process output; a failed transform on a PASS exit is a failed
+	// postcondition, otherwise the raw output is kept for diagnosis
 	var validationErrors []error
-	if cmdResult.ExitCode == 0 && cmdResult.Error == nil && check.Validate != nil {
+	if len(check.Transform) > 0 {
+		output, err := transform.Apply(ctx, cmdResult.Output, check.Transform)
+		cmdResult.Output = output
+		if err != nil && exitCode == 0 && cmdResult.Error == nil {
+			validationErrors = append(validationErrors, err)
+		}
+	}
+
+	// Validate output (only on PASS exit)
+	if exitCode == 0 && cmdResult.Error == nil && check.Validate != nil && len(validationErrors) == 0 {
 		validationErrors = validate.Output(cmdResult.Output, check.Validate)
+		if script := check.Validate.Script; script != "" && len(validationErrors) == 0 {
+			err := validate.Script(ctx, script, validate.ScriptEnv{
+				Output:   cmdResult.Output,
+				ExitCode: cmdResult.ExitCode,
+				Duration: time.Since(start),
+				Name:     check.Name,
+				ID:       check.GetID(),
+				Layer:    check.Layer,
+				Tags:     check.Tags,
+			})
+			if err != nil {
+				validationErrors = append(validationErrors, err)
+			}
+		}
 	}
 
 	// Classify the result
-	result := engine.ClassifyResult(cmdResult.ExitCode, cmdResult.Error, validationErrors, check.IsGating())
+	result := engine.ClassifyResult(exitCode, cmdResult.Error, validationErrors, check.IsGating())
+	if exitCode != cmdResult.ExitCode {
+		result.ExitCode = cmdResult.ExitCode
+		result.OutcomeReason = fmt.Sprintf("%s (exit code %d, expect.exit_codes %v)", result.OutcomeReason, cmdResult.ExitCode, check.PassExitCodes())
+	}
 	result.Output = cmdResult.Output
-	result.RetryCount = attempts - 1
+	result.CPUTime = cmdResult.CPUTime
+	result.MaxRSS = cmdResult.MaxRSS
+
+	// Negative tests pass when the command fails
+	if check.IsNegative() {
+		result.ApplyNegativeExpectation()
+	}
 
 	return result
 }
 
+// execOptions returns the base options for commands the runner starts,
+// applying the configured environment.
+func (r *Runner) execOptions() exec.Options {
+	if r.Config == nil {
+		return exec.Options{}
+	}
+	return exec.Options{Environ: r.Config.Environment.Environ(os.Environ())}
+}
+
+// checkOptions returns the options for every command a check runs: its
+// command, conditions, before and after commands, fallback, and
+// diagnostics. A sandboxed check's commands are all confined.
+func (r *Runner) checkOptions(check *config.Check) exec.Options {
+	opts := r.execOptions()
+	if s := check.Sandbox; s != nil {
+		opts.Sandbox = &exec.Sandbox{Network: s.Network, Root: s.Root}
+	}
+	return opts
+}
+
 // buildScriptCommand builds a command string from a script config.
 func (r *Runner) buildScriptCommand(script *config.ScriptConfig) string {
 	path := script.Path
@@ -199,7 +1059,7 @@ func (r *Runner) buildScriptCommand(script *config.ScriptConfig) string {
 	// Quote arguments for safe shell usage
 	args := make([]string, len(script.Args))
 	for i, arg := range script.Args {
-		args[i] = shellQuote(arg)
+		args[i] = exec.ShellQuote(arg)
 	}
 
 	return path + " " + strings.Join(args, " ")
@@ -217,32 +1077,51 @@ func (r *Runner) sortByLayer(checks []config.Check) []config.Check {
 	return sorted
 }
 
-// shouldFailFast returns true if execution should stop on gating failure.
-// For now, always fail fast - can be made configurable later.
-func (r *Runner) shouldFailFast() bool {
-	return true
+// shouldFailFast returns true if execution should stop on a gating
+// failure in the given layer, which is the default unless the layer is
+// configured with continue_on_failure.
+func (r *Runner) shouldFailFast(layer int) bool {
+	return r.Config == nil || !r.Config.ContinuesOnFailure(layer)
 }
 
 // printResult prints the check result with appropriate formatting.
-func (r *Runner) printResult(result *engine.CheckResult) {
-	color := result.Outcome.Color()
-	reset := engine.ColorReset()
+func (r *Runner) printResult(w io.Writer, result *engine.CheckResult) {
+	color := r.color(result.Outcome)
+	reset := r.colorReset()
 
-	_, _ = fmt.Fprintf(r.Output, "%s%s%s\n", color, result.Outcome, reset)
+	_, _ = fmt.Fprintf(w, "%s%s%s\n", color, r.consoleStyle().Label(string(result.Outcome)), reset)
 
-	if r.Verbose || result.Outcome == engine.OutcomeError || result.Outcome == engine.OutcomeFail {
+	if r.Verbose || result.Outcome == engine.OutcomeError || result.Outcome == engine.OutcomeFail || result.Outcome == engine.OutcomeXPass {
 		if result.OutcomeReason != "" {
-			_, _ = fmt.Fprintf(r.Output, "  Reason: %s\n", result.OutcomeReason)
+			_, _ = fmt.Fprintf(w, "  Reason: %s\n", result.OutcomeReason)
 		}
 		if result.RetryCount > 0 {
-			_, _ = fmt.Fprintf(r.Output, "  Retries: %d\n", result.RetryCount)
+			_, _ = fmt.Fprintf(w, "  Retries: %d\n", result.RetryCount)
 		}
 	}
 
-	if r.Verbose && result.Output != "" {
-		_, _ = fmt.Fprintf(r.Output, "  Output:\n")
+	for _, d := range result.Diagnostics {
+		_, _ = fmt.Fprintf(w, "  Diagnostic: %s", d.Command)
+		if d.ExitCode != 0 {
+			_, _ = fmt.Fprintf(w, " (exit %d)", d.ExitCode)
+		}
+		_, _ = fmt.Fprintln(w)
+		for _, line := range strings.Split(strings.TrimRight(d.Output, "\n"), "\n") {
+			_, _ = fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+
+	if r.Verbose && (result.CPUTime > 0 || result.MaxRSS > 0) {
+		_, _ = fmt.Fprintf(w, "  Resources: cpu %s, max RSS %.1f MiB\n",
+			result.CPUTime.Round(time.Millisecond), float64(result.MaxRSS)/(1<<20))
+	}
+
+	// Kinds that don't stream (native probes, ssh groups) show their
+	// output once done
+	if r.Verbose && !result.Streamed && strings.TrimSpace(result.Output) != "" {
+		_, _ = fmt.Fprintf(w, "  Output:\n")
 		for _, line := range strings.Split(strings.TrimSpace(result.Output), "\n") {
-			_, _ = fmt.Fprintf(r.Output, "    %s\n", line)
+			_, _ = fmt.Fprintf(w, "    %s\n", line)
 		}
 	}
 }
@@ -255,21 +1134,165 @@ func (r *Runner) PrintSummary(result *RunResult, duration string) {
 	_, _ = fmt.Fprintf(r.Output, "Summary: %d passed, %d failed, %d warnings, %d skipped, %d errors (out of %d total)\n",
 		result.PassCount, result.FailCount, result.WarnCount, result.SkipCount, result.ErrorCount, result.TotalCount)
 
+	if style := r.consoleStyle(); style != nil && len(style.Buckets) > 0 {
+		counts := result.OutcomeCounts()
+		parts := make([]string, len(style.Buckets))
+		for i, b := range style.Buckets {
+			parts[i] = fmt.Sprintf("%d %s", b.BucketTotal(counts), b.Name)
+		}
+		_, _ = fmt.Fprintf(r.Output, "Buckets: %s\n", strings.Join(parts, ", "))
+	}
+
+	if result.XFailCount > 0 || result.XPassCount > 0 {
+		_, _ = fmt.Fprintf(r.Output, "Expected failures: %d xfail, %d xpass\n", result.XFailCount, result.XPassCount)
+	}
+
 	if duration != "" {
 		_, _ = fmt.Fprintf(r.Output, "Total time: %s\n", duration)
 	}
 
+	for _, res := range result.Results {
+		if res.Result.Flaky {
+			_, _ = fmt.Fprintf(r.Output, "%sFLAKY: %s passed after %d retries%s\n",
+				r.color(engine.OutcomeWarn), res.Check.Name, res.Result.RetryCount, r.colorReset())
+		}
+	}
+
+	for _, res := range result.Results {
+		if res.Result.Outcome == engine.OutcomeXPass {
+			_, _ = fmt.Fprintf(r.Output, "%sXPASS: %s passed but is marked expected_failure - remove the marker%s\n",
+				r.color(engine.OutcomeXPass), res.Check.Name, r.colorReset())
+		}
+	}
+
+	for _, layer := range result.TimedOutLayers {
+		_, _ = fmt.Fprintf(r.Output, "%sTIMEOUT: layer %d ran out of its %s budget%s\n",
+			r.color(engine.OutcomeError), layer, r.Config.LayerTimeout(layer), r.colorReset())
+	}
+
+	now := time.Now()
+	for _, res := range result.Results {
+		if d := res.Check.Disabled; d.IsActive(now) {
+			_, _ = fmt.Fprintf(r.Output, "%sDISABLED: %s - %s%s\n",
+				r.color(engine.OutcomeSkip), res.Check.Name, strings.TrimPrefix(d.Describe(now), "disabled: "), r.colorReset())
+		}
+	}
+
+	for _, res := range result.Results {
+		if q := res.Check.Quarantine; q.IsActive(now) {
+			status := "still failing"
+			switch res.Result.Outcome {
+			case engine.OutcomePass:
+				status = "passing - consider lifting it"
+			case engine.OutcomeSkip:
+				status = "skipped"
+			}
+			_, _ = fmt.Fprintf(r.Output, "%sQUARANTINED: %s - %s [%s]%s\n",
+				r.color(engine.OutcomeWarn), res.Check.Name, q.Describe(now), status, r.colorReset())
+		}
+	}
+
+	if r.GroupBy != "" {
+		r.printFailuresBy(result)
+	}
+
+	if rc := result.RootCause(); rc != nil {
+		_, _ = fmt.Fprintf(r.Output, "\nProbable root cause (layer %d):\n", rc.Layer)
+		for _, c := range rc.Causes {
+			_, _ = fmt.Fprintf(r.Output, "  %s%s: %s%s - %s\n",
+				r.color(c.Result.Outcome), c.Check.Name, r.consoleStyle().Label(string(c.Result.Outcome)), r.colorReset(), c.Result.OutcomeReason)
+		}
+		_, _ = fmt.Fprintf(r.Output, "  %d downstream check(s) failed, skipped, or did not run\n", rc.Downstream)
+	}
+
 	if result.GatingFails > 0 {
 		_, _ = fmt.Fprintf(r.Output, "\n%s%d gating check(s) failed - deployment blocked%s\n",
-			engine.OutcomeFail.Color(), result.GatingFails, engine.ColorReset())
+			r.color(engine.OutcomeFail), result.GatingFails, r.colorReset())
 	}
 	_, _ = fmt.Fprintf(r.Output, "========================================\n")
 }
 
+// color returns the ANSI color for an outcome, or "" when colors are off.
+func (r *Runner) color(o engine.Outcome) string {
+	if r.NoColor {
+		return ""
+	}
+	return o.Color()
+}
+
+// colorReset returns the ANSI reset code, or "" when colors are off.
+func (r *Runner) colorReset() string {
+	if r.NoColor {
+		return ""
+	}
+	return engine.ColorReset()
+}
+
+// consoleStyle returns the console summary style from config, if any.
+func (r *Runner) consoleStyle() *config.SummaryStyle {
+	if r.Config == nil || r.Config.Summary == nil {
+		return nil
+	}
+	return r.Config.Summary.Console
+}
+
+// OutcomeCounts returns the number of results per outcome name.
+func (result *RunResult) OutcomeCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range result.Results {
+		counts[string(r.Result.Outcome)]++
+	}
+	return counts
+}
+
+// GatingFailures returns the results that block rollouts.
+func (result *RunResult) GatingFailures() []CheckExecutionResult {
+	var failures []CheckExecutionResult
+	for _, r := range result.Results {
+		if r.Result.IsGatingFailure() {
+			failures = append(failures, r)
+		}
+	}
+	return failures
+}
+
+// RunHookCommand runs a hook command with run context exposed as
+// environment variables:
+//
+//	SMOKE_CLUSTER        cluster name
+//	SMOKE_EXIT_CODE      CLI exit code for the run
+//	SMOKE_GATING_FAILS   number of blocking results
+//	SMOKE_FAILED_CHECKS  comma-separated IDs of blocking checks
+func (r *Runner) RunHookCommand(ctx context.Context, hook *config.HookConfig, result *RunResult) exec.CommandResult {
+	command, err := config.ApplyTemplate(hook.Command, r.templateVars())
+	if err != nil {
+		return exec.CommandResult{ExitCode: -1, Error: fmt.Errorf("failed to apply template to hook command: %w", err)}
+	}
+
+	failures := result.GatingFailures()
+	ids := make([]string, len(failures))
+	for i, f := range failures {
+		ids[i] = f.Check.GetID()
+	}
+
+	env := []string{
+		"SMOKE_CLUSTER=" + r.Vars.Cluster,
+		fmt.Sprintf("SMOKE_EXIT_CODE=%d", result.ExitCode()),
+		fmt.Sprintf("SMOKE_GATING_FAILS=%d", len(failures)),
+		"SMOKE_FAILED_CHECKS=" + strings.Join(ids, ","),
+	}
+
+	opts := r.execOptions()
+	opts.Env = env
+	res := exec.RunCommandOpts(ctx, command, hook.GetTimeout(60*time.Second), opts)
+	res.Output = r.redact(res.Output)
+	return res
+}
+
 // ExitCode returns the appropriate CLI exit code based on results.
-// 0 = all passed, 1 = gating failures, 2 = errors
+// 0 = all passed, 1 = gating failures, 2 = errors or a failed setup
 func (result *RunResult) ExitCode() int {
-	if result.ErrorCount > 0 {
+	if result.ErrorCount > 0 || result.SetupError != nil {
 		return 2
 	}
 	if result.GatingFails > 0 {
@@ -277,14 +1300,3 @@ func (result *RunResult) ExitCode() int {
 	}
 	return 0
 }
-
-// shellQuote quotes a string for safe shell usage.
-func shellQuote(s string) string {
-	if s == "" {
-		return "''"
-	}
-	if !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}|<>&;()") {
-		return s
-	}
-	return "'" + strings.ReplaceAll(s, "'", "'\"'\"'") + "'"
-}
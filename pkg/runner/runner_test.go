@@ -3,12 +3,22 @@ package runner
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/exec"
+	"github.com/erauner/homelab-smoke/pkg/transform"
 	"github.com/erauner/homelab-smoke/pkg/validate"
 )
 
@@ -63,6 +73,30 @@ func TestRunnerRun(t *testing.T) {
 	}
 }
 
+func TestRunnerNegativeTestRetry(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Not Public", Command: "exit 1", Retry: &config.RetryConfig{}, Expect: &config.ExpectConfig{Outcome: config.ExpectOutcomeFail}},
+			{Name: "Unexpectedly Public", Command: "exit 0", Retry: &config.RetryConfig{}, Expect: &config.ExpectConfig{Outcome: config.ExpectOutcomeFail}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.RetryDelay = time.Millisecond
+	r.MaxRetries = 2
+
+	result := r.Run(context.Background())
+
+	// The expected failure is final; the unexpected PASS is retried
+	if got := result.Results[0].Result; !got.IsPass() || got.RetryCount != 0 {
+		t.Errorf("expected PASS without retries, got %s after %d retries", got.Outcome, got.RetryCount)
+	}
+	if got := result.Results[1].Result; got.Outcome != engine.OutcomeFail || got.RetryCount != 2 {
+		t.Errorf("expected FAIL after 2 retries, got %s after %d retries", got.Outcome, got.RetryCount)
+	}
+}
+
 func TestRunnerWithNonGatingFail(t *testing.T) {
 	gatingFalse := false
 	cfg := &config.Config{
@@ -91,6 +125,240 @@ func TestRunnerWithNonGatingFail(t *testing.T) {
 	}
 }
 
+func TestRunnerLayerContinueOnFailure(t *testing.T) {
+	cfg := &config.Config{
+		Layers: map[int]config.LayerConfig{3: {ContinueOnFailure: true}},
+		Checks: []config.Check{
+			{Name: "Infra", Command: "true", Layer: 1},
+			{Name: "App A", Command: "exit 1", Layer: 3},
+			{Name: "App B", Command: "true", Layer: 3},
+			{Name: "Reports", Command: "true", Layer: 4},
+			{Name: "Reports Broken", Command: "exit 1", Layer: 4},
+			{Name: "Never", Command: "true", Layer: 5},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.MaxRetries = 0
+
+	result := r.Run(context.Background())
+
+	ran := make([]string, 0, len(result.Results))
+	for _, res := range result.Results {
+		ran = append(ran, res.Check.Name)
+	}
+	if got := strings.Join(ran, ","); got != "Infra,App A,App B,Reports,Reports Broken" {
+		t.Errorf("layer 3 should continue past its failure and layer 4 should stop the run, ran %s", got)
+	}
+	if result.GatingFails != 2 || result.ExitCode() != 1 {
+		t.Errorf("failures in a continuing layer still fail the run: %d gating fails, exit %d", result.GatingFails, result.ExitCode())
+	}
+}
+
+func TestRunnerLayerTimeout(t *testing.T) {
+	cfg := &config.Config{
+		Layers: map[int]config.LayerConfig{1: {Timeout: config.Duration{Duration: 100 * time.Millisecond}}},
+		Checks: []config.Check{
+			{Name: "Slow", Command: "sleep 0.2", Layer: 1},
+			{Name: "Left Over", Command: "true", Layer: 1},
+			{Name: "Next Layer", Command: "true", Layer: 2},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	var out bytes.Buffer
+	r.Output = &out
+	r.MaxRetries = 0
+
+	result := r.Run(context.Background())
+
+	if got := result.Results[0].Result; !got.IsPass() {
+		t.Errorf("expected the running check to finish, got %s", got.Outcome)
+	}
+	if got := result.Results[1].Result; got.Outcome != engine.OutcomeSkip || got.OutcomeReason != "layer 1 timed out (budget 100ms)" {
+		t.Errorf("expected the rest of the layer to be skipped, got %s: %s", got.Outcome, got.OutcomeReason)
+	}
+	if got := result.Results[2].Result; !got.IsPass() {
+		t.Errorf("expected the next layer to run, got %s", got.Outcome)
+	}
+	if len(result.TimedOutLayers) != 1 || result.TimedOutLayers[0] != 1 {
+		t.Errorf("expected layer 1 to be reported as timed out, got %v", result.TimedOutLayers)
+	}
+
+	r.PrintSummary(result, "")
+	if !strings.Contains(out.String(), "TIMEOUT: layer 1 ran out of its 100ms budget") {
+		t.Errorf("expected the summary to name the timed out layer:\n%s", out.String())
+	}
+}
+
+func TestRunnerPerCheckRetries(t *testing.T) {
+	one := 1
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Default Retries", Command: "exit 1", Retry: &config.RetryConfig{}, Expect: &config.ExpectConfig{Gating: new(bool)}},
+			{Name: "One Retry", Command: "exit 1", Retry: &config.RetryConfig{Max: &one, Delay: config.Duration{Duration: time.Millisecond}}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.MaxRetries = 2
+	r.RetryDelay = time.Millisecond
+
+	result := r.Run(context.Background())
+
+	if got := result.Results[0].Result.RetryCount; got != 2 {
+		t.Errorf("expected the runner's 2 retries, got %d", got)
+	}
+	if got := result.Results[1].Result.RetryCount; got != 1 {
+		t.Errorf("expected retry.max of 1 to override, got %d", got)
+	}
+}
+
+func TestRunnerFlaky(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Flaky", Command: fmt.Sprintf("test -f %[1]s || { touch %[1]s; exit 1; }", marker), Retry: &config.RetryConfig{}},
+			{Name: "Steady", Command: "true", Retry: &config.RetryConfig{}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	var out bytes.Buffer
+	r.Output = &out
+	r.RetryDelay = time.Millisecond
+	r.FlakyAsWarn = true
+
+	result := r.Run(context.Background())
+
+	flaky := result.Results[0].Result
+	if !flaky.Flaky || flaky.Outcome != engine.OutcomeWarn || flaky.OutcomeReason != "flaky: passed after 1 retries" {
+		t.Errorf("expected a flaky WARN, got flaky=%t %s: %s", flaky.Flaky, flaky.Outcome, flaky.OutcomeReason)
+	}
+	if steady := result.Results[1].Result; steady.Flaky || !steady.IsPass() {
+		t.Errorf("a first-attempt PASS is not flaky, got flaky=%t %s", steady.Flaky, steady.Outcome)
+	}
+	if result.FlakyCount != 1 {
+		t.Errorf("expected 1 flaky check, got %d", result.FlakyCount)
+	}
+
+	r.PrintSummary(result, "")
+	if !strings.Contains(out.String(), "FLAKY: Flaky passed after 1 retries") {
+		t.Errorf("expected the summary to list the flaky check:\n%s", out.String())
+	}
+}
+
+func TestPassRates(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Stable", Command: "true"},
+			{Name: "Every Other Run", Command: fmt.Sprintf(`n=$(cat %[1]s 2>/dev/null || echo 0); echo $((n+1)) > %[1]s; [ $((n %% 2)) -eq 0 ]`, counter), Expect: &config.ExpectConfig{Gating: new(bool)}},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+	r.MaxRetries = 0
+
+	var runs []*RunResult
+	for range 4 {
+		runs = append(runs, r.Run(context.Background()))
+	}
+	rates := PassRates(runs)
+
+	if len(rates) != 2 {
+		t.Fatalf("expected 2 pass rates, got %d", len(rates))
+	}
+	if p := rates[0]; p.Runs != 4 || p.Passes != 4 || !p.Deterministic() {
+		t.Errorf("expected Stable to pass 4/4, got %d/%d (deterministic %t)", p.Passes, p.Runs, p.Deterministic())
+	}
+	if p := rates[1]; p.Passes != 2 || p.Outcomes[engine.OutcomeFail] != 2 || p.Deterministic() {
+		t.Errorf("expected Every Other Run to pass 2/4 and be unstable, got %d/%d %v", p.Passes, p.Runs, p.Outcomes)
+	}
+
+	out.Reset()
+	r.PrintPassRates("Pass rates over 4 runs", rates)
+	for _, want := range []string{
+		"100%  4/4  Stable (4 PASS)",
+		" 50%  2/4  Every Other Run (2 PASS, 2 FAIL) - UNSTABLE",
+		"1 check(s) changed outcome between runs or were flaky",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("pass rates missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunnerWaitFor(t *testing.T) {
+	polls := filepath.Join(t.TempDir(), "polls")
+	wait := &config.WaitFor{
+		Timeout:  config.Duration{Duration: 2 * time.Second},
+		Interval: config.Duration{Duration: 10 * time.Millisecond},
+	}
+	cfg := &config.Config{
+		Checks: []config.Check{
+			// Becomes ready on the third poll, and only the output says so
+			{
+				Name:     "Rollout Ready",
+				Command:  fmt.Sprintf(`n=$(cat %[1]s 2>/dev/null || echo 0); n=$((n+1)); echo $n > %[1]s; [ $n -ge 3 ] && echo Ready || echo Progressing`, polls),
+				Validate: &validate.Validation{Contains: "Ready"},
+				WaitFor:  wait,
+			},
+			{
+				Name:    "Never Ready",
+				Command: "exit 1",
+				WaitFor: &config.WaitFor{Timeout: config.Duration{Duration: 50 * time.Millisecond}, Interval: config.Duration{Duration: 20 * time.Millisecond}},
+				Expect:  &config.ExpectConfig{Gating: new(bool)},
+			},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	start := time.Now()
+	result := r.Run(context.Background())
+
+	ready := result.Results[0].Result
+	if !ready.IsPass() || ready.RetryCount != 2 {
+		t.Errorf("expected PASS after 3 polls, got %s after %d retries: %s", ready.Outcome, ready.RetryCount, ready.OutcomeReason)
+	}
+	never := result.Results[1].Result
+	if never.Outcome != engine.OutcomeFail || !strings.Contains(never.OutcomeReason, "still not passing after waiting 50ms") {
+		t.Errorf("expected FAIL at the deadline, got %s: %s", never.Outcome, never.OutcomeReason)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected polling to stop at the deadline, took %s", elapsed)
+	}
+}
+
+func TestRunnerWaitForNegative(t *testing.T) {
+	polls := filepath.Join(t.TempDir(), "polls")
+	cfg := &config.Config{
+		Checks: []config.Check{
+			// Stops answering on the third poll, which is what it expects
+			{
+				Name:    "Endpoint Gone",
+				Command: fmt.Sprintf(`n=$(cat %[1]s 2>/dev/null || echo 0); n=$((n+1)); echo $n > %[1]s; [ $n -lt 3 ]`, polls),
+				WaitFor: &config.WaitFor{Timeout: config.Duration{Duration: 2 * time.Second}, Interval: config.Duration{Duration: 10 * time.Millisecond}},
+				Expect:  &config.ExpectConfig{Outcome: config.ExpectOutcomeFail},
+			},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	got := r.Run(context.Background()).Results[0].Result
+	if !got.IsPass() || got.RetryCount != 2 {
+		t.Errorf("expected PASS after 3 polls, got %s after %d retries: %s", got.Outcome, got.RetryCount, got.OutcomeReason)
+	}
+}
+
 func TestRunnerWithScript(t *testing.T) {
 	// Create a temp script
 	tmpDir := t.TempDir()
@@ -151,6 +419,57 @@ func TestRunnerSortByLayer(t *testing.T) {
 	}
 }
 
+func TestRootCause(t *testing.T) {
+	res := func(name string, layer int, exitCode int) CheckExecutionResult {
+		return CheckExecutionResult{
+			Check:  &config.Check{Name: name, Layer: layer},
+			Result: engine.ClassifyResult(exitCode, nil, nil, true),
+		}
+	}
+
+	result := &RunResult{
+		Results: []CheckExecutionResult{
+			res("DNS Resolves", 1, engine.ExitPass),
+			res("API Server Reachable", 1, engine.ExitError),
+			res("Nodes Ready", 2, engine.ExitFail),
+			res("ArgoCD Synced", 3, engine.ExitSkip),
+			res("Grafana Up", 3, engine.ExitPass),
+		},
+		TotalCount: 7,
+	}
+
+	rc := result.RootCause()
+	if rc == nil {
+		t.Fatal("expected a root cause")
+	}
+	if rc.Layer != 1 || len(rc.Causes) != 1 || rc.Causes[0].Check.Name != "API Server Reachable" {
+		t.Errorf("unexpected root cause: layer %d, causes %v", rc.Layer, rc.Causes)
+	}
+	// Nodes Ready, ArgoCD Synced, and the 2 checks that never ran
+	if rc.Downstream != 4 {
+		t.Errorf("expected 4 downstream checks, got %d", rc.Downstream)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(&config.Config{}, "", config.TemplateVars{})
+	r.Output = &buf
+	r.NoColor = true
+	r.PrintSummary(result, "")
+	want := "Probable root cause (layer 1):\n  API Server Reachable: ERROR - script error (exit code 2)\n  4 downstream check(s) failed, skipped, or did not run\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("summary missing root cause:\n%s", buf.String())
+	}
+
+	// Failures in a single layer have nothing to collapse
+	isolated := &RunResult{
+		Results:    []CheckExecutionResult{res("A", 2, engine.ExitFail), res("B", 2, engine.ExitFail), res("C", 3, engine.ExitPass)},
+		TotalCount: 3,
+	}
+	if rc := isolated.RootCause(); rc != nil {
+		t.Errorf("expected no root cause, got %+v", rc)
+	}
+}
+
 func TestRunResultExitCode(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -194,28 +513,6 @@ func TestRunResultExitCode(t *testing.T) {
 	}
 }
 
-func TestShellQuote(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{input: "", expected: "''"},
-		{input: "simple", expected: "simple"},
-		{input: "with space", expected: "'with space'"},
-		{input: "with'quote", expected: "'with'\"'\"'quote'"},
-		{input: "special$var", expected: "'special$var'"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			result := shellQuote(tt.input)
-			if result != tt.expected {
-				t.Errorf("expected %q, got %q", tt.expected, result)
-			}
-		})
-	}
-}
-
 func TestRunnerWithValidation(t *testing.T) {
 	cfg := &config.Config{
 		Checks: []config.Check{
@@ -240,3 +537,1459 @@ func TestRunnerWithValidation(t *testing.T) {
 		t.Errorf("PassCount expected 1, got %d", result.PassCount)
 	}
 }
+
+func TestRunnerValidationScript(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{
+				Name:     "Enough Replicas",
+				Command:  `echo '{"replicas": 3}'`,
+				Validate: &validate.Validation{Script: "if json.decode(output)[\"replicas\"] < 2:\n    fail(\"too few replicas\")"},
+			},
+			{
+				Name:     "Too Few Replicas",
+				Command:  `echo '{"replicas": 1}'`,
+				Validate: &validate.Validation{Contains: "replicas", Script: "if json.decode(output)[\"replicas\"] < 2:\n    fail(\"too few replicas\")"},
+				Expect:   &config.ExpectConfig{Gating: new(bool)},
+			},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	result := r.Run(context.Background())
+
+	if result.PassCount != 1 || result.FailCount != 1 {
+		t.Fatalf("expected 1 pass and 1 fail, got %d and %d", result.PassCount, result.FailCount)
+	}
+	if reason := result.Results[1].Result.OutcomeReason; reason != "validation failed: too few replicas" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+}
+
+func TestRunnerTransform(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{
+				Name:      "Head Then Validate",
+				Command:   `printf '\033[32mready\033[0m\nERROR later\n'`,
+				Transform: []transform.Step{{StripANSI: true}, {Head: 1}},
+				Validate:  &validate.Validation{Regex: `^ready\n$`, NotContains: "ERROR"},
+			},
+			{
+				Name:      "Bad JSON",
+				Command:   "echo not-json",
+				Transform: []transform.Step{{JSONPretty: true}},
+				Expect:    &config.ExpectConfig{Gating: new(bool)},
+			},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	result := r.Run(context.Background())
+
+	head := result.Results[0].Result
+	if !head.IsPass() || head.Output != "ready\n" {
+		t.Errorf("expected PASS with transformed output, got %s %q (%s)", head.Outcome, head.Output, head.OutcomeReason)
+	}
+
+	bad := result.Results[1].Result
+	if bad.Outcome != engine.OutcomeFail || !strings.Contains(bad.OutcomeReason, "transform json_pretty") {
+		t.Errorf("expected FAIL from transform, got %s (%s)", bad.Outcome, bad.OutcomeReason)
+	}
+	if strings.TrimSpace(bad.Output) != "not-json" {
+		t.Errorf("expected raw output kept, got %q", bad.Output)
+	}
+}
+
+func TestRunnerDiagnostics(t *testing.T) {
+	cfg := &config.Config{
+		Diagnostics: map[string][]string{"media": {"echo tag-diag", "echo check-diag"}},
+		Checks: []config.Check{
+			{Name: "Passes", Command: "true", Diagnostics: []string{"echo should-not-run"}},
+			{
+				Name:        "Fails",
+				Command:     "exit 1",
+				Tags:        []string{"media"},
+				Diagnostics: []string{"echo check-diag", "echo {{.Cluster}}; exit 3"},
+				Expect:      &config.ExpectConfig{Gating: new(bool)},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{Cluster: "home"})
+	r.Output = &out
+	result := r.Run(context.Background())
+
+	if d := result.Results[0].Result.Diagnostics; len(d) != 0 {
+		t.Errorf("passing check should have no diagnostics, got %+v", d)
+	}
+
+	diags := result.Results[1].Result.Diagnostics
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics (own, then tag, deduplicated), got %+v", diags)
+	}
+	if diags[1].Command != "echo home; exit 3" || strings.TrimSpace(diags[1].Output) != "home" || diags[1].ExitCode != 3 {
+		t.Errorf("unexpected templated diagnostic: %+v", diags[1])
+	}
+	if strings.TrimSpace(diags[2].Output) != "tag-diag" {
+		t.Errorf("expected tag diagnostic last, got %+v", diags[2])
+	}
+	if !strings.Contains(out.String(), "  Diagnostic: echo home; exit 3 (exit 3)\n    home\n") {
+		t.Errorf("expected diagnostics in console output, got:\n%s", out.String())
+	}
+}
+
+func TestRunnerOverrides(t *testing.T) {
+	cfg := &config.Config{
+		Overrides: []config.OverrideRule{
+			{Tag: "nas", Output: "rate limit", From: []string{"ERROR"}, Outcome: "WARN", Reason: "known NAS rate limiting"},
+			{Name: "flaky-*", Outcome: "SKIP"},
+		},
+		Checks: []config.Check{
+			{Name: "NAS Rate Limited", Tags: []string{"nas"}, Command: "echo 'rate limit exceeded'; exit 2"},
+			{Name: "Flaky Probe", Command: "exit 1"},
+			{Name: "NAS Broken", Tags: []string{"nas"}, Command: "echo 'disk gone'; exit 2"},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	result := r.Run(context.Background())
+
+	limited := result.Results[0].Result
+	if limited.Outcome != engine.OutcomeWarn || !strings.HasPrefix(limited.OutcomeReason, "known NAS rate limiting (was ERROR") {
+		t.Errorf("expected override to WARN, got %s (%s)", limited.Outcome, limited.OutcomeReason)
+	}
+	if flaky := result.Results[1].Result; flaky.Outcome != engine.OutcomeSkip {
+		t.Errorf("expected glob override to SKIP, got %s", flaky.Outcome)
+	}
+	if broken := result.Results[2].Result; broken.Outcome != engine.OutcomeError {
+		t.Errorf("non-matching output should keep ERROR, got %s", broken.Outcome)
+	}
+}
+
+func TestRunHookCommand(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Pass Check", Command: "echo hello"},
+			{Name: "Gateway Has IP", Command: "exit 1"},
+		},
+	}
+	vars := config.TemplateVars{Cluster: "test"}
+
+	r := NewRunner(cfg, "/tmp", vars)
+	r.Output = &bytes.Buffer{}
+
+	ctx := context.Background()
+	result := r.Run(ctx)
+
+	hook := &config.HookConfig{
+		Command: `echo "{{.Cluster}} $SMOKE_CLUSTER $SMOKE_EXIT_CODE $SMOKE_GATING_FAILS $SMOKE_FAILED_CHECKS"`,
+	}
+	hookResult := r.RunHookCommand(ctx, hook, result)
+
+	if hookResult.Error != nil {
+		t.Fatalf("hook failed: %v", hookResult.Error)
+	}
+	expected := "test test 1 1 gateway-has-ip"
+	if strings.TrimSpace(hookResult.Output) != expected {
+		t.Errorf("expected %q, got %q", expected, hookResult.Output)
+	}
+}
+
+func TestRunHookCommandGatingError(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Pass Check", Command: "echo hello"},
+			{Name: "Gateway Errors", Command: "exit 2"},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	ctx := context.Background()
+	result := r.Run(ctx)
+
+	// An ERROR blocks even though it isn't counted as a gating FAIL
+	failures := result.GatingFailures()
+	if len(failures) != 1 || failures[0].Check.Name != "Gateway Errors" {
+		t.Fatalf("expected the ERROR as the only gating failure, got %d", len(failures))
+	}
+
+	hook := &config.HookConfig{Command: `echo "$SMOKE_EXIT_CODE $SMOKE_GATING_FAILS $SMOKE_FAILED_CHECKS"`}
+	hookResult := r.RunHookCommand(ctx, hook, result)
+	if expected := "2 1 gateway-errors"; strings.TrimSpace(hookResult.Output) != expected {
+		t.Errorf("expected %q, got %q", expected, hookResult.Output)
+	}
+}
+
+func TestRunnerFallbackCommand(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Fallback Used", Command: "exit 2", FallbackCommand: "echo {{.Cluster}}"},
+			{Name: "Fallback Unused", Command: "exit 1", FallbackCommand: "exit 0"},
+		},
+	}
+	vars := config.TemplateVars{Cluster: "test"}
+
+	r := NewRunner(cfg, "/tmp", vars)
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+
+	used := result.Results[0].Result
+	if !used.IsPass() || !used.Fallback {
+		t.Errorf("expected PASS via fallback, got %s (fallback=%v)", used.Outcome, used.Fallback)
+	}
+	if strings.TrimSpace(used.Output) != "test" {
+		t.Errorf("expected templated fallback output, got %q", used.Output)
+	}
+
+	unused := result.Results[1].Result
+	if unused.Fallback || unused.IsPass() {
+		t.Errorf("fallback should only run on ERROR, got %s (fallback=%v)", unused.Outcome, unused.Fallback)
+	}
+}
+
+func TestRunnerExpectedFailure(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Known Broken", Command: "exit 1", ExpectedFailure: &config.ExpectedFailure{Reason: "upstream bug"}},
+			{Name: "Fixed Now", Command: "exit 0", ExpectedFailure: &config.ExpectedFailure{}},
+			{Name: "Expired", Command: "exit 1", ExpectedFailure: &config.ExpectedFailure{Until: "2000-01-01"}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+
+	if result.XFailCount != 1 {
+		t.Errorf("XFailCount expected 1, got %d", result.XFailCount)
+	}
+	if result.XPassCount != 1 {
+		t.Errorf("XPassCount expected 1, got %d", result.XPassCount)
+	}
+	if result.GatingFails != 1 {
+		t.Errorf("GatingFails expected 1 (expired xfail), got %d", result.GatingFails)
+	}
+}
+
+func TestRunnerDisabled(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Turned Off", Command: "exit 1", Disabled: &config.Disabled{Reason: "NAS offline"}},
+			{Name: "Back On", Command: "exit 0", Disabled: &config.Disabled{Reason: "migration", Until: "2000-01-01"}},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+
+	result := r.Run(context.Background())
+	r.PrintSummary(result, "")
+
+	if got := result.Results[0].Result; got.Outcome != engine.OutcomeSkip || !strings.Contains(got.OutcomeReason, "NAS offline") {
+		t.Errorf("expected SKIP with reason, got %s (%s)", got.Outcome, got.OutcomeReason)
+	}
+	if got := result.Results[1].Result; !got.IsPass() {
+		t.Errorf("expired disable should run normally, got %s", got.Outcome)
+	}
+	if !strings.Contains(out.String(), "DISABLED: Turned Off - NAS offline - no expiry set") {
+		t.Errorf("summary missing disabled notice:\n%s", out.String())
+	}
+}
+
+func TestRunnerSetupTeardown(t *testing.T) {
+	dir := t.TempDir()
+	scratch := filepath.Join(dir, "scratch")
+	torndown := filepath.Join(dir, "torndown")
+
+	tests := []struct {
+		name        string
+		setup       []string
+		checks      []config.Check
+		wantResults int
+		wantExit    int
+	}{
+		{
+			name:        "checks see setup",
+			setup:       []string{"mkdir " + scratch},
+			checks:      []config.Check{{Name: "Scratch Exists", Command: "test -d " + scratch}},
+			wantResults: 1,
+		},
+		{
+			name:        "fail fast still tears down",
+			setup:       []string{"mkdir " + scratch},
+			checks:      []config.Check{{Name: "Broken", Command: "exit 1", Layer: 1}, {Name: "Never", Command: "true", Layer: 2}},
+			wantResults: 1,
+			wantExit:    1,
+		},
+		{
+			name:     "failed setup runs no checks",
+			setup:    []string{"echo no cluster; exit 3"},
+			checks:   []config.Check{{Name: "Never", Command: "true"}},
+			wantExit: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.RemoveAll(scratch)
+			_ = os.Remove(torndown)
+			cfg := &config.Config{
+				Setup:    tt.setup,
+				Teardown: []string{"rm -rf " + scratch, "touch " + torndown},
+				Checks:   tt.checks,
+			}
+
+			var out bytes.Buffer
+			r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+			r.Output = &out
+			r.MaxRetries = 0
+
+			result := r.Run(context.Background())
+			if len(result.Results) != tt.wantResults || result.ExitCode() != tt.wantExit {
+				t.Errorf("expected %d results and exit %d, got %d and exit %d:\n%s", tt.wantResults, tt.wantExit, len(result.Results), result.ExitCode(), out.String())
+			}
+			if _, err := os.Stat(torndown); err != nil {
+				t.Error("expected teardown to run")
+			}
+			if _, err := os.Stat(scratch); !os.IsNotExist(err) {
+				t.Error("expected teardown to remove the scratch dir")
+			}
+		})
+	}
+
+	cfg := &config.Config{Setup: []string{"echo no cluster; exit 3"}, Checks: []config.Check{{Name: "Never", Command: "true"}}}
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	result := r.Run(context.Background())
+	if result.SetupError == nil || result.SetupError.Error() != `setup "echo no cluster; exit 3" exited with code 3: no cluster` {
+		t.Errorf("unexpected setup error: %v", result.SetupError)
+	}
+}
+
+func TestRunnerBeforeAfter(t *testing.T) {
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "seed")
+	cleaned := filepath.Join(dir, "cleaned")
+
+	cfg := &config.Config{
+		Layers: map[int]config.LayerConfig{0: {ContinueOnFailure: true}},
+		Checks: []config.Check{
+			{
+				Name:    "Seeded",
+				Command: "cat " + seed,
+				Before:  []string{"echo row > " + seed},
+				After:   []string{"rm " + seed, "touch " + cleaned},
+			},
+			{
+				Name:    "Seed Fails",
+				Command: "true",
+				Before:  []string{"echo db down; exit 1"},
+				After:   []string{"rm " + cleaned},
+			},
+			{
+				Name:    "Cleanup Fails",
+				Command: "true",
+				After:   []string{"exit 1"},
+			},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.MaxRetries = 0
+
+	result := r.Run(context.Background())
+
+	if got := result.Results[0].Result; !got.IsPass() || strings.TrimSpace(got.Output) != "row" {
+		t.Errorf("expected the check to see seeded data, got %s: %q", got.Outcome, got.Output)
+	}
+	if got := result.Results[1].Result; got.Outcome != engine.OutcomeError || !strings.Contains(got.OutcomeReason, "before \"echo db down; exit 1\" exited with code 1: db down") {
+		t.Errorf("expected ERROR from the failed before command, got %s: %s", got.Outcome, got.OutcomeReason)
+	}
+	if _, err := os.Stat(cleaned); !os.IsNotExist(err) {
+		t.Error("expected after commands to run even when before failed")
+	}
+	if got := result.Results[2].Result; got.Outcome != engine.OutcomeWarn || !strings.HasPrefix(got.OutcomeReason, `after "exit 1" exited with code 1`) {
+		t.Errorf("expected WARN from the failed after command, got %s: %s", got.Outcome, got.OutcomeReason)
+	}
+}
+
+func TestRunnerFixtures(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "log")
+	record := func(s string) string { return "echo " + s + " >> " + log }
+
+	cfg := &config.Config{
+		Layers: map[int]config.LayerConfig{2: {ContinueOnFailure: true}},
+		Fixtures: map[string]config.FixtureConfig{
+			"port-forward": {Setup: []string{record("up")}, Teardown: []string{record("down")}},
+			"broken":       {Setup: []string{record("broken-up"), "exit 1"}, Teardown: []string{record("broken-down")}},
+			"unused":       {Setup: []string{record("unused-up")}},
+		},
+		Checks: []config.Check{
+			{Name: "A", Command: record("A"), Uses: []string{"port-forward"}},
+			{Name: "B", Command: record("B")},
+			{Name: "C", Command: record("C"), Layer: 1, Uses: []string{"port-forward"}},
+			{Name: "D", Command: record("D"), Layer: 1},
+			{Name: "E", Command: record("E"), Layer: 2, Uses: []string{"broken"}},
+			{Name: "F", Command: record("F"), Layer: 2, Uses: []string{"broken"}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.MaxRetries = 0
+
+	result := r.Run(context.Background())
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(data)), []string{"up", "A", "B", "C", "down", "D", "broken-up", "broken-down"}; !slices.Equal(got, want) {
+		t.Errorf("expected fixtures up before their first user and down after their last, got %v, want %v", got, want)
+	}
+	for _, i := range []int{4, 5} {
+		if got := result.Results[i].Result; got.Outcome != engine.OutcomeError || !strings.Contains(got.OutcomeReason, `fixture broken setup "exit 1" exited with code 1`) {
+			t.Errorf("expected %s to ERROR from the broken fixture, got %s: %s", result.Results[i].Check.Name, got.Outcome, got.OutcomeReason)
+		}
+	}
+	if result.TeardownError != nil {
+		t.Errorf("unexpected teardown error: %v", result.TeardownError)
+	}
+}
+
+func TestRunnerFixtureTeardownAfterFailFast(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "log")
+
+	cfg := &config.Config{
+		Fixtures: map[string]config.FixtureConfig{
+			"tunnel": {Setup: []string{"echo up >> " + log}, Teardown: []string{"echo down >> " + log, "exit 1"}},
+		},
+		Checks: []config.Check{
+			{Name: "Gate", Command: "exit 1", Uses: []string{"tunnel"}},
+			{Name: "Later", Command: "true", Layer: 1, Uses: []string{"tunnel"}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.MaxRetries = 0
+
+	result := r.Run(context.Background())
+
+	if len(result.Results) != 1 {
+		t.Fatalf("expected the run to stop after the gating failure, got %d results", len(result.Results))
+	}
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(data)); !slices.Equal(got, []string{"up", "down"}) {
+		t.Errorf("expected the fixture to be torn down at the end of the run, got %v", got)
+	}
+	if result.TeardownError == nil || !strings.Contains(result.TeardownError.Error(), `fixture tunnel teardown "exit 1" exited with code 1`) {
+		t.Errorf("expected fixture teardown error, got %v", result.TeardownError)
+	}
+}
+
+func TestRunnerRedactsSecrets(t *testing.T) {
+	cfg := &config.Config{
+		Layers:      map[int]config.LayerConfig{0: {ContinueOnFailure: true}},
+		Diagnostics: map[string][]string{"auth": {"echo diag {{.Secret.token}}"}},
+		Checks: []config.Check{
+			{Name: "Login", Command: "echo token={{.Secret.token}}; exit 1", Tags: []string{"auth"}},
+			{Name: "Before", Command: "true", Before: []string{"echo bad {{.Secret.token}}; exit 1"}},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{Secret: map[string]string{"token": "s3cr3t"}})
+	r.Output = &out
+	r.MaxRetries = 0
+	r.Verbose = true
+
+	result := r.Run(context.Background())
+
+	login := result.Results[0].Result
+	if strings.TrimSpace(login.Output) != "token=***" || login.Diagnostics[0].Command != "echo diag ***" || strings.TrimSpace(login.Diagnostics[0].Output) != "diag ***" {
+		t.Errorf("expected secrets masked in output and diagnostics, got %q, %+v", login.Output, login.Diagnostics)
+	}
+	if reason := result.Results[1].Result.OutcomeReason; !strings.HasSuffix(reason, "bad ***") {
+		t.Errorf("expected secret masked in reason, got %q", reason)
+	}
+	if strings.Contains(out.String(), "s3cr3t") {
+		t.Errorf("secret leaked into output:\n%s", out.String())
+	}
+}
+
+func TestRunnerMaintenance(t *testing.T) {
+	always := config.MaintenanceWindow{Schedule: "* * * * *", Duration: config.Duration{Duration: time.Minute}, Reason: "NAS reboot"}
+	never := config.MaintenanceWindow{Schedule: "0 0 31 2 *", Duration: config.Duration{Duration: time.Minute}}
+	skip := always
+	skip.Outcome = "SKIP"
+	skip.Tag = "backup"
+
+	cfg := &config.Config{
+		Maintenance: []config.MaintenanceWindow{skip},
+		Checks: []config.Check{
+			{Name: "NAS Mounted", Command: "exit 1", Maintenance: []config.MaintenanceWindow{always}},
+			{Name: "Backups Fresh", Command: "exit 1", Tags: []string{"backup"}},
+			{Name: "DNS", Command: "true", Maintenance: []config.MaintenanceWindow{always}},
+			{Name: "Ingress", Command: "exit 1", Layer: 1, Maintenance: []config.MaintenanceWindow{never}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.MaxRetries = 0
+
+	result := r.Run(context.Background())
+
+	if got := result.Results[0].Result; got.Outcome != engine.OutcomeWarn || got.OutcomeReason != "maintenance: NAS reboot (was FAIL: check failed (exit code 1))" {
+		t.Errorf("expected WARN during the check's window, got %s: %s", got.Outcome, got.OutcomeReason)
+	}
+	if got := result.Results[1].Result; got.Outcome != engine.OutcomeSkip || got.IsGatingFailure() {
+		t.Errorf("expected non-gating SKIP during the tag's window, got %s", got.Outcome)
+	}
+	if got := result.Results[2].Result; got.Outcome != engine.OutcomePass {
+		t.Errorf("expected passing check unchanged, got %s", got.Outcome)
+	}
+	if got := result.Results[3].Result; got.Outcome != engine.OutcomeFail || !got.IsGatingFailure() {
+		t.Errorf("expected gating FAIL outside the window, got %s", got.Outcome)
+	}
+}
+
+func TestRunnerFailuresByOwner(t *testing.T) {
+	cfg := &config.Config{
+		Layers: map[int]config.LayerConfig{0: {ContinueOnFailure: true}},
+		Checks: []config.Check{
+			{Name: "NAS Mounted", Command: "exit 1", Owner: "sam", Team: "storage"},
+			{Name: "Jellyfin Ready", Command: "exit 2", Owner: "erin", Team: "media"},
+			{Name: "DNS", Command: "exit 1"},
+			{Name: "Backups Fresh", Command: "exit 1", Owner: "sam", Team: "storage"},
+			{Name: "Plex Ready", Command: "true", Owner: "erin", Team: "media"},
+			{Name: "Disk Space", Command: "exit 3", Owner: "sam"},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+	r.MaxRetries = 0
+	r.NoColor = true
+	r.GroupBy = "owner"
+
+	result := r.Run(context.Background())
+	out.Reset()
+	r.PrintSummary(result, "")
+
+	want := `
+Failures by owner:
+  erin: Jellyfin Ready (ERROR)
+  sam: NAS Mounted (FAIL), Backups Fresh (FAIL)
+  (no owner): DNS (FAIL)
+`
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("expected failures grouped by owner:\n%s\ngot:\n%s", want, out.String())
+	}
+
+	groups := result.FailuresBy("team")
+	var keys []string
+	for _, g := range groups {
+		keys = append(keys, fmt.Sprintf("%s=%d", g.Key, len(g.Results)))
+	}
+	if got := strings.Join(keys, " "); got != "media=1 storage=2 =1" {
+		t.Errorf("unexpected team groups: %s", got)
+	}
+}
+
+func TestRunnerQuarantine(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Flaky Upstream", Command: "exit 1", Quarantine: &config.Quarantine{Reason: "upstream DNS flaps"}},
+			{Name: "Recovered", Command: "true", Quarantine: &config.Quarantine{Reason: "cert renewal"}},
+			{Name: "Expired", Command: "exit 1", Quarantine: &config.Quarantine{Reason: "old", Until: "2000-01-01"}, Expect: &config.ExpectConfig{Gating: new(bool)}},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+	r.MaxRetries = 0
+
+	result := r.Run(context.Background())
+	r.PrintSummary(result, "")
+
+	if got := result.Results[0].Result; got.Outcome != engine.OutcomeWarn || got.Gating {
+		t.Errorf("expected a non-gating WARN, got %s (gating %t)", got.Outcome, got.Gating)
+	}
+	if got := result.Results[2].Result; got.Outcome != engine.OutcomeFail {
+		t.Errorf("expired quarantine should report normally, got %s", got.Outcome)
+	}
+	if result.GatingFails != 0 || len(result.Results) != 3 {
+		t.Errorf("quarantined failures must not gate, got %d gating fails", result.GatingFails)
+	}
+	for _, want := range []string{
+		"QUARANTINED: Flaky Upstream - upstream DNS flaps - no expiry set [still failing]",
+		"QUARANTINED: Recovered - cert renewal - no expiry set [passing - consider lifting it]",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "QUARANTINED: Expired") {
+		t.Errorf("expired quarantines should not be listed:\n%s", out.String())
+	}
+}
+
+func TestRunnerEnvironment(t *testing.T) {
+	t.Setenv("SMOKE_TEST_LEAK", "leaked")
+	t.Setenv("SMOKE_TEST_KEEP", "kept")
+
+	cfg := &config.Config{
+		Environment: &config.EnvironmentConfig{
+			Scrub: true,
+			Pass:  []string{"SMOKE_TEST_KEEP"},
+			Set:   map[string]string{"TZ": "UTC"},
+		},
+		Checks: []config.Check{
+			{Name: "Leak Hidden", Command: `test -z "$SMOKE_TEST_LEAK"`},
+			{Name: "Pass Kept", Command: `test "$SMOKE_TEST_KEEP" = kept`},
+			{Name: "Set Forced", Command: `test "$TZ" = UTC`},
+			{Name: "Condition Scrubbed", When: `test -z "$SMOKE_TEST_LEAK"`, Command: "true"},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+	for _, res := range result.Results {
+		if !res.Result.IsPass() {
+			t.Errorf("%s: expected PASS, got %s (%s)", res.Check.Name, res.Result.Outcome, res.Result.OutcomeReason)
+		}
+	}
+}
+
+func TestRunnerExpectExitCodes(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Grep No Match", Command: "exit 1", Expect: &config.ExpectConfig{ExitCodes: []int{0, 1}}},
+			{Name: "Zero Not Listed", Command: "exit 0", Expect: &config.ExpectConfig{ExitCodes: []int{4}}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+
+	if got := result.Results[0].Result; !got.IsPass() || got.ExitCode != 1 {
+		t.Errorf("expected PASS with raw exit code 1, got %s (exit %d)", got.Outcome, got.ExitCode)
+	}
+	if got := result.Results[1].Result; got.Outcome != engine.OutcomeFail {
+		t.Errorf("expected FAIL for unlisted exit 0, got %s", got.Outcome)
+	}
+}
+
+func TestRunnerRetryHonorsExitCodes(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Grep No Match", Command: "exit 1", Retry: &config.RetryConfig{}, Expect: &config.ExpectConfig{ExitCodes: []int{0, 1}}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.RetryDelay = time.Millisecond
+
+	got := r.Run(context.Background()).Results[0].Result
+	if !got.IsPass() || got.RetryCount != 0 || got.Flaky {
+		t.Errorf("expected PASS without retries, got %s after %d retries (flaky %v)", got.Outcome, got.RetryCount, got.Flaky)
+	}
+}
+
+func TestRunnerNegativeTest(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Not Public", Command: "exit 1", Expect: &config.ExpectConfig{Outcome: config.ExpectOutcomeFail}},
+			{Name: "Unexpectedly Public", Command: "exit 0", Expect: &config.ExpectConfig{Outcome: config.ExpectOutcomeFail}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+
+	if result.PassCount != 1 {
+		t.Errorf("PassCount expected 1, got %d", result.PassCount)
+	}
+	if result.GatingFails != 1 {
+		t.Errorf("GatingFails expected 1, got %d", result.GatingFails)
+	}
+}
+
+func TestRunnerConditions(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "When True", Command: "exit 0", When: `{{eq .Cluster "home"}}`},
+			{Name: "When False", Command: "exit 1", When: `{{eq .Cluster "lab"}}`},
+			{Name: "Skip If Command", Command: "exit 1", SkipIf: "test -d /"},
+			{Name: "Skip If Not Met", Command: "exit 0", SkipIf: "test -d /nonexistent"},
+			{Name: "When Errors", Command: "exit 0", When: "exit 5"},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{Cluster: "home"})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+
+	want := []engine.Outcome{
+		engine.OutcomePass,
+		engine.OutcomeSkip,
+		engine.OutcomeSkip,
+		engine.OutcomePass,
+		engine.OutcomeError,
+	}
+	for i, w := range want {
+		if got := result.Results[i].Result.Outcome; got != w {
+			t.Errorf("%s: expected %s, got %s", cfg.Checks[i].Name, w, got)
+		}
+	}
+}
+
+func TestRunnerCapture(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{
+				Name:    "Ingress IP",
+				Command: "echo 'external-ip: 10.0.0.5'",
+				Layer:   1,
+				Capture: map[string]string{"ingress_ip": `external-ip: (\S+)`},
+			},
+			{Name: "Uses Capture", Command: "echo {{.Custom.ingress_ip}}", Layer: 2},
+			{Name: "No Match", Command: "echo nothing", Layer: 2, Capture: map[string]string{"x": `missing`}},
+		},
+	}
+	gatingFalse := false
+	cfg.Checks[2].Expect = &config.ExpectConfig{Gating: &gatingFalse}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+
+	if got := strings.TrimSpace(result.Results[1].Result.Output); got != "10.0.0.5" {
+		t.Errorf("expected captured value in later check, got %q", got)
+	}
+	if got := result.Results[2].Result.Outcome; got != engine.OutcomeFail {
+		t.Errorf("expected FAIL for unmatched capture, got %s", got)
+	}
+}
+
+func TestRunnerParallel(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Slow", Command: "sleep 0.3; echo slow", Layer: 1},
+			{Name: "Fast", Command: "echo fast", Layer: 1},
+			{Name: "Also Slow", Command: "sleep 0.3; echo also", Layer: 1},
+			{Name: "Next Layer", Command: "echo next", Layer: 2},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+	r.Verbose = true
+	r.Parallel = 3
+
+	start := time.Now()
+	result := r.Run(context.Background())
+	elapsed := time.Since(start)
+
+	if result.PassCount != 4 {
+		t.Fatalf("PassCount expected 4, got %d", result.PassCount)
+	}
+	if elapsed > 550*time.Millisecond {
+		t.Errorf("expected layer checks to run concurrently, took %v", elapsed)
+	}
+
+	// Results are recorded in config order regardless of completion order
+	for i, name := range []string{"Slow", "Fast", "Also Slow", "Next Layer"} {
+		if result.Results[i].Check.Name != name {
+			t.Errorf("result %d expected %q, got %q", i, name, result.Results[i].Check.Name)
+		}
+	}
+
+	// Each check's block is flushed whole: its progress line is followed
+	// directly by its own outcome and output
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "[") && strings.Contains(line, "Slow...") {
+			if !strings.HasSuffix(line, "PASS"+engine.ColorReset()) {
+				t.Errorf("progress line not followed by its outcome: %q", line)
+			}
+			if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "  Reason:") {
+				t.Errorf("check block interleaved after %q", line)
+			}
+		}
+	}
+	if strings.Index(out.String(), "Next Layer") < strings.Index(out.String(), "also") {
+		t.Error("layer 2 output should follow all layer 1 output")
+	}
+}
+
+func TestProgress(t *testing.T) {
+	p := &progress{total: 10, parallel: 2}
+
+	p.start()
+	p.start()
+	status := p.finish(2 * time.Second)
+
+	expected := "1/10 | 1 running, 8 queued | ETA 10s"
+	if status != expected {
+		t.Errorf("expected %q, got %q", expected, status)
+	}
+
+	p.advanceTo(9)
+	status = p.finish(2 * time.Second)
+	if !strings.HasPrefix(status, "10/10 | 0 running, 0 queued") || strings.Contains(status, "ETA") {
+		t.Errorf("unexpected final status %q", status)
+	}
+}
+
+func benchmarkRunner(b *testing.B, parallel int) {
+	cfg := &config.Config{Checks: make([]config.Check, 20)}
+	for i := range cfg.Checks {
+		cfg.Checks[i] = config.Check{Name: "noop " + strconv.Itoa(i), Command: "true", Layer: i%2 + 1}
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = io.Discard
+	r.Parallel = parallel
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Run(context.Background())
+	}
+}
+
+func BenchmarkRunnerSequential(b *testing.B) { benchmarkRunner(b, 1) }
+
+func BenchmarkRunnerParallel(b *testing.B) { benchmarkRunner(b, 8) }
+
+func TestRunnerVerboseStreaming(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Echo", Command: "echo one; printf two"},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+	r.Verbose = true
+
+	r.Run(context.Background())
+
+	expected := "[1/1] Echo... \n  [Echo] one\n  [Echo] two\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected output to start with %q, got %q", expected, out.String())
+	}
+	if strings.Contains(out.String(), "Output:") {
+		t.Errorf("streamed output should not be printed again, got %q", out.String())
+	}
+}
+
+func TestRunnerVerbosePhasesParallel(t *testing.T) {
+	cfg := &config.Config{}
+	for i := range 8 {
+		cfg.Checks = append(cfg.Checks, config.Check{
+			Name:    fmt.Sprintf("Check %d", i),
+			Command: "true",
+			Before:  []string{"echo before-" + strconv.Itoa(i) + " >/dev/null"},
+		})
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+	r.Verbose = true
+	r.Parallel = 4
+
+	r.Run(context.Background())
+
+	// Every before line is written whole, even with checks in parallel
+	for i := range 8 {
+		line := fmt.Sprintf("[before] echo before-%d >/dev/null\n", i)
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected %q in output, got %q", line, out.String())
+		}
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		max      int
+		expected string
+	}{
+		{name: "fits", output: "abc", max: 3, expected: "abc"},
+		{name: "ascii", output: "abcdef", max: 2, expected: "[... 4 bytes truncated]\nef"},
+		// "é" is 2 bytes; cutting inside it drops the whole character
+		{name: "mid rune", output: "aébc", max: 3, expected: "[... 3 bytes truncated]\nbc"},
+		{name: "rune boundary", output: "aébc", max: 4, expected: "[... 1 bytes truncated]\nébc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateOutput(tt.output, tt.max)
+			if got != tt.expected {
+				t.Errorf("truncateOutput(%q, %d) = %q, want %q", tt.output, tt.max, got, tt.expected)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateOutput(%q, %d) = %q is not valid UTF-8", tt.output, tt.max, got)
+			}
+		})
+	}
+}
+
+func TestPrintResultUnstreamedOutput(t *testing.T) {
+	r := NewRunner(&config.Config{}, "/tmp", config.TemplateVars{})
+	r.Verbose = true
+	r.NoColor = true
+
+	// Native probes never stream, so verbose mode prints their output
+	var out bytes.Buffer
+	r.printResult(&out, &engine.CheckResult{Outcome: engine.OutcomePass, Output: "SERVING\n"})
+	if !strings.Contains(out.String(), "  Output:\n    SERVING\n") {
+		t.Errorf("expected the probe's output, got %q", out.String())
+	}
+
+	out.Reset()
+	r.printResult(&out, &engine.CheckResult{Outcome: engine.OutcomePass, Output: "SERVING\n", Streamed: true})
+	if strings.Contains(out.String(), "SERVING") {
+		t.Errorf("expected streamed output to be left out, got %q", out.String())
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	lw := &lineWriter{mu: &mu, w: &out, prefix: "> "}
+
+	_, _ = lw.Write([]byte("par"))
+	if lw.Written() {
+		t.Error("partial line should not be forwarded before newline")
+	}
+	_, _ = lw.Write([]byte("tial\nfull\nrest"))
+	lw.Flush()
+
+	expected := "> partial\n> full\n> rest\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRunnerOnResultAndRetainOutput(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Big Output", Command: "printf 'aaaaaaaaaa-tail'"},
+			{Name: "Small Output", Command: "printf 'ok'"},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+	r.RetainOutputBytes = 5
+
+	var seen []string
+	r.OnResult = func(res CheckExecutionResult) {
+		seen = append(seen, res.Result.Output)
+	}
+
+	result := r.Run(context.Background())
+
+	if len(seen) != 2 || seen[0] != "aaaaaaaaaa-tail" {
+		t.Errorf("OnResult should see full output, got %q", seen)
+	}
+	if got := result.Results[0].Result.Output; got != "[... 10 bytes truncated]\n-tail" {
+		t.Errorf("expected truncated retained output, got %q", got)
+	}
+	if got := result.Results[1].Result.Output; got != "ok" {
+		t.Errorf("short output should be kept as-is, got %q", got)
+	}
+}
+
+func TestRunnerOnResultBeforeLayerEnds(t *testing.T) {
+	for _, parallel := range []int{1, 2} {
+		done := filepath.Join(t.TempDir(), "done")
+		cfg := &config.Config{
+			Checks: []config.Check{
+				{Name: "Fast", Command: "true"},
+				{Name: "Slow", Command: "sleep 0.5; touch " + done},
+			},
+		}
+
+		r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+		r.Output = &bytes.Buffer{}
+		r.Parallel = parallel
+
+		var streamed []string
+		r.OnResult = func(res CheckExecutionResult) {
+			if res.Check.Name == "Fast" {
+				if _, err := os.Stat(done); err == nil {
+					t.Errorf("parallel %d: OnResult for Fast ran after Slow finished", parallel)
+				}
+			}
+			streamed = append(streamed, res.Check.Name)
+		}
+
+		r.Run(context.Background())
+
+		if !slices.Equal(streamed, []string{"Fast", "Slow"}) {
+			t.Errorf("parallel %d: expected results streamed as checks complete, got %v", parallel, streamed)
+		}
+	}
+}
+
+func TestRunnerOnStartAndOnOutput(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Talker", Command: "echo one; echo token=s3cret; printf two"},
+			{Name: "Quiet", Command: "true"},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{Secret: map[string]string{"token": "s3cret"}})
+	r.Output = &bytes.Buffer{}
+
+	var events []string
+	r.OnStart = func(check *config.Check) {
+		events = append(events, "start "+check.Name)
+	}
+	r.OnOutput = func(check *config.Check, line string) {
+		events = append(events, check.Name+": "+line)
+	}
+	r.OnResult = func(res CheckExecutionResult) {
+		events = append(events, "done "+res.Check.Name)
+	}
+
+	r.Run(context.Background())
+
+	want := []string{"start Talker", "Talker: one", "Talker: token=***", "Talker: two", "done Talker", "start Quiet", "done Quiet"}
+	if !slices.Equal(events, want) {
+		t.Errorf("unexpected events:\n%q\nwant:\n%q", events, want)
+	}
+}
+
+func TestRunnerQuiet(t *testing.T) {
+	for _, parallel := range []int{1, 2} {
+		cfg := &config.Config{
+			Checks: []config.Check{
+				{Name: "Passing", Layer: 1, Command: "true"},
+				{Name: "Failing", Layer: 1, Command: "exit 1", Expect: &config.ExpectConfig{Gating: new(bool)}},
+				{Name: "Later", Layer: 2, Command: "true"},
+			},
+		}
+
+		var out bytes.Buffer
+		r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+		r.Output = &out
+		r.NoColor = true
+		r.Quiet = true
+		r.Parallel = parallel
+		r.MaxRetries = 0
+
+		result := r.Run(context.Background())
+		r.PrintSummary(result, "")
+
+		got := out.String()
+		if strings.Contains(got, "Passing") || strings.Contains(got, "Later") || strings.Contains(got, "--- Layer") {
+			t.Errorf("parallel=%d: quiet output should leave out passing checks and layers:\n%s", parallel, got)
+		}
+		if !strings.Contains(got, "Failing... FAIL") || !strings.Contains(got, "Summary: 2 passed, 1 failed") {
+			t.Errorf("parallel=%d: quiet output should keep failures and the summary:\n%s", parallel, got)
+		}
+	}
+}
+
+func TestBuildExecInPodCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     config.ExecInPodConfig
+		context  string
+		expected string
+	}{
+		{
+			name:     "pod",
+			spec:     config.ExecInPodConfig{Namespace: "media", Pod: "jellyfin-0", Command: "wget -qO- http://sonarr:8989/ping"},
+			expected: `kubectl -n media exec jellyfin-0 -- sh -c 'wget -qO- http://sonarr:8989/ping'`,
+		},
+		{
+			name:     "deployment target with container and context",
+			spec:     config.ExecInPodConfig{Pod: "deploy/web", Container: "app", Command: "true"},
+			context:  "home-admin",
+			expected: `kubectl --context=home-admin exec deploy/web -c app -- sh -c true`,
+		},
+		{
+			name:    "selector",
+			spec:    config.ExecInPodConfig{Namespace: "media", Selector: "app=jellyfin", Command: "true"},
+			context: "home-admin",
+			expected: `pod=$(kubectl --context=home-admin -n media get pods -l app=jellyfin -o jsonpath='{.items[0].metadata.name}' 2>/dev/null) && [ -n "$pod" ] || ` +
+				`{ echo no pod matches selector app=jellyfin; exit 2; }; ` +
+				`kubectl --context=home-admin -n media exec "$pod" -- sh -c true`,
+		},
+		{
+			name: "selector with shell metacharacters",
+			spec: config.ExecInPodConfig{Selector: `app=$(id)"`, Command: "true"},
+			expected: `pod=$(kubectl get pods -l 'app=$(id)"' -o jsonpath='{.items[0].metadata.name}' 2>/dev/null) && [ -n "$pod" ] || ` +
+				`{ echo no pod matches selector 'app=$(id)"'; exit 2; }; ` +
+				`kubectl exec "$pod" -- sh -c true`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildExecInPodCommand(&tt.spec, tt.context)
+			if got != tt.expected {
+				t.Errorf("expected:\n  %s\ngot:\n  %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunnerConsoleSummaryStyle(t *testing.T) {
+	cfg := &config.Config{
+		Summary: &config.SummaryConfig{
+			Console: &config.SummaryStyle{
+				Labels:  map[string]string{"WARN": "degraded"},
+				Buckets: []config.SummaryBucket{{Name: "attention", Outcomes: []string{"WARN", "SKIP"}}},
+			},
+		},
+		Checks: []config.Check{
+			{Name: "Warn", Command: "exit 4"},
+			{Name: "Skip", Command: "exit 3"},
+			{Name: "Pass", Command: "exit 0"},
+		},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+
+	result := r.Run(context.Background())
+	r.PrintSummary(result, "")
+
+	if !strings.Contains(out.String(), "Warn... "+engine.OutcomeWarn.Color()+"degraded") {
+		t.Errorf("expected custom label in output, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Buckets: 2 attention\n") {
+		t.Errorf("expected bucket totals in summary, got:\n%s", out.String())
+	}
+}
+
+func TestBuildSSHCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     config.SSHConfig
+		expected string
+	}{
+		{
+			name:     "host only",
+			spec:     config.SSHConfig{Host: "nas.lan", Command: "df -h /volume1"},
+			expected: `ssh -o BatchMode=yes -o ConnectTimeout=30 nas.lan -- 'df -h /volume1'`,
+		},
+		{
+			name:     "user port key",
+			spec:     config.SSHConfig{Host: "pve1", User: "root", Port: 2222, Key: "~/.ssh/smoke", Command: "pvecm status"},
+			expected: `ssh -o BatchMode=yes -o ConnectTimeout=30 -p 2222 -i ~/.ssh/smoke -o IdentitiesOnly=yes root@pve1 -- 'pvecm status'`,
+		},
+		{
+			name:     "jump host",
+			spec:     config.SSHConfig{Host: "10.0.20.5", User: "root", Jump: "admin@gateway.lan", Command: "uptime"},
+			expected: `ssh -o BatchMode=yes -o ConnectTimeout=30 -J admin@gateway.lan root@10.0.20.5 -- uptime`,
+		},
+		{
+			name: "pinned host key",
+			spec: config.SSHConfig{Host: "pve1", Port: 2222, HostKey: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5", Command: "uptime"},
+			expected: `known_hosts=$(mktemp) || exit 2; printf '%s\n' '[pve1]:2222 ssh-ed25519 AAAAC3NzaC1lZDI1NTE5' >"$known_hosts"; ` +
+				`ssh -o BatchMode=yes -o ConnectTimeout=30 -p 2222 -o UserKnownHostsFile="$known_hosts" -o GlobalKnownHostsFile=/dev/null -o StrictHostKeyChecking=yes pve1 -- uptime; ` +
+				`rc=$?; rm -f "$known_hosts"; exit $rc`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildSSHCommand(&tt.spec, 30*time.Second)
+			if got != tt.expected {
+				t.Errorf("expected:\n  %s\ngot:\n  %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAggregateHosts(t *testing.T) {
+	pass := exec.CommandResult{ExitCode: 0, Output: "ok\n"}
+	fail := exec.CommandResult{ExitCode: 1, Output: "degraded\n"}
+	warn := exec.CommandResult{ExitCode: 4}
+	skip := exec.CommandResult{ExitCode: 3}
+	down := exec.CommandResult{ExitCode: 255}
+
+	tests := []struct {
+		name      string
+		results   []exec.CommandResult
+		ratio     float64
+		passCodes []int
+		wantExit  int
+		wantErr   bool
+		wantTally string
+	}{
+		{name: "all pass", results: []exec.CommandResult{pass, pass}, ratio: 1, wantExit: 0, wantTally: "2/2 hosts passed"},
+		{name: "one fails", results: []exec.CommandResult{pass, fail}, ratio: 1, wantExit: 1, wantTally: "1/2 hosts passed"},
+		{name: "ratio met", results: []exec.CommandResult{pass, pass, fail}, ratio: 0.5, wantExit: 0, wantTally: "2/3 hosts passed (min_pass_ratio 0.5)"},
+		{name: "warn counts as passing", results: []exec.CommandResult{pass, warn}, ratio: 1, wantExit: 4},
+		{name: "skips left out", results: []exec.CommandResult{pass, skip}, ratio: 1, wantExit: 0, wantTally: "1/1 hosts passed"},
+		{name: "all skip", results: []exec.CommandResult{skip, skip}, ratio: 1, wantExit: 3},
+		{name: "unreachable only", results: []exec.CommandResult{pass, down}, ratio: 1, wantExit: 2, wantErr: true},
+		{name: "pass codes", results: []exec.CommandResult{fail, fail}, ratio: 1, passCodes: []int{1}, wantExit: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := make([]hostResult, len(tt.results))
+			for i, res := range tt.results {
+				hosts[i] = hostResult{host: fmt.Sprintf("node%d", i+1), result: res}
+			}
+			got := aggregateHosts(hosts, tt.ratio, tt.passCodes)
+			if got.ExitCode != tt.wantExit {
+				t.Errorf("exit code: expected %d, got %d\n%s", tt.wantExit, got.ExitCode, got.Output)
+			}
+			if (got.Error != nil) != tt.wantErr {
+				t.Errorf("error: expected %v, got %v", tt.wantErr, got.Error)
+			}
+			if !strings.Contains(got.Output, tt.wantTally) {
+				t.Errorf("output missing %q:\n%s", tt.wantTally, got.Output)
+			}
+		})
+	}
+
+	got := aggregateHosts([]hostResult{{host: "nas1", result: fail}}, 1, nil)
+	if want := "nas1: FAIL (exit 1)\n  degraded\n"; !strings.HasPrefix(got.Output, want) {
+		t.Errorf("expected per-host output %q, got:\n%s", want, got.Output)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	hosts := []string{"a", "b", "c"}
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(len(hosts))
+	go func() {
+		started.Wait()
+		close(release)
+	}()
+
+	results := fanOut(context.Background(), hosts, func(ctx context.Context, host string) exec.CommandResult {
+		started.Done()
+		<-release // every host must be running at once
+		return exec.CommandResult{Output: host}
+	})
+	for i, hr := range results {
+		if hr.host != hosts[i] || hr.result.Output != hosts[i] {
+			t.Errorf("result %d: expected %s, got %+v", i, hosts[i], hr)
+		}
+	}
+}
+
+func TestRunnerFileCheck(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "heartbeat")
+	if err := os.WriteFile(file, []byte("hello\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hello := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Fresh Small File", File: &config.FileConfig{Path: file, MaxAge: config.Duration{Duration: time.Hour}, MinSize: 1, MaxSize: 1024, SHA256: hello}},
+			{Name: "Proc Mounted", File: &config.FileConfig{Path: "/proc/", Mounted: true}},
+			{Name: "Free Space", File: &config.FileConfig{Path: dir, MinFree: 1, MinFreePercent: 1}},
+			{Name: "Too Small", File: &config.FileConfig{Path: file, MinSize: 1 << 20}},
+			{Name: "Not Mounted", File: &config.FileConfig{Path: dir, Mounted: true}},
+			{Name: "Missing", File: &config.FileConfig{Path: filepath.Join(dir, "missing")}},
+		},
+	}
+
+	r := NewRunner(cfg, dir, config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+	for _, res := range result.Results[:3] {
+		if !res.Result.IsPass() {
+			t.Errorf("%s: expected PASS, got %s: %s", res.Check.Name, res.Result.Outcome, res.Result.Output)
+		}
+	}
+	for _, res := range result.Results[3:] {
+		if res.Result.Outcome != engine.OutcomeFail {
+			t.Errorf("%s: expected FAIL, got %s: %s", res.Check.Name, res.Result.Outcome, res.Result.Output)
+		}
+	}
+	if out := result.Results[3].Result.Output; !strings.Contains(out, "FAIL size 6 bytes (min_size 1048576)") {
+		t.Errorf("expected size failure in output, got %q", out)
+	}
+}
+
+func TestRunnerSystemdCheck(t *testing.T) {
+	// Fake systemctl: only smbd is active and enabled
+	bin := t.TempDir()
+	fake := `#!/bin/sh
+quiet=; [ "$2" = --quiet ] && { quiet=1; set -- "$1" "$3"; }
+case "$1:$2" in
+  is-active:smbd) state=active ;;
+  is-enabled:smbd) state=enabled ;;
+  is-active:*) state=inactive ;;
+  *) state=disabled ;;
+esac
+[ -z "$quiet" ] && echo "$state"
+case "$state" in active|enabled) exit 0 ;; *) exit 3 ;; esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "systemctl"), []byte(fake), 0o755); err != nil { //nolint:gosec // Test helper must be executable
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Samba Running", Systemd: &config.SystemdConfig{Units: []string{"smbd"}, Enabled: true}},
+			{Name: "NUT Running", Systemd: &config.SystemdConfig{Units: []string{"smbd", "nut-server.service"}}},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+	if got := result.Results[0].Result; !got.IsPass() {
+		t.Errorf("expected PASS, got %s: %s", got.Outcome, got.Output)
+	}
+	got := result.Results[1].Result
+	if got.Outcome != engine.OutcomeFail {
+		t.Errorf("expected FAIL, got %s: %s", got.Outcome, got.Output)
+	}
+	for _, want := range []string{"ok   smbd active (active)", "FAIL nut-server.service active (inactive)"} {
+		if !strings.Contains(got.Output, want) {
+			t.Errorf("expected %q in output, got %q", want, got.Output)
+		}
+	}
+}
+
+func TestTimestampWriter(t *testing.T) {
+	var out bytes.Buffer
+	tw := NewTimestampWriter(&out)
+	tw.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	_, _ = fmt.Fprintf(tw, "[1/2] Check... ")
+	_, _ = fmt.Fprintf(tw, "PASS\n\n--- Layer 2 ---\n")
+
+	expected := "2026-01-02T03:04:05Z [1/2] Check... PASS\n" +
+		"2026-01-02T03:04:05Z \n" +
+		"2026-01-02T03:04:05Z --- Layer 2 ---\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, out.String())
+	}
+}
+
+func TestRunnerNoColor(t *testing.T) {
+	cfg := &config.Config{
+		Checks: []config.Check{{Name: "Fail", Command: "exit 1"}},
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &out
+	r.NoColor = true
+
+	result := r.Run(context.Background())
+	r.PrintSummary(result, "")
+
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("expected no ANSI codes, got %q", out.String())
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	var buf bytes.Buffer
+	if UseColor(&buf, false, false) {
+		t.Error("a non-terminal writer should not get colors")
+	}
+	if !UseColor(&buf, true, false) {
+		t.Error("force should enable colors")
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if UseColor(f, false, false) {
+		t.Error("a regular file should not get colors")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if UseColor(&buf, false, false) {
+		t.Error("NO_COLOR should disable colors")
+	}
+	if !UseColor(&buf, true, false) {
+		t.Error("force should override NO_COLOR")
+	}
+	if UseColor(&buf, false, true) {
+		t.Error("disable should disable colors")
+	}
+}
+
+func TestRunnerSandboxConfinesEveryCommand(t *testing.T) {
+	if res := exec.RunCommandOpts(context.Background(), "true", 5*time.Second, exec.Options{Sandbox: &exec.Sandbox{}}); res.Error != nil {
+		t.Skipf("sandbox unavailable: %v", res.Error)
+	}
+
+	// The jail has no shell, so every confined command fails to start; a
+	// command that escaped it would run on the host
+	marker := filepath.Join(t.TempDir(), "escaped")
+	sandbox := &config.SandboxConfig{Root: t.TempDir()}
+	cfg := &config.Config{
+		Checks: []config.Check{
+			{Name: "Skip If", Command: "true", SkipIf: "test -d /", Sandbox: sandbox},
+			{Name: "Before", Command: "true", Before: []string{"touch " + marker}, Sandbox: sandbox},
+			{Name: "Diagnostics", Command: "true", Diagnostics: []string{"touch " + marker}, Sandbox: sandbox},
+		},
+	}
+
+	r := NewRunner(cfg, "/tmp", config.TemplateVars{})
+	r.Output = &bytes.Buffer{}
+
+	result := r.Run(context.Background())
+	for _, res := range result.Results {
+		if res.Result.Outcome != engine.OutcomeError {
+			t.Errorf("%s: expected ERROR from the jail, got %s (%s)", res.Check.Name, res.Result.Outcome, res.Result.OutcomeReason)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("a sandboxed check's command ran on the host")
+	}
+}
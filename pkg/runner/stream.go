@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// lineWriter prefixes each complete line written to it and forwards it to
+// an underlying writer under a shared mutex, so streamed output from
+// concurrent checks interleaves only at line boundaries.
+type lineWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+
+	// leadingNewline starts the first line on a fresh line, for when the
+	// cursor sits after a progress prefix like "[1/3] Name... ".
+	leadingNewline bool
+
+	// redact, if set, masks secret values in each line.
+	redact func(string) string
+
+	// onLine, if set, receives each line instead of w.
+	onLine func(string)
+
+	buf     []byte
+	written bool
+}
+
+// Write buffers p and forwards every complete line.
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.buf = append(lw.buf, p...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		lw.emit(lw.buf[:i])
+		lw.buf = lw.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush forwards any trailing partial line.
+func (lw *lineWriter) Flush() {
+	if len(lw.buf) > 0 {
+		lw.emit(lw.buf)
+		lw.buf = nil
+	}
+}
+
+// Written reports whether any line has been forwarded.
+func (lw *lineWriter) Written() bool {
+	return lw.written
+}
+
+func (lw *lineWriter) emit(line []byte) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	text := string(line)
+	if lw.redact != nil {
+		text = lw.redact(text)
+	}
+	if lw.onLine != nil {
+		lw.onLine(text)
+		return
+	}
+
+	if !lw.written && lw.leadingNewline {
+		_, _ = io.WriteString(lw.w, "\n")
+	}
+	lw.written = true
+
+	_, _ = io.WriteString(lw.w, lw.prefix+text+"\n")
+}
+
+// TimestampWriter prefixes every line written through it with an RFC3339
+// timestamp, making logs correlatable with other system logs.
+type TimestampWriter struct {
+	w   io.Writer
+	now func() time.Time
+
+	mu      sync.Mutex
+	midLine bool
+}
+
+// NewTimestampWriter returns a TimestampWriter writing to w.
+func NewTimestampWriter(w io.Writer) *TimestampWriter {
+	return &TimestampWriter{w: w, now: time.Now}
+}
+
+// Write writes p, inserting a timestamp at the start of each line.
+func (tw *TimestampWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	written := 0
+	for len(p) > 0 {
+		if !tw.midLine {
+			if _, err := io.WriteString(tw.w, tw.now().Format(time.RFC3339)+" "); err != nil {
+				return written, err
+			}
+			tw.midLine = true
+		}
+
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i+1]
+			tw.midLine = false
+		}
+
+		n, err := tw.w.Write(line)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(line):]
+	}
+	return written, nil
+}
@@ -0,0 +1,119 @@
+// Package smoketest lets check authors test their scripts with go test:
+// a check runs through the real runner, so its exit code, output, and
+// postconditions are classified exactly as in a smoke run, and the test
+// asserts the outcome. Commands the script calls, like kubectl, can be
+// replaced with fakes to drive each code path.
+package smoketest
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/runner"
+)
+
+// Harness runs checks for a test.
+type Harness struct {
+	// Dir is the directory relative script paths resolve from, as the
+	// checks file's directory does in a run.
+	Dir string
+
+	// Vars are the template variables for the check.
+	Vars config.TemplateVars
+
+	// Timeout is the timeout for checks that don't set one.
+	Timeout time.Duration
+
+	// RetryDelay is the delay between retries for checks that don't set
+	// one, kept short so retrying checks don't slow tests down.
+	RetryDelay time.Duration
+
+	t testing.TB
+}
+
+// New returns a harness resolving scripts from dir.
+func New(t testing.TB, dir string) *Harness {
+	return &Harness{
+		Dir:        dir,
+		Vars:       config.TemplateVars{Cluster: "smoketest"},
+		Timeout:    30 * time.Second,
+		RetryDelay: 10 * time.Millisecond,
+		t:          t,
+	}
+}
+
+// Load returns the check with the given name or ID from a checks file,
+// with suite defaults applied, so tests exercise the check as configured.
+func (h *Harness) Load(path, nameOrID string) config.Check {
+	h.t.Helper()
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		h.t.Fatalf("smoketest: %v", err)
+	}
+	for _, check := range cfg.Checks {
+		if check.Name == nameOrID || check.GetID() == nameOrID {
+			return check
+		}
+	}
+	h.t.Fatalf("smoketest: no check %q in %s", nameOrID, path)
+	return config.Check{}
+}
+
+// Run runs a single check and returns its classified result. An invalid
+// check fails the test.
+func (h *Harness) Run(check config.Check) *engine.CheckResult {
+	h.t.Helper()
+	cfg := &config.Config{Checks: []config.Check{check}}
+	if err := cfg.Validate(); err != nil {
+		h.t.Fatalf("smoketest: invalid check %q: %v", check.Name, err)
+	}
+
+	r := runner.NewRunner(cfg, h.Dir, h.Vars)
+	r.DefaultTimeout = h.Timeout
+	r.RetryDelay = h.RetryDelay
+	r.NoColor = true
+	r.Output = io.Discard
+
+	result := r.Run(context.Background())
+	if len(result.Results) != 1 {
+		h.t.Fatalf("smoketest: check %q did not run", check.Name)
+	}
+	return result.Results[0].Result
+}
+
+// Expect runs a check and fails the test unless it classifies as want,
+// reporting the reason, exit code, and output. Returns the result for
+// further assertions.
+func (h *Harness) Expect(check config.Check, want engine.Outcome) *engine.CheckResult {
+	h.t.Helper()
+	result := h.Run(check)
+	if result.Outcome != want {
+		h.t.Errorf("check %q: outcome %s, want %s\nreason: %s\nexit code: %d\noutput:\n%s",
+			check.Name, result.Outcome, want, result.OutcomeReason, result.ExitCode, strings.TrimRight(result.Output, "\n"))
+	}
+	return result
+}
+
+// Fake puts an executable named name on PATH for the rest of the test,
+// running the given shell script body, e.g. Fake("kubectl", `echo
+// '{"items":[]}'`) to stub a cluster response. Arguments are available as
+// "$@".
+func (h *Harness) Fake(name, script string) {
+	h.t.Helper()
+	dir := filepath.Join(h.t.TempDir(), "fake-"+name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		h.t.Fatalf("smoketest: %v", err)
+	}
+	body := "#!/bin/sh\n" + script + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o755); err != nil { //nolint:gosec // Fakes must be executable
+		h.t.Fatalf("smoketest: %v", err)
+	}
+	h.t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
@@ -0,0 +1,71 @@
+package smoketest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erauner/homelab-smoke/pkg/config"
+	"github.com/erauner/homelab-smoke/pkg/engine"
+	"github.com/erauner/homelab-smoke/pkg/validate"
+)
+
+// podsReady is a check script in the usual style: it asks kubectl and
+// maps the answer onto the exit code contract.
+const podsReady = `#!/bin/sh
+out=$(kubectl get pods -n "$1" --no-headers 2>&1) || { echo "kubectl failed: $out"; exit 2; }
+[ -z "$out" ] && { echo "no pods"; exit 3; }
+echo "$out" | grep -v Running && exit 1
+echo "all pods running"
+`
+
+func TestHarness(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pods-ready.sh"), []byte(podsReady), 0o755); err != nil { //nolint:gosec // Test script must be executable
+		t.Fatal(err)
+	}
+	check := config.Check{
+		Name:     "Pods Ready",
+		Script:   &config.ScriptConfig{Path: "pods-ready.sh", Args: []string{"media"}},
+		Validate: &validate.Validation{Contains: "running"},
+	}
+
+	tests := []struct {
+		name    string
+		kubectl string
+		want    engine.Outcome
+	}{
+		{"all running", `echo "jellyfin-0   1/1   Running   0   1d"`, engine.OutcomePass},
+		{"crashlooping", `echo "jellyfin-0   0/1   CrashLoopBackOff   7   1d"`, engine.OutcomeFail},
+		{"empty namespace", `true`, engine.OutcomeSkip},
+		{"api down", `echo "connection refused" >&2; exit 1`, engine.OutcomeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(t, dir)
+			h.Fake("kubectl", tt.kubectl)
+			h.Expect(check, tt.want)
+		})
+	}
+}
+
+func TestHarnessLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checks.yaml")
+	content := `defaults:
+  retry: true
+checks:
+  - name: "Flaky Until Marker"
+    command: "test -f marker || { touch marker; exit 1; }"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	h := New(t, dir)
+	check := h.Load(path, "flaky-until-marker")
+	if result := h.Expect(check, engine.OutcomePass); result.RetryCount != 1 {
+		t.Errorf("expected 1 retry from the suite default, got %d", result.RetryCount)
+	}
+}
@@ -0,0 +1,147 @@
+// Package transform provides post-processing steps for check output.
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	osexec "os/exec"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Step is one output transformation. Exactly one field is set. Flag steps
+// may also be written as a bare name (e.g., "- strip_ansi").
+type Step struct {
+	// StripANSI removes ANSI escape sequences (colors, cursor movement).
+	StripANSI bool `yaml:"strip_ansi,omitempty"`
+
+	// JSONPretty re-indents JSON output.
+	JSONPretty bool `yaml:"json_pretty,omitempty"`
+
+	// Head keeps the first N lines.
+	Head int `yaml:"head,omitempty"`
+
+	// JQ filters JSON output through a jq expression, with raw string
+	// output (jq -r). Requires jq on PATH.
+	JQ string `yaml:"jq,omitempty"`
+}
+
+// ansiPattern matches CSI sequences and OSC sequences (e.g., hyperlinks).
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// UnmarshalYAML implements yaml.Unmarshaler for Step, accepting a bare
+// name for flag steps.
+func (s *Step) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		switch value.Value {
+		case "strip_ansi":
+			s.StripANSI = true
+		case "json_pretty":
+			s.JSONPretty = true
+		default:
+			// A TypeError lets decoding continue and report every problem
+			msg := fmt.Sprintf("line %d: unknown transform %q", value.Line, value.Value)
+			return &yaml.TypeError{Errors: []string{msg}}
+		}
+		return nil
+	}
+
+	type plain Step
+	return value.Decode((*plain)(s))
+}
+
+// Name returns the step's YAML name.
+func (s *Step) Name() string {
+	switch {
+	case s.StripANSI:
+		return "strip_ansi"
+	case s.JSONPretty:
+		return "json_pretty"
+	case s.Head != 0:
+		return "head"
+	case s.JQ != "":
+		return "jq"
+	}
+	return ""
+}
+
+// Validate checks that exactly one operation is set with a usable value.
+func (s *Step) Validate() error {
+	set := 0
+	for _, on := range []bool{s.StripANSI, s.JSONPretty, s.Head != 0, s.JQ != ""} {
+		if on {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		return fmt.Errorf("transform step has no operation")
+	case set > 1:
+		return fmt.Errorf("transform step must have exactly one operation")
+	case s.Head < 0:
+		return fmt.Errorf("transform head must be positive, got %d", s.Head)
+	}
+	return nil
+}
+
+// Apply runs the steps over output in order. On error the output so far
+// is returned along with the failing step's error.
+func Apply(ctx context.Context, output string, steps []Step) (string, error) {
+	for i := range steps {
+		step := &steps[i]
+		next, err := step.apply(ctx, output)
+		if err != nil {
+			return output, fmt.Errorf("transform %s: %w", step.Name(), err)
+		}
+		output = next
+	}
+	return output, nil
+}
+
+// apply runs a single step.
+func (s *Step) apply(ctx context.Context, output string) (string, error) {
+	switch {
+	case s.StripANSI:
+		return ansiPattern.ReplaceAllString(output, ""), nil
+	case s.JSONPretty:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(strings.TrimSpace(output)), "", "  "); err != nil {
+			return "", fmt.Errorf("invalid JSON: %w", err)
+		}
+		return buf.String() + "\n", nil
+	case s.Head > 0:
+		lines := strings.SplitAfter(output, "\n")
+		if len(lines) <= s.Head {
+			return output, nil
+		}
+		return strings.Join(lines[:s.Head], ""), nil
+	case s.JQ != "":
+		cmd := osexec.CommandContext(ctx, "jq", "-r", s.JQ)
+		cmd.Stdin = strings.NewReader(output)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("%w: %s", err, msg)
+			}
+			return "", err
+		}
+		return string(out), nil
+	}
+	return output, nil
+}
+
+// NeedsJQ returns whether any step runs jq.
+func NeedsJQ(steps []Step) bool {
+	for _, s := range steps {
+		if s.JQ != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package transform
+
+import (
+	"context"
+	osexec "os/exec"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		steps    []Step
+		expected string
+		errMsg   string
+	}{
+		{
+			name:     "strip ansi",
+			output:   "\x1b[32mok\x1b[0m done\x1b]8;;https://x\x07link\x1b]8;;\x07\n",
+			steps:    []Step{{StripANSI: true}},
+			expected: "ok donelink\n",
+		},
+		{
+			name:     "json pretty",
+			output:   `{"a":1,"b":[true]}` + "\n",
+			steps:    []Step{{JSONPretty: true}},
+			expected: "{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}\n",
+		},
+		{
+			name:   "json pretty invalid",
+			output: "not json",
+			steps:  []Step{{JSONPretty: true}},
+			errMsg: "transform json_pretty: invalid JSON",
+		},
+		{
+			name:     "head",
+			output:   "1\n2\n3\n4\n",
+			steps:    []Step{{Head: 2}},
+			expected: "1\n2\n",
+		},
+		{
+			name:     "head longer than output",
+			output:   "1\n2\n",
+			steps:    []Step{{Head: 5}},
+			expected: "1\n2\n",
+		},
+		{
+			name:     "chained",
+			output:   "\x1b[1mline one\x1b[0m\nline two\n",
+			steps:    []Step{{StripANSI: true}, {Head: 1}},
+			expected: "line one\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(context.Background(), tt.output, tt.steps)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplyJQ(t *testing.T) {
+	if _, err := osexec.LookPath("jq"); err != nil {
+		t.Skip("jq not installed")
+	}
+
+	got, err := Apply(context.Background(), `{"items":[{"name":"a"},{"name":"b"}]}`, []Step{{JQ: ".items[].name"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "a\nb\n" {
+		t.Errorf("expected raw names, got %q", got)
+	}
+
+	if _, err := Apply(context.Background(), "not json", []Step{{JQ: "."}}); err == nil {
+		t.Error("expected error for invalid JSON input")
+	}
+}
+
+func TestStepUnmarshalYAML(t *testing.T) {
+	var steps []Step
+	input := "- strip_ansi\n- head: 3\n- jq: .status\n- json_pretty: true\n"
+	if err := yaml.Unmarshal([]byte(input), &steps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for i := range steps {
+		if err := steps[i].Validate(); err != nil {
+			t.Errorf("step %d: %v", i, err)
+		}
+		names = append(names, steps[i].Name())
+	}
+	if got := strings.Join(names, ","); got != "strip_ansi,head,jq,json_pretty" {
+		t.Errorf("unexpected steps: %s", got)
+	}
+
+	if err := yaml.Unmarshal([]byte("- squash\n"), &steps); err == nil {
+		t.Error("expected error for unknown transform")
+	}
+}
+
+func TestStepValidate(t *testing.T) {
+	tests := []struct {
+		step   Step
+		errMsg string
+	}{
+		{step: Step{}, errMsg: "no operation"},
+		{step: Step{StripANSI: true, Head: 1}, errMsg: "exactly one"},
+		{step: Step{Head: -1}, errMsg: "must be positive"},
+	}
+	for _, tt := range tests {
+		if err := tt.step.Validate(); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// maxScriptSteps bounds a script's execution so a runaway loop can't hang
+// the run.
+const maxScriptSteps = 10_000_000
+
+// scriptOptions allows if/for/while at the top level, so scripts needn't
+// wrap their logic in a function.
+var scriptOptions = &syntax.FileOptions{TopLevelControl: true, GlobalReassign: true, Set: true, While: true}
+
+// ScriptEnv is what a validation script can see.
+type ScriptEnv struct {
+	// Output is the check's (transformed) output.
+	Output string
+
+	// ExitCode is the command's exit code.
+	ExitCode int
+
+	// Duration is how long the command took.
+	Duration time.Duration
+
+	// Name, ID, Layer, and Tags describe the check.
+	Name  string
+	ID    string
+	Layer int
+	Tags  []string
+}
+
+// scriptGlobals lists the names predeclared for scripts.
+var scriptGlobals = []string{"output", "exit_code", "duration", "check", "json"}
+
+// CompileScript parses and resolves a validation script, reporting syntax
+// errors and undefined names before any check runs.
+func CompileScript(src string) error {
+	_, _, err := starlark.SourceProgramOptions(scriptOptions, "validate.script", src, isScriptGlobal)
+	return err
+}
+
+// Script runs a Starlark validation script. The script sees output,
+// exit_code, duration (seconds), check (name, id, layer, tags), and the
+// json module; calling fail("message") fails validation with that
+// message, and finishing normally passes. Any other error also fails.
+func Script(ctx context.Context, src string, env ScriptEnv) error {
+	_, prog, err := starlark.SourceProgramOptions(scriptOptions, "validate.script", src, isScriptGlobal)
+	if err != nil {
+		return fmt.Errorf("validate.script: %w", err)
+	}
+
+	tags := make([]starlark.Value, len(env.Tags))
+	for i, tag := range env.Tags {
+		tags[i] = starlark.String(tag)
+	}
+	predeclared := starlark.StringDict{
+		"output":    starlark.String(env.Output),
+		"exit_code": starlark.MakeInt(env.ExitCode),
+		"duration":  starlark.Float(env.Duration.Seconds()),
+		"check": starlarkstruct.FromStringDict(starlark.String("check"), starlark.StringDict{
+			"name":  starlark.String(env.Name),
+			"id":    starlark.String(env.ID),
+			"layer": starlark.MakeInt(env.Layer),
+			"tags":  starlark.NewList(tags),
+		}),
+		"json": json.Module,
+	}
+
+	thread := &starlark.Thread{Name: "validate.script"}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	if _, err := prog.Init(thread, predeclared); err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			if msg, ok := strings.CutPrefix(evalErr.Msg, "fail: "); ok {
+				return errors.New(msg)
+			}
+			return fmt.Errorf("validate.script: %s", evalErr.Msg)
+		}
+		return fmt.Errorf("validate.script: %w", err)
+	}
+	return nil
+}
+
+// isScriptGlobal reports whether name is predeclared for scripts.
+func isScriptGlobal(name string) bool {
+	for _, g := range scriptGlobals {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
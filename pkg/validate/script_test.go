@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScript(t *testing.T) {
+	env := ScriptEnv{
+		Output:   `{"items": [{"name": "n1", "ready": true}, {"name": "n2", "ready": false}]}`,
+		ExitCode: 0,
+		Duration: 1500 * time.Millisecond,
+		Name:     "Nodes Ready",
+		ID:       "nodes-ready",
+		Layer:    2,
+		Tags:     []string{"k8s"},
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name:   "passes by finishing",
+			script: "nodes = json.decode(output)[\"items\"]\nif len(nodes) != 2:\n    fail(\"expected 2 nodes\")",
+		},
+		{
+			name:    "fail message",
+			script:  "ready = [n[\"name\"] for n in json.decode(output)[\"items\"] if n[\"ready\"]]\nif len(ready) < 2:\n    fail(\"only %d of 2 nodes ready\" % len(ready))",
+			wantErr: "only 1 of 2 nodes ready",
+		},
+		{
+			name:   "metadata",
+			script: "if check.id != \"nodes-ready\" or check.layer != 2 or \"k8s\" not in check.tags or exit_code != 0 or duration != 1.5:\n    fail(\"bad metadata\")",
+		},
+		{
+			name:    "runtime error",
+			script:  "x = json.decode(\"not json\")",
+			wantErr: "validate.script: json.decode",
+		},
+		{
+			name:    "runaway loop",
+			script:  "while True:\n    pass",
+			wantErr: "too many steps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Script(context.Background(), tt.script, env)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCompileScript(t *testing.T) {
+	if err := CompileScript("if len(output) == 0:\n    fail(\"empty\")"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := CompileScript("fail(outptu)"); err == nil || !strings.Contains(err.Error(), "undefined: outptu") {
+		t.Errorf("expected undefined name error, got %v", err)
+	}
+	if err := CompileScript("if :"); err == nil {
+		t.Error("expected syntax error")
+	}
+}
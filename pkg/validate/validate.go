@@ -17,9 +17,14 @@ type Validation struct {
 
 	// Regex requires the output to match this regular expression.
 	Regex string `yaml:"regex,omitempty"`
+
+	// Script is a Starlark script for assertions too complex for the
+	// above; it fails validation by calling fail("message"). See Script.
+	Script string `yaml:"script,omitempty"`
 }
 
-// Output checks if the output satisfies all validation postconditions.
+// Output checks if the output satisfies the contains, not_contains, and
+// regex postconditions (the script needs the run's context; see Script).
 // Returns a slice of errors for each failed validation.
 // An empty slice means all validations passed.
 func Output(output string, v *Validation) []error {
@@ -61,5 +66,5 @@ func (v *Validation) IsEmpty() bool {
 	if v == nil {
 		return true
 	}
-	return v.Contains == "" && v.NotContains == "" && v.Regex == ""
+	return v.Contains == "" && v.NotContains == "" && v.Regex == "" && v.Script == ""
 }
@@ -122,6 +122,11 @@ func TestValidationIsEmpty(t *testing.T) {
 			validation: &Validation{Regex: ".*"},
 			expected:   false,
 		},
+		{
+			name:       "has script",
+			validation: &Validation{Script: "pass"},
+			expected:   false,
+		},
 	}
 
 	for _, tt := range tests {